@@ -1,8 +1,20 @@
 package main
 
 import (
-	"gobase/pkg/config"
+	"context"
 	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/config"
+	"gobase/pkg/logger"
+	loggerTypes "gobase/pkg/logger/types"
+	"gobase/pkg/monitor/prometheus/collector"
+	"gobase/pkg/queue"
+	"gobase/pkg/queue/worker"
+	"gobase/pkg/scheduler"
 )
 
 func main() {
@@ -10,6 +22,125 @@ func main() {
 	if err := config.Init(); err != nil {
 		log.Fatalf("Failed to initialize config: %v", err)
 	}
+	cfg := config.GetConfig()
+
+	// 初始化日志
+	logger, err := logger.NewLogger()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	// 初始化Redis客户端
+	client, err := newRedisClient(&cfg.Redis, logger)
+	if err != nil {
+		log.Fatalf("Failed to initialize redis client: %v", err)
+	}
+	defer client.Close()
+
+	// 初始化作业队列
+	queueOpts := []queue.Option{queue.WithLogger(logger)}
+	if cfg.Worker.VisibilityTimeout > 0 {
+		queueOpts = append(queueOpts, queue.WithVisibilityTimeout(cfg.Worker.VisibilityTimeout))
+	}
+	if cfg.Worker.MaxRetries != nil {
+		queueOpts = append(queueOpts, queue.WithMaxRetries(*cfg.Worker.MaxRetries))
+	}
+	q, err := queue.NewQueue(client, queueOpts...)
+	if err != nil {
+		log.Fatalf("Failed to initialize job queue: %v", err)
+	}
+
+	// 初始化工作器
+	workerOpts := []worker.Option{worker.WithLogger(logger)}
+	if len(cfg.Worker.Queues) > 0 {
+		workerOpts = append(workerOpts, worker.WithQueues(cfg.Worker.Queues...))
+	}
+	if cfg.Worker.Concurrency > 0 {
+		workerOpts = append(workerOpts, worker.WithConcurrency(cfg.Worker.Concurrency))
+	}
+	if cfg.Worker.PollInterval > 0 {
+		workerOpts = append(workerOpts, worker.WithPollInterval(cfg.Worker.PollInterval))
+	}
+	if cfg.Worker.JobTimeout > 0 {
+		workerOpts = append(workerOpts, worker.WithJobTimeout(cfg.Worker.JobTimeout))
+	}
+	if cfg.Worker.VisibilityTimeout > 0 {
+		// 每个可见性超时内续期三次，单次续期失败不会导致作业被重新投递
+		workerOpts = append(workerOpts, worker.WithHeartbeatInterval(cfg.Worker.VisibilityTimeout/3))
+	}
+	w, err := worker.New(q, workerOpts...)
+	if err != nil {
+		log.Fatalf("Failed to initialize worker: %v", err)
+	}
 
-	// ... 其他初始化代码 ...
+	// 初始化定时任务调度器
+	businessMetrics := collector.NewBusinessCollector("gobase")
+	if err := businessMetrics.Register(); err != nil {
+		logger.Warn(context.Background(), "Failed to register business metrics", loggerTypes.Error(err))
+	}
+	sched, err := scheduler.New(client,
+		scheduler.WithLogger(logger),
+		scheduler.WithMetrics(businessMetrics),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize scheduler: %v", err)
+	}
+
+	// 业务作业处理器和定时任务在启动前注册，见 pkg/queue/README.md
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := w.Start(ctx); err != nil {
+		log.Fatalf("Failed to start worker: %v", err)
+	}
+	if err := sched.Start(ctx); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+
+	// 等待退出信号后优雅关闭
+	<-ctx.Done()
+
+	shutdownTimeout := cfg.Worker.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := sched.Stop(shutdownCtx); err != nil {
+		logger.Error(shutdownCtx, "Scheduler stop failed", loggerTypes.Error(err))
+	}
+	if err := w.Shutdown(shutdownCtx); err != nil {
+		logger.Error(shutdownCtx, "Worker shutdown failed", loggerTypes.Error(err))
+	}
+}
+
+// newRedisClient 根据配置创建Redis客户端
+func newRedisClient(cfg *config.RedisConfig, logger loggerTypes.Logger) (redis.Client, error) {
+	opts := []redis.Option{
+		redis.WithAddresses(cfg.Addresses),
+		redis.WithUsername(cfg.Username),
+		redis.WithPassword(cfg.Password),
+		redis.WithDB(cfg.Database),
+		redis.WithLogger(logger),
+		redis.WithEnableMetrics(cfg.EnableMetrics),
+		redis.WithEnableTracing(cfg.EnableTracing),
+	}
+	if cfg.PoolSize > 0 {
+		opts = append(opts, redis.WithPoolSize(cfg.PoolSize))
+	}
+	if cfg.MaxRetries != nil {
+		opts = append(opts, redis.WithMaxRetries(*cfg.MaxRetries))
+	}
+	if cfg.DialTimeout > 0 {
+		opts = append(opts, redis.WithDialTimeout(cfg.DialTimeout))
+	}
+	if cfg.ReadTimeout > 0 {
+		opts = append(opts, redis.WithReadTimeout(cfg.ReadTimeout))
+	}
+	if cfg.WriteTimeout > 0 {
+		opts = append(opts, redis.WithWriteTimeout(cfg.WriteTimeout))
+	}
+	return redis.NewClient(opts...)
 }
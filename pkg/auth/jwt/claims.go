@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"fmt"
 	"time"
 
 	"gobase/pkg/errors"
@@ -46,6 +47,32 @@ type Claims interface {
 	GetExpiresAt() time.Time
 }
 
+// ClaimsFactory 创建空的Claims对象，供 TokenManager 解析令牌使用
+type ClaimsFactory func() Claims
+
+// ParseInto 验证令牌并解析为应用自定义的claims类型
+// C 通常是嵌入 StandardClaims 的结构体，例如 jwt.ParseInto[TenantClaims](ctx, tm, tokenString) 返回 *TenantClaims
+func ParseInto[C any, PC interface {
+	*C
+	Claims
+}](ctx context.Context, tm *TokenManager, tokenString string) (PC, error) {
+	claims := PC(new(C))
+	if _, err := tm.validateInto(ctx, tokenString, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ClaimsAs 将Claims转换为具体类型，类型不符时返回 ClaimsInvalid 错误
+func ClaimsAs[C Claims](claims Claims) (C, error) {
+	typed, ok := claims.(C)
+	if !ok {
+		var zero C
+		return zero, errors.NewClaimsInvalidError(fmt.Sprintf("unexpected claims type %T", claims), nil)
+	}
+	return typed, nil
+}
+
 // StandardClaims 标准Claims实现
 type StandardClaims struct {
 	jwt.RegisteredClaims
@@ -57,6 +84,13 @@ type StandardClaims struct {
 	IPAddress   string    `json:"ip_address"`
 	TokenType   TokenType `json:"token_type"`
 	TokenID     string    `json:"token_id"`
+	// FamilyID 令牌族ID，同一次登录签发及其后刷新得到的令牌属于同一族
+	FamilyID string `json:"family_id,omitempty"`
+	// ClientID 令牌签发给的客户端，令牌吊销端点据此检查请求客户端
+	ClientID string `json:"client_id,omitempty"`
+
+	// leeway 验证过期时间时允许的时钟偏差，由 TokenManager 在解析前设置
+	leeway time.Duration
 }
 
 // NewStandardClaims 创建标准Claims
@@ -119,6 +153,16 @@ func (c *StandardClaims) GetTokenID() string {
 	return c.TokenID
 }
 
+// GetFamilyID 获取令牌族ID
+func (c *StandardClaims) GetFamilyID() string {
+	return c.FamilyID
+}
+
+// GetClientID 获取令牌签发给的客户端ID
+func (c *StandardClaims) GetClientID() string {
+	return c.ClientID
+}
+
 // Validate 验证Claims
 func (c *StandardClaims) Validate() error {
 	// 验证必填字段
@@ -127,7 +171,7 @@ func (c *StandardClaims) Validate() error {
 	}
 
 	// 验证过期时间
-	if c.ExpiresAt != nil && time.Now().After(c.ExpiresAt.Time) {
+	if c.ExpiresAt != nil && time.Now().After(c.ExpiresAt.Time.Add(c.leeway)) {
 		return ErrClaimsExpired
 	}
 
@@ -139,6 +183,16 @@ func (c *StandardClaims) Validate() error {
 	return nil
 }
 
+// setLeeway 设置验证过期时间时允许的时钟偏差
+func (c *StandardClaims) setLeeway(leeway time.Duration) {
+	c.leeway = leeway
+}
+
+// standardClaims 返回嵌入的 StandardClaims，签发令牌对时通过它设置令牌类型、ID和有效期
+func (c *StandardClaims) standardClaims() *StandardClaims {
+	return c
+}
+
 // SetExpiresAt 实现Claims接口的SetExpiresAt方法
 func (c *StandardClaims) SetExpiresAt(t time.Time) {
 	c.ExpiresAt = jwt.NewNumericDate(t)
@@ -190,6 +244,13 @@ func WithDeviceID(deviceID string) ClaimsOption {
 	}
 }
 
+// WithClientID 设置令牌签发给的客户端ID
+func WithClientID(clientID string) ClaimsOption {
+	return func(c *StandardClaims) {
+		c.ClientID = clientID
+	}
+}
+
 // WithIPAddress 设置IP地址
 func WithIPAddress(ip string) ClaimsOption {
 	return func(c *StandardClaims) {
@@ -211,6 +272,13 @@ func WithTokenID(tokenID string) ClaimsOption {
 	}
 }
 
+// WithFamilyID 设置令牌族ID
+func WithFamilyID(familyID string) ClaimsOption {
+	return func(c *StandardClaims) {
+		c.FamilyID = familyID
+	}
+}
+
 // WithExpiresAt 设置过期时间
 func WithExpiresAt(expiresAt time.Time) ClaimsOption {
 	return func(c *StandardClaims) {
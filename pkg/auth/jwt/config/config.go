@@ -20,6 +20,13 @@ type Config struct {
 	AccessTokenExpiration  time.Duration `json:"access_token_expiration" yaml:"access_token_expiration"`
 	RefreshTokenExpiration time.Duration `json:"refresh_token_expiration" yaml:"refresh_token_expiration"`
 
+	// 声明验证配置
+	Issuers        []string      `json:"issuers" yaml:"issuers"`                 // 可接受的签发者，为空时不检查
+	Audiences      []string      `json:"audiences" yaml:"audiences"`             // 本服务的受众标识，为空时不检查
+	Leeway         time.Duration `json:"leeway" yaml:"leeway"`                   // 允许的时钟偏差
+	MaxTokenAge    time.Duration `json:"max_token_age" yaml:"max_token_age"`     // 自签发起的最长有效时间，为0时不检查
+	RequiredClaims []string      `json:"required_claims" yaml:"required_claims"` // 必须包含的声明
+
 	// 黑名单配置
 	BlacklistEnabled bool   `json:"blacklist_enabled" yaml:"blacklist_enabled"`
 	BlacklistType    string `json:"blacklist_type" yaml:"blacklist_type"` // memory or redis
@@ -71,3 +78,15 @@ func DefaultConfig() *Config {
 		EnableTracing:          true,
 	}
 }
+
+// TokenManagerOptions 返回按配置创建 TokenManager 的选项，包括令牌有效期和声明验证
+func (c *Config) TokenManagerOptions() []jwt.TokenManagerOption {
+	return []jwt.TokenManagerOption{
+		jwt.WithTokenExpirations(c.AccessTokenExpiration, c.RefreshTokenExpiration),
+		jwt.WithIssuers(c.Issuers...),
+		jwt.WithAudiences(c.Audiences...),
+		jwt.WithLeeway(c.Leeway),
+		jwt.WithMaxTokenAge(c.MaxTokenAge),
+		jwt.WithRequiredClaims(c.RequiredClaims...),
+	}
+}
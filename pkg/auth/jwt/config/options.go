@@ -44,6 +44,29 @@ func WithRefreshTokenExpiration(d time.Duration) Option {
 	}
 }
 
+// WithClaimsValidation 配置签发者、受众和必需声明的验证
+func WithClaimsValidation(issuers, audiences, requiredClaims []string) Option {
+	return func(c *Config) {
+		c.Issuers = issuers
+		c.Audiences = audiences
+		c.RequiredClaims = requiredClaims
+	}
+}
+
+// WithLeeway 设置允许的时钟偏差
+func WithLeeway(d time.Duration) Option {
+	return func(c *Config) {
+		c.Leeway = d
+	}
+}
+
+// WithMaxTokenAge 设置令牌自签发起的最长有效时间
+func WithMaxTokenAge(d time.Duration) Option {
+	return func(c *Config) {
+		c.MaxTokenAge = d
+	}
+}
+
 // WithBlacklist 配置黑名单
 func WithBlacklist(enabled bool, typ string) Option {
 	return func(c *Config) {
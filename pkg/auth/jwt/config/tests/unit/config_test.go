@@ -46,6 +46,21 @@ func TestConfigOptions(t *testing.T) {
 				assert.Equal(t, "private-key", c.PrivateKey)
 			},
 		},
+		{
+			name: "WithClaimsValidation",
+			options: []config.Option{
+				config.WithClaimsValidation([]string{"auth-service"}, []string{"orders"}, []string{"exp"}),
+				config.WithLeeway(30 * time.Second),
+				config.WithMaxTokenAge(time.Hour),
+			},
+			validate: func(t *testing.T, c *config.Config) {
+				assert.Equal(t, []string{"auth-service"}, c.Issuers)
+				assert.Equal(t, []string{"orders"}, c.Audiences)
+				assert.Equal(t, []string{"exp"}, c.RequiredClaims)
+				assert.Equal(t, 30*time.Second, c.Leeway)
+				assert.Equal(t, time.Hour, c.MaxTokenAge)
+			},
+		},
 		// ... 测试其他配置选项
 	}
 
@@ -2,8 +2,12 @@ package crypto
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/rsa"
+	"math/big"
 
 	"gobase/pkg/auth/jwt"
 	"gobase/pkg/errors"
@@ -137,6 +141,203 @@ func (r *RSA) Name() jwt.SigningMethod {
 	return r.method
 }
 
+// RSAPSS 实现RSA-PSS系列算法
+type RSAPSS struct {
+	hash   crypto.Hash
+	method jwt.SigningMethod
+}
+
+// NewRSAPSS 创建RSA-PSS算法实例
+func NewRSAPSS(method jwt.SigningMethod) (Algorithm, error) {
+	var hash crypto.Hash
+
+	switch method {
+	case jwt.PS256:
+		hash = crypto.SHA256
+	case jwt.PS384:
+		hash = crypto.SHA384
+	case jwt.PS512:
+		hash = crypto.SHA512
+	default:
+		return nil, errors.NewAlgorithmMismatchError("unsupported RSA-PSS method", nil)
+	}
+
+	return &RSAPSS{
+		hash:   hash,
+		method: method,
+	}, nil
+}
+
+// Sign 使用RSA-PSS算法签名，盐长度等于哈希长度
+func (r *RSAPSS) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.NewKeyInvalidError("RSA-PSS key must be *rsa.PrivateKey", nil)
+	}
+
+	hasher := r.hash.New()
+	hasher.Write(data)
+
+	signature, err := rsa.SignPSS(rand.Reader, privateKey, r.hash, hasher.Sum(nil), &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	})
+	if err != nil {
+		return nil, errors.NewSignatureInvalidError("RSA-PSS signing failed", err)
+	}
+
+	return signature, nil
+}
+
+// Verify 验证RSA-PSS签名，自动识别盐长度
+func (r *RSAPSS) Verify(data []byte, signature []byte, key interface{}) error {
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return errors.NewKeyInvalidError("RSA-PSS key must be *rsa.PublicKey", nil)
+	}
+
+	hasher := r.hash.New()
+	hasher.Write(data)
+
+	err := rsa.VerifyPSS(publicKey, r.hash, hasher.Sum(nil), signature, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthAuto,
+	})
+	if err != nil {
+		return errors.NewSignatureInvalidError("RSA-PSS signature verification failed", err)
+	}
+
+	return nil
+}
+
+// Name 获取算法名称
+func (r *RSAPSS) Name() jwt.SigningMethod {
+	return r.method
+}
+
+// ECDSA 实现ECDSA系列算法，签名为定长的 r||s (RFC 7518 3.4)
+type ECDSA struct {
+	hash      crypto.Hash
+	curveBits int
+	method    jwt.SigningMethod
+}
+
+// NewECDSA 创建ECDSA算法实例
+func NewECDSA(method jwt.SigningMethod) (Algorithm, error) {
+	var hash crypto.Hash
+	var curveBits int
+
+	switch method {
+	case jwt.ES256:
+		hash, curveBits = crypto.SHA256, 256
+	case jwt.ES384:
+		hash, curveBits = crypto.SHA384, 384
+	case jwt.ES512:
+		hash, curveBits = crypto.SHA512, 521
+	default:
+		return nil, errors.NewAlgorithmMismatchError("unsupported ECDSA method", nil)
+	}
+
+	return &ECDSA{
+		hash:      hash,
+		curveBits: curveBits,
+		method:    method,
+	}, nil
+}
+
+// Sign 使用ECDSA算法签名
+func (e *ECDSA) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.NewKeyInvalidError("ECDSA key must be *ecdsa.PrivateKey", nil)
+	}
+	if privateKey.Curve.Params().BitSize != e.curveBits {
+		return nil, errors.NewKeyInvalidError("ECDSA key curve does not match signing method", nil)
+	}
+
+	hasher := e.hash.New()
+	hasher.Write(data)
+
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hasher.Sum(nil))
+	if err != nil {
+		return nil, errors.NewSignatureInvalidError("ECDSA signing failed", err)
+	}
+
+	// r 和 s 各自按曲线字节数左补零
+	keyBytes := (e.curveBits + 7) / 8
+	signature := make([]byte, 2*keyBytes)
+	r.FillBytes(signature[:keyBytes])
+	s.FillBytes(signature[keyBytes:])
+	return signature, nil
+}
+
+// Verify 验证ECDSA签名
+func (e *ECDSA) Verify(data []byte, signature []byte, key interface{}) error {
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.NewKeyInvalidError("ECDSA key must be *ecdsa.PublicKey", nil)
+	}
+	if publicKey.Curve.Params().BitSize != e.curveBits {
+		return errors.NewKeyInvalidError("ECDSA key curve does not match signing method", nil)
+	}
+
+	keyBytes := (e.curveBits + 7) / 8
+	if len(signature) != 2*keyBytes {
+		return errors.NewSignatureInvalidError("ECDSA signature has invalid length", nil)
+	}
+
+	hasher := e.hash.New()
+	hasher.Write(data)
+
+	r := new(big.Int).SetBytes(signature[:keyBytes])
+	s := new(big.Int).SetBytes(signature[keyBytes:])
+	if !ecdsa.Verify(publicKey, hasher.Sum(nil), r, s) {
+		return errors.NewSignatureInvalidError("ECDSA signature verification failed", nil)
+	}
+
+	return nil
+}
+
+// Name 获取算法名称
+func (e *ECDSA) Name() jwt.SigningMethod {
+	return e.method
+}
+
+// EdDSA 实现Ed25519签名算法
+type EdDSA struct{}
+
+// NewEdDSA 创建EdDSA算法实例
+func NewEdDSA() Algorithm {
+	return &EdDSA{}
+}
+
+// Sign 使用Ed25519签名
+func (e *EdDSA) Sign(data []byte, key interface{}) ([]byte, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.NewKeyInvalidError("EdDSA key must be ed25519.PrivateKey", nil)
+	}
+
+	return ed25519.Sign(privateKey, data), nil
+}
+
+// Verify 验证Ed25519签名
+func (e *EdDSA) Verify(data []byte, signature []byte, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return errors.NewKeyInvalidError("EdDSA key must be ed25519.PublicKey", nil)
+	}
+
+	if !ed25519.Verify(publicKey, data, signature) {
+		return errors.NewSignatureInvalidError("EdDSA signature verification failed", nil)
+	}
+
+	return nil
+}
+
+// Name 获取算法名称
+func (e *EdDSA) Name() jwt.SigningMethod {
+	return jwt.EdDSA
+}
+
 // CreateAlgorithm 根据签名方法创建相应的算法实例
 func CreateAlgorithm(method jwt.SigningMethod) (Algorithm, error) {
 	switch method {
@@ -144,6 +345,12 @@ func CreateAlgorithm(method jwt.SigningMethod) (Algorithm, error) {
 		return NewHMAC(method)
 	case jwt.RS256, jwt.RS384, jwt.RS512:
 		return NewRSA(method)
+	case jwt.PS256, jwt.PS384, jwt.PS512:
+		return NewRSAPSS(method)
+	case jwt.ES256, jwt.ES384, jwt.ES512:
+		return NewECDSA(method)
+	case jwt.EdDSA:
+		return NewEdDSA(), nil
 	default:
 		return nil, errors.NewAlgorithmMismatchError("unsupported signing method", nil)
 	}
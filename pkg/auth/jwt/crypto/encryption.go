@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/rsa"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+)
+
+// encryptionKeys 从 KeyManager 读取 JWE 密钥，密钥轮换后立即使用新密钥
+type encryptionKeys struct {
+	km  *KeyManager
+	alg jwt.KeyAlgorithm
+}
+
+// EncryptionKeys 返回使用该密钥管理器密钥的 JWE 密钥提供者
+// dir 要求 HMAC 密钥管理器且密钥为 32 字节，RSA-OAEP 和 RSA-OAEP-256 要求 RSA 或 RSA-PSS 密钥管理器
+// 加密密钥应与签名密钥分开管理，使用单独的 KeyManager
+// 轮换密钥后用旧密钥加密的令牌无法再解密
+func (km *KeyManager) EncryptionKeys(alg jwt.KeyAlgorithm) (jwt.EncryptionKeyProvider, error) {
+	switch alg {
+	case jwt.Direct:
+		if _, ok := km.algorithm.(*HMAC); !ok {
+			return nil, errors.NewAlgorithmMismatchError("dir encryption requires an HMAC key manager", nil)
+		}
+	case jwt.RSAOAEP, jwt.RSAOAEP256:
+		if !isRSAMethod(km.method) {
+			return nil, errors.NewAlgorithmMismatchError(string(alg)+" requires an RSA key manager", nil)
+		}
+	default:
+		return nil, errors.NewAlgorithmMismatchError("unsupported key management algorithm "+string(alg), nil)
+	}
+	return &encryptionKeys{km: km, alg: alg}, nil
+}
+
+// KeyAlgorithm 获取密钥管理算法
+func (k *encryptionKeys) KeyAlgorithm() jwt.KeyAlgorithm {
+	return k.alg
+}
+
+// GetEncryptionKey 获取加密密钥
+func (k *encryptionKeys) GetEncryptionKey() (interface{}, error) {
+	if k.alg == jwt.Direct {
+		return k.secret()
+	}
+	key, err := k.km.GetVerificationKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, NewKeyInvalidError("RSA public key is required", nil)
+	}
+	return publicKey, nil
+}
+
+// GetDecryptionKey 获取解密密钥
+func (k *encryptionKeys) GetDecryptionKey() (interface{}, error) {
+	if k.alg == jwt.Direct {
+		return k.secret()
+	}
+	key, err := k.km.GetSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, NewKeyInvalidError("RSA private key is required", nil)
+	}
+	return privateKey, nil
+}
+
+// secret 返回 dir 使用的对称密钥
+func (k *encryptionKeys) secret() ([]byte, error) {
+	key, err := k.km.GetSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	secret, ok := key.([]byte)
+	if !ok || len(secret) != 32 {
+		return nil, NewKeyInvalidError("dir encryption requires a 32 byte secret key", nil)
+	}
+	return secret, nil
+}
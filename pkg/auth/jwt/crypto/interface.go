@@ -1,17 +1,17 @@
 package crypto
 
 import (
+	"context"
+
 	"gobase/pkg/auth/jwt"
 )
 
-// KeyProvider 密钥提供者接口
+// KeyProvider 密钥提供者接口，可以直接用于 jwt.NewTokenManagerWithKeys
 type KeyProvider interface {
-	// GetSigningKey 获取签名密钥
-	GetSigningKey() (interface{}, error)
-	// GetVerificationKey 获取验证密钥
-	GetVerificationKey() (interface{}, error)
+	// 签名方法以及签名、验证密钥
+	jwt.KeyProvider
 	// RotateKeys 轮换密钥
-	RotateKeys() error
+	RotateKeys(ctx context.Context) error
 }
 
 // Algorithm 加密算法接口
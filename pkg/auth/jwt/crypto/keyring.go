@@ -0,0 +1,653 @@
+package crypto
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/events"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/lock"
+	"gobase/pkg/logger/types"
+)
+
+// 确保 Keyring 实现 KeyProvider 和 jwt.KeyIDProvider
+var (
+	_ KeyProvider       = (*Keyring)(nil)
+	_ jwt.KeyIDProvider = (*Keyring)(nil)
+)
+
+// DefaultGracePeriod 退役密钥的默认宽限期
+const DefaultGracePeriod = 24 * time.Hour
+
+// Key 密钥环中的一把密钥
+type Key struct {
+	// ID 密钥ID，写入令牌头的 kid
+	ID string
+	// Method 签名方法
+	Method jwt.SigningMethod
+	// PrivateKey 私钥，从其他实例导入的密钥只有公钥，为 nil
+	PrivateKey crypto.Signer
+	// PublicKey 公钥
+	PublicKey crypto.PublicKey
+	// CreatedAt 创建时间
+	CreatedAt time.Time
+	// RetiredAt 退役时间，零值表示仍在使用
+	RetiredAt time.Time
+}
+
+// Retired 是否已退役
+func (k *Key) Retired() bool {
+	return !k.RetiredAt.IsZero()
+}
+
+// EventPublisher 密钥事件发布者，events.Publisher 实现了该接口
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, event *events.Event) error
+}
+
+// KeyringOption 密钥环选项
+type KeyringOption func(*Keyring)
+
+// WithGracePeriod 设置退役密钥的宽限期，宽限期内旧密钥签发的令牌仍可验证
+// 通常不小于访问令牌的有效期
+func WithGracePeriod(d time.Duration) KeyringOption {
+	return func(r *Keyring) {
+		r.gracePeriod = d
+	}
+}
+
+// WithKeyringPublisher 设置事件发布者，轮换后发布 KeyRotated 事件
+func WithKeyringPublisher(publisher EventPublisher) KeyringOption {
+	return func(r *Keyring) {
+		r.publisher = publisher
+	}
+}
+
+// WithKeyringLogger 设置日志记录器
+func WithKeyringLogger(logger types.Logger) KeyringOption {
+	return func(r *Keyring) {
+		r.logger = logger
+	}
+}
+
+// WithKeyringClock 设置时钟，主要用于测试
+func WithKeyringClock(now func() time.Time) KeyringOption {
+	return func(r *Keyring) {
+		r.now = now
+	}
+}
+
+// WithSigningKey 使用已有私钥作为初始签名密钥，kid 为空时自动生成
+func WithSigningKey(kid string, privateKey crypto.Signer) KeyringOption {
+	return func(r *Keyring) {
+		r.initial = &Key{ID: kid, PrivateKey: privateKey}
+	}
+}
+
+// WithRotationLock 设置分布式锁，使用共享存储时只有获得锁的实例执行轮换
+func WithRotationLock(locker lock.Locker, key string) KeyringOption {
+	return func(r *Keyring) {
+		r.locker = locker
+		r.lockKey = key
+	}
+}
+
+// WithMinKeyAge 设置签名密钥的最短使用时间，使用共享存储时签名密钥比该时间新则跳过轮换
+// 避免多个实例的定时轮换在同一周期内重复轮换，通常设为轮换间隔的一半
+func WithMinKeyAge(d time.Duration) KeyringOption {
+	return func(r *Keyring) {
+		r.minKeyAge = d
+	}
+}
+
+// WithEventKeyImport 允许未使用共享存储的密钥环从 KeyRotated 事件中导入公钥
+// 事件本身没有签名，能向事件通道发布消息的任何一方都可以借此注入验证密钥，
+// 只应在事件通道受信任(仅内部服务可写入且启用认证)时开启，否则应使用 LoadKeyring
+func WithEventKeyImport() KeyringOption {
+	return func(r *Keyring) {
+		r.importEventKeys = true
+	}
+}
+
+// Keyring 密钥环，持有一把签名密钥和若干只用于验证的密钥
+// 轮换后旧密钥退役，宽限期内仍可验证旧令牌，超过宽限期后移除
+type Keyring struct {
+	method      jwt.SigningMethod
+	gracePeriod time.Duration
+	publisher   EventPublisher
+	logger      types.Logger
+	now         func() time.Time
+	initial     *Key
+	store       KeyStore
+	locker      lock.Locker
+	lockKey     string
+	minKeyAge   time.Duration
+	// importEventKeys 是否从 KeyRotated 事件中导入公钥
+	importEventKeys bool
+
+	// rotateMu 保证本实例同一时间只有一次轮换或重新加载
+	rotateMu sync.Mutex
+	mutex    sync.RWMutex
+	active   *Key
+	keys     map[string]*Key
+	version  int64
+}
+
+// NewKeyring 创建密钥环并生成初始签名密钥，只支持非对称签名方法
+// 密钥只保存在本实例内存中，多实例部署使用 LoadKeyring
+func NewKeyring(method jwt.SigningMethod, opts ...KeyringOption) (*Keyring, error) {
+	r, err := newKeyring(method, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := r.initialKey()
+	if err != nil {
+		return nil, err
+	}
+	r.active = key
+	r.keys[key.ID] = key
+	return r, nil
+}
+
+// LoadKeyring 创建使用共享存储的密钥环
+// 存储为空时生成初始签名密钥并保存；配置了 WithRotationLock 时初始化和轮换都在锁内进行
+func LoadKeyring(ctx context.Context, method jwt.SigningMethod, store KeyStore, opts ...KeyringOption) (*Keyring, error) {
+	if store == nil {
+		return nil, errors.NewConfigInvalidError("key store is required", nil)
+	}
+	r, err := newKeyring(method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.store = store
+
+	set, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		unlock, err := r.lock(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		// 获得锁后再次读取，其他实例可能已经完成初始化
+		if set, err = store.Load(ctx); err != nil {
+			return nil, err
+		}
+		if set == nil {
+			key, err := r.initialKey()
+			if err != nil {
+				return nil, err
+			}
+			set, err = encodeKeySet(method, key.ID, map[string]*Key{key.ID: key}, 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := store.Save(ctx, set); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := r.apply(ctx, set); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// newKeyring 创建不含密钥的密钥环并应用选项
+func newKeyring(method jwt.SigningMethod, opts ...KeyringOption) (*Keyring, error) {
+	if _, err := CreateAlgorithm(method); err != nil {
+		return nil, err
+	}
+	if method == jwt.HS256 || method == jwt.HS384 || method == jwt.HS512 {
+		return nil, errors.NewAlgorithmMismatchError("keyring requires an asymmetric signing method", nil)
+	}
+
+	r := &Keyring{
+		method:      method,
+		gracePeriod: DefaultGracePeriod,
+		logger:      &types.NoopLogger{},
+		now:         time.Now,
+		lockKey:     "jwt:keyring",
+		keys:        make(map[string]*Key),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.gracePeriod < 0 {
+		return nil, errors.NewConfigInvalidError("grace period must not be negative", nil)
+	}
+	return r, nil
+}
+
+// initialKey 返回 WithSigningKey 设置的私钥，未设置时生成新密钥
+func (r *Keyring) initialKey() (*Key, error) {
+	key := r.initial
+	r.initial = nil
+	if key == nil {
+		return r.newKey()
+	}
+
+	if key.PrivateKey == nil {
+		return nil, errors.NewKeyInvalidError("signing key is required", nil)
+	}
+	if err := checkPublicKey(r.method, key.PrivateKey.Public()); err != nil {
+		return nil, err
+	}
+	key.Method = r.method
+	key.PublicKey = key.PrivateKey.Public()
+	key.CreatedAt = r.now()
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	return key, nil
+}
+
+// Method 获取签名方法
+func (r *Keyring) Method() jwt.SigningMethod {
+	return r.method
+}
+
+// GetSigningKey 获取当前签名密钥
+func (r *Keyring) GetSigningKey() (interface{}, error) {
+	_, key, err := r.GetSigningKeyWithID()
+	return key, err
+}
+
+// GetVerificationKey 获取当前签名密钥对应的公钥
+func (r *Keyring) GetVerificationKey() (interface{}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.active.PublicKey, nil
+}
+
+// GetSigningKeyWithID 获取当前签名密钥及其 kid
+func (r *Keyring) GetSigningKeyWithID() (string, interface{}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.active.PrivateKey == nil {
+		return "", nil, errors.NewKeyInvalidError("active key has no private key", nil)
+	}
+	return r.active.ID, r.active.PrivateKey, nil
+}
+
+// GetVerificationKeyByID 按 kid 获取验证密钥，已超过宽限期的密钥视为不存在
+func (r *Keyring) GetVerificationKeyByID(kid string) (interface{}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, ok := r.keys[kid]
+	if !ok || r.expired(key) {
+		return nil, errors.NewKeyInvalidError("unknown key id: "+kid, nil)
+	}
+	return key.PublicKey, nil
+}
+
+// ActiveKeyID 获取当前签名密钥的 kid
+func (r *Keyring) ActiveKeyID() string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.active.ID
+}
+
+// Keys 获取仍可用于验证的密钥，按创建时间排序
+func (r *Keyring) Keys() []Key {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]Key, 0, len(r.keys))
+	for _, key := range r.keys {
+		if !r.expired(key) {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// RotateKeys 轮换密钥，实现 KeyProvider
+func (r *Keyring) RotateKeys(ctx context.Context) error {
+	_, err := r.Rotate(ctx)
+	return err
+}
+
+// Rotate 生成新的签名密钥，当前密钥退役并在宽限期内继续用于验证
+// 使用共享存储时先在锁内重新加载，其他实例已经轮换或签名密钥比 WithMinKeyAge 新时直接返回当前密钥；
+// 新密钥保存成功后才会生效
+// 配置了事件发布者时发布 KeyRotated 事件，发布失败只记录日志，不影响本地轮换结果
+func (r *Keyring) Rotate(ctx context.Context) (*Key, error) {
+	unlock, err := r.lock(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	r.rotateMu.Lock()
+	defer r.rotateMu.Unlock()
+
+	if r.store != nil {
+		previousID := r.ActiveKeyID()
+		if err := r.reload(ctx); err != nil {
+			return nil, NewRotationFailedError("failed to load keys", err)
+		}
+		r.mutex.RLock()
+		active := *r.active
+		r.mutex.RUnlock()
+		if active.ID != previousID || r.now().Sub(active.CreatedAt) < r.minKeyAge {
+			r.logger.Info(ctx, "signing key already rotated, skipping",
+				types.Field{Key: "kid", Value: active.ID},
+			)
+			return &active, nil
+		}
+	}
+
+	key, err := r.newKey()
+	if err != nil {
+		return nil, NewRotationFailedError("failed to generate key", err)
+	}
+
+	// 在副本上轮换，保存成功后再替换
+	r.mutex.RLock()
+	previous := *r.active
+	previous.RetiredAt = key.CreatedAt
+	keys := make(map[string]*Key, len(r.keys)+1)
+	for kid, k := range r.keys {
+		if !r.expired(k) {
+			keys[kid] = k
+		}
+	}
+	version := r.version + 1
+	r.mutex.RUnlock()
+	keys[previous.ID] = &previous
+	keys[key.ID] = key
+
+	if r.store != nil {
+		set, err := encodeKeySet(r.method, key.ID, keys, version)
+		if err == nil {
+			err = r.store.Save(ctx, set)
+		}
+		if err != nil {
+			return nil, NewRotationFailedError("failed to save keys", err)
+		}
+	}
+
+	r.mutex.Lock()
+	r.active = key
+	r.keys = keys
+	r.version = version
+	r.mutex.Unlock()
+
+	r.logger.Info(ctx, "signing key rotated",
+		types.Field{Key: "kid", Value: key.ID},
+		types.Field{Key: "previous_kid", Value: previous.ID},
+	)
+
+	if r.publisher != nil {
+		event, err := r.rotatedEvent(key, &previous)
+		if err == nil {
+			err = r.publisher.PublishEvent(ctx, event)
+		}
+		if err != nil {
+			r.logger.Error(ctx, "failed to publish key rotated event",
+				types.Field{Key: "kid", Value: key.ID},
+				types.Error(err),
+			)
+		}
+	}
+
+	return key, nil
+}
+
+// Reload 从共享存储重新加载密钥，存储中的版本不比本地新时不做修改
+func (r *Keyring) Reload(ctx context.Context) error {
+	if r.store == nil {
+		return errors.NewConfigInvalidError("keyring has no key store", nil)
+	}
+	r.rotateMu.Lock()
+	defer r.rotateMu.Unlock()
+	return r.reload(ctx)
+}
+
+// Poll 按 interval 定期重新加载共享存储中的密钥，直到 ctx 结束
+// 作为 KeyRotated 事件的补充，事件丢失时其他实例也能在一个周期内拿到新密钥
+func (r *Keyring) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Reload(ctx); err != nil {
+				r.logger.Warn(ctx, "failed to reload keys", types.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// HandleKeyRotated 处理其他实例发布的 KeyRotated 事件，可直接注册到 events.Subscriber
+// 使用共享存储时从存储重新加载密钥，不信任事件内容；未使用共享存储时只有开启 WithEventKeyImport
+// 才导入事件携带的公钥，否则返回 ConfigInvalid 错误。本实例发布的事件和已知的 kid 会被忽略
+func (r *Keyring) HandleKeyRotated(event *events.Event) error {
+	if event == nil || event.Type != events.KeyRotated || event.KeyID == "" {
+		return nil
+	}
+
+	r.mutex.RLock()
+	_, known := r.keys[event.KeyID]
+	r.mutex.RUnlock()
+	if known {
+		return nil
+	}
+	if r.store != nil {
+		return r.Reload(context.Background())
+	}
+	if !r.importEventKeys {
+		return errors.NewConfigInvalidError("keyring has no key store and event key import is disabled", nil)
+	}
+
+	if method, _ := event.Payload["method"].(string); jwt.SigningMethod(method) != r.method {
+		return errors.NewAlgorithmMismatchError("rotated key uses signing method "+method, nil)
+	}
+	publicPEM, _ := event.Payload["public_key"].(string)
+	publicKey, err := parsePublicKey(r.method, publicPEM)
+	if err != nil {
+		return err
+	}
+
+	createdAt := event.Timestamp
+	if createdAt.IsZero() {
+		createdAt = r.now()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.keys[event.KeyID] = &Key{
+		ID:        event.KeyID,
+		Method:    r.method,
+		PublicKey: publicKey,
+		CreatedAt: createdAt,
+	}
+	// 其他实例的上一把密钥随之退役，本实例的签名密钥不受影响
+	if previousID, _ := event.Payload["previous_kid"].(string); previousID != "" {
+		if previous, ok := r.keys[previousID]; ok && previous != r.active && !previous.Retired() {
+			previous.RetiredAt = createdAt
+		}
+	}
+	r.prune()
+
+	r.logger.Info(context.Background(), "imported rotated verification key",
+		types.Field{Key: "kid", Value: event.KeyID},
+	)
+	return nil
+}
+
+// newKey 生成新的签名密钥
+func (r *Keyring) newKey() (*Key, error) {
+	privateKey, err := generateKey(r.method)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{
+		ID:         uuid.New().String(),
+		Method:     r.method,
+		PrivateKey: privateKey,
+		PublicKey:  privateKey.Public(),
+		CreatedAt:  r.now(),
+	}, nil
+}
+
+// expired 退役密钥是否已超过宽限期，调用方需持有锁
+func (r *Keyring) expired(key *Key) bool {
+	return key.Retired() && !r.now().Before(key.RetiredAt.Add(r.gracePeriod))
+}
+
+// prune 移除超过宽限期的密钥，调用方需持有写锁
+func (r *Keyring) prune() {
+	for kid, key := range r.keys {
+		if r.expired(key) {
+			delete(r.keys, kid)
+		}
+	}
+}
+
+// reload 从存储读取并应用密钥，调用方需持有 rotateMu
+func (r *Keyring) reload(ctx context.Context) error {
+	set, err := r.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if set == nil {
+		return errors.NewKeyInvalidError("key store is empty", nil)
+	}
+	return r.apply(ctx, set)
+}
+
+// apply 应用存储中的密钥集，版本不比本地新时忽略
+func (r *Keyring) apply(ctx context.Context, set *KeySet) error {
+	r.mutex.RLock()
+	upToDate := r.active != nil && set.Version <= r.version
+	r.mutex.RUnlock()
+	if upToDate {
+		return nil
+	}
+
+	active, keys, err := decodeKeySet(ctx, r.logger, r.method, set)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	r.active = active
+	r.keys = keys
+	r.version = set.Version
+	r.prune()
+	r.mutex.Unlock()
+
+	r.logger.Info(ctx, "keys loaded from key store",
+		types.Field{Key: "kid", Value: active.ID},
+		types.Field{Key: "version", Value: set.Version},
+	)
+	return nil
+}
+
+// lock 获取轮换锁，未配置锁时直接返回；wait 为 true 时等待锁释放
+func (r *Keyring) lock(ctx context.Context, wait bool) (func(), error) {
+	if r.locker == nil {
+		return func() {}, nil
+	}
+
+	var lk lock.Lock
+	var err error
+	if wait {
+		lk, err = r.locker.Lock(ctx, r.lockKey)
+	} else {
+		lk, err = r.locker.TryLock(ctx, r.lockKey)
+	}
+	if err != nil {
+		if errors.HasErrorCode(err, codes.LockNotAcquired) {
+			return nil, NewRotationFailedError("key rotation is in progress on another instance", err)
+		}
+		return nil, NewRotationFailedError("failed to acquire rotation lock", err)
+	}
+	return func() {
+		if err := lk.Unlock(ctx); err != nil {
+			r.logger.Warn(ctx, "failed to release rotation lock", types.Error(err))
+		}
+	}, nil
+}
+
+// rotatedEvent 构造 KeyRotated 事件，只携带公钥
+func (r *Keyring) rotatedEvent(key, previous *Key) (*events.Event, error) {
+	publicKey, err := encodePublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &events.Event{
+		Type:      events.KeyRotated,
+		Timestamp: key.CreatedAt,
+		KeyID:     key.ID,
+		Payload: map[string]interface{}{
+			"method":       string(r.method),
+			"previous_kid": previous.ID,
+			"public_key":   publicKey,
+			"retire_at":    previous.RetiredAt.Add(r.gracePeriod),
+		},
+	}, nil
+}
+
+// parsePublicKey 解析 PKIX 格式的 PEM 公钥，并检查公钥类型与签名方法是否匹配
+func parsePublicKey(method jwt.SigningMethod, publicKeyPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.NewKeyInvalidError("failed to decode public key PEM block", nil)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.NewKeyInvalidError("failed to parse public key", err)
+	}
+	if err := checkPublicKey(method, publicKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// checkPublicKey 检查公钥类型与签名方法是否匹配
+func checkPublicKey(method jwt.SigningMethod, publicKey crypto.PublicKey) error {
+	var ok bool
+	switch {
+	case isRSAMethod(method):
+		_, ok = publicKey.(*rsa.PublicKey)
+	case isECDSAMethod(method):
+		var pub *ecdsa.PublicKey
+		pub, ok = publicKey.(*ecdsa.PublicKey)
+		ok = ok && pub.Curve == curveOf(method)
+	case method == jwt.EdDSA:
+		_, ok = publicKey.(ed25519.PublicKey)
+	}
+	if !ok {
+		return errors.NewKeyInvalidError("key type does not match signing method "+string(method), nil)
+	}
+	return nil
+}
@@ -2,10 +2,15 @@ package crypto
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"sync"
 
 	"gobase/pkg/auth/jwt"
@@ -52,37 +57,35 @@ func (km *KeyManager) InitializeKeys(ctx context.Context, config *jwt.KeyConfig)
 		}
 		return nil
 
-	case *RSA:
-		var privateKey *rsa.PrivateKey
-		var publicKey *rsa.PublicKey
+	default:
+		// 非对称算法
+		var privateKey crypto.Signer
 		var err error
 
 		if config != nil && config.PrivateKey != "" {
 			// 使用提供的密钥
-			privateKey, publicKey, err = parseRSAKeyPair(ctx, km.logger, config.PrivateKey)
-			if err != nil {
-				return err
-			}
+			privateKey, err = parsePrivateKey(ctx, km.logger, km.method, config.PrivateKey)
 		} else {
 			// 自动生成密钥对
-			privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
-			if err != nil {
-				return errors.NewKeyInvalidError("failed to generate RSA key pair", err)
-			}
-			publicKey = &privateKey.PublicKey
+			privateKey, err = generateKey(km.method)
+		}
+		if err != nil {
+			return err
 		}
 
 		km.keyPair = &jwt.KeyPair{
 			PrivateKey: privateKey,
-			PublicKey:  publicKey,
+			PublicKey:  privateKey.Public(),
 		}
 		return nil
-
-	default:
-		return errors.NewKeyInvalidError("unsupported algorithm", nil)
 	}
 }
 
+// Method 获取签名方法
+func (km *KeyManager) Method() jwt.SigningMethod {
+	return km.method
+}
+
 // GetSigningKey 获取签名密钥
 func (km *KeyManager) GetSigningKey() (interface{}, error) {
 	km.mutex.RLock()
@@ -107,60 +110,106 @@ func (km *KeyManager) GetVerificationKey() (interface{}, error) {
 	return km.keyPair.PublicKey, nil
 }
 
-// RotateKeys 轮转密钥
+// Keys 获取可以公开的验证密钥，只包含公钥，没有 kid
+// HMAC 密钥不能公开，返回空
+func (km *KeyManager) Keys() []Key {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+
+	if km.keyPair == nil {
+		return nil
+	}
+	if _, ok := km.algorithm.(*HMAC); ok {
+		return nil
+	}
+	return []Key{{
+		Method:    km.method,
+		PublicKey: km.keyPair.PublicKey,
+	}}
+}
+
+// RotateKeys 轮转密钥，新密钥只保存在本进程内存中
+// 多实例部署需要共享密钥时使用 LoadKeyring
 func (km *KeyManager) RotateKeys(ctx context.Context) error {
 	km.mutex.Lock()
 	defer km.mutex.Unlock()
 
 	// 先检查方法类型
-	switch km.method {
-	case jwt.HS256, jwt.HS384, jwt.HS512:
+	switch km.algorithm.(type) {
+	case *HMAC:
 		return errors.NewKeyInvalidError("key rotation not supported for HMAC", nil)
-	case jwt.RS256, jwt.RS384, jwt.RS512:
+	default:
 		// 检查密钥对是否已初始化
 		if km.keyPair == nil {
 			return errors.NewKeyInvalidError("key pair not initialized", nil)
 		}
-		// 生成新的 RSA 密钥对
-		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		// 生成新的密钥对
+		privateKey, err := generateKey(km.method)
 		if err != nil {
-			return errors.NewKeyInvalidError("failed to generate RSA key pair", err)
+			return err
 		}
 		km.keyPair = &jwt.KeyPair{
 			PrivateKey: privateKey,
-			PublicKey:  &privateKey.PublicKey,
+			PublicKey:  privateKey.Public(),
 		}
 		return nil
-	default:
-		return errors.NewAlgorithmMismatchError("unsupported signing method", nil)
 	}
 }
 
 // 辅助函数
 
 func createAlgorithm(method jwt.SigningMethod) (Algorithm, error) {
+	return CreateAlgorithm(method)
+}
+
+// generateKey 按签名方法生成私钥，RSA 和 RSA-PSS 使用 2048 位密钥
+func generateKey(method jwt.SigningMethod) (crypto.Signer, error) {
+	var key crypto.Signer
+	var err error
+
 	switch method {
-	case jwt.HS256, jwt.HS384, jwt.HS512:
-		return NewHMAC(method)
-	case jwt.RS256, jwt.RS384, jwt.RS512:
-		return NewRSA(method)
+	case jwt.RS256, jwt.RS384, jwt.RS512, jwt.PS256, jwt.PS384, jwt.PS512:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.ES256, jwt.ES384, jwt.ES512:
+		key, err = ecdsa.GenerateKey(curveOf(method), rand.Reader)
+	case jwt.EdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
 	default:
 		return nil, errors.NewAlgorithmMismatchError("unsupported signing method", nil)
 	}
+
+	if err != nil {
+		return nil, errors.NewKeyInvalidError("failed to generate "+string(method)+" key pair", err)
+	}
+	return key, nil
 }
 
-func parseRSAKeyPair(ctx context.Context, logger types.Logger, privateKeyPEM string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+// curveOf 返回 ECDSA 签名方法使用的曲线
+func curveOf(method jwt.SigningMethod) elliptic.Curve {
+	switch method {
+	case jwt.ES384:
+		return elliptic.P384()
+	case jwt.ES512:
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
+
+// parsePrivateKey 解析 PEM 格式的私钥，并检查私钥类型与签名方法是否匹配
+// 支持 PKCS1(RSA PRIVATE KEY)、SEC1(EC PRIVATE KEY) 和 PKCS8(PRIVATE KEY)
+func parsePrivateKey(ctx context.Context, logger types.Logger, method jwt.SigningMethod, privateKeyPEM string) (crypto.Signer, error) {
 	// 1. 验证 PEM 内容是否为空
 	if len(privateKeyPEM) == 0 {
 		logger.Error(ctx, "empty PEM content")
-		return nil, nil, errors.NewKeyInvalidError("empty PEM content", nil)
+		return nil, errors.NewKeyInvalidError("empty PEM content", nil)
 	}
 
 	// 2. 解码 PEM
 	block, _ := pem.Decode([]byte(privateKeyPEM))
 	if block == nil {
 		logger.Error(ctx, "failed to decode PEM block")
-		return nil, nil, errors.NewKeyInvalidError("failed to decode PEM block", nil)
+		return nil, errors.NewKeyInvalidError("failed to decode PEM block", nil)
 	}
 
 	logger.Debug(ctx, "decoded PEM block",
@@ -168,46 +217,80 @@ func parseRSAKeyPair(ctx context.Context, logger types.Logger, privateKeyPEM str
 		types.Field{Key: "bytes_length", Value: len(block.Bytes)})
 
 	// 3. 解析私钥
-	var privateKey *rsa.PrivateKey
+	var key interface{}
 	var err error
 
-	if block.Type == "RSA PRIVATE KEY" {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
 		// 处理 PKCS1 格式
-		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-		if err != nil {
-			logger.Error(ctx, "failed to parse PKCS1 private key",
-				types.Field{Key: "error", Value: err.Error()})
-			return nil, nil, errors.NewKeyInvalidError("failed to parse private key", err)
-		}
-	} else if block.Type == "PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		// 处理 SEC1 格式
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
 		// 处理 PKCS8 格式
-		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-		if err != nil {
-			logger.Error(ctx, "failed to parse PKCS8 private key",
-				types.Field{Key: "error", Value: err.Error()})
-			return nil, nil, errors.NewKeyInvalidError("failed to parse private key", err)
-		}
-		var ok bool
-		privateKey, ok = key.(*rsa.PrivateKey)
-		if !ok {
-			logger.Error(ctx, "parsed key is not an RSA private key")
-			return nil, nil, errors.NewKeyInvalidError("parsed key is not an RSA private key", nil)
-		}
-	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
 		logger.Error(ctx, "unsupported private key type",
 			types.Field{Key: "type", Value: block.Type})
-		return nil, nil, errors.NewKeyInvalidError("unsupported private key type", nil)
+		return nil, errors.NewKeyInvalidError("unsupported private key type", nil)
 	}
-
-	// 4. 验证密钥
-	if err := privateKey.Validate(); err != nil {
-		logger.Error(ctx, "invalid RSA private key",
+	if err != nil {
+		logger.Error(ctx, "failed to parse private key",
+			types.Field{Key: "type", Value: block.Type},
 			types.Field{Key: "error", Value: err.Error()})
-		return nil, nil, errors.NewKeyInvalidError("invalid RSA private key", err)
+		return nil, errors.NewKeyInvalidError("failed to parse private key", err)
 	}
 
-	logger.Debug(ctx, "successfully parsed RSA key pair",
-		types.Field{Key: "modulus_size", Value: privateKey.N.BitLen()})
+	// 4. 验证密钥类型与签名方法
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if !isRSAMethod(method) {
+			break
+		}
+		if err := k.Validate(); err != nil {
+			logger.Error(ctx, "invalid RSA private key",
+				types.Field{Key: "error", Value: err.Error()})
+			return nil, errors.NewKeyInvalidError("invalid RSA private key", err)
+		}
+		logger.Debug(ctx, "successfully parsed RSA key pair",
+			types.Field{Key: "modulus_size", Value: k.N.BitLen()})
+		return k, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != curveOf(method) || !isECDSAMethod(method) {
+			break
+		}
+		logger.Debug(ctx, "successfully parsed ECDSA key pair",
+			types.Field{Key: "curve", Value: k.Curve.Params().Name})
+		return k, nil
+	case ed25519.PrivateKey:
+		if method != jwt.EdDSA {
+			break
+		}
+		logger.Debug(ctx, "successfully parsed Ed25519 key pair")
+		return k, nil
+	}
+
+	logger.Error(ctx, "private key does not match signing method",
+		types.Field{Key: "method", Value: method},
+		types.Field{Key: "key_type", Value: fmt.Sprintf("%T", key)})
+	return nil, errors.NewKeyInvalidError("private key does not match signing method "+string(method), nil)
+}
 
-	return privateKey, &privateKey.PublicKey, nil
+// isRSAMethod 判断是否为使用 RSA 密钥的签名方法
+func isRSAMethod(method jwt.SigningMethod) bool {
+	switch method {
+	case jwt.RS256, jwt.RS384, jwt.RS512, jwt.PS256, jwt.PS384, jwt.PS512:
+		return true
+	}
+	return false
+}
+
+// isECDSAMethod 判断是否为 ECDSA 签名方法
+func isECDSAMethod(method jwt.SigningMethod) bool {
+	switch method {
+	case jwt.ES256, jwt.ES384, jwt.ES512:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,163 @@
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"time"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// KeyStore 密钥环的持久化存储，多个实例共享同一份密钥
+type KeyStore interface {
+	// Load 读取密钥集，存储为空时返回 nil, nil
+	Load(ctx context.Context) (*KeySet, error)
+	// Save 保存密钥集
+	Save(ctx context.Context, set *KeySet) error
+}
+
+// KeySet 持久化的密钥环快照
+type KeySet struct {
+	// Method 签名方法
+	Method jwt.SigningMethod `json:"method"`
+	// ActiveID 当前签名密钥的 kid
+	ActiveID string `json:"active_id"`
+	// Version 版本号，每次轮换加一
+	Version int64 `json:"version"`
+	// Keys 签名密钥和宽限期内的退役密钥
+	Keys []StoredKey `json:"keys"`
+}
+
+// StoredKey 持久化的单个密钥
+type StoredKey struct {
+	ID string `json:"id"`
+	// PrivateKey PKCS8 格式的 PEM 私钥
+	PrivateKey string `json:"private_key,omitempty"`
+	// PublicKey PKIX 格式的 PEM 公钥
+	PublicKey string    `json:"public_key"`
+	CreatedAt time.Time `json:"created_at"`
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+// encodeKeySet 将密钥编码为 KeySet
+func encodeKeySet(method jwt.SigningMethod, activeID string, keys map[string]*Key, version int64) (*KeySet, error) {
+	set := &KeySet{
+		Method:   method,
+		ActiveID: activeID,
+		Version:  version,
+		Keys:     make([]StoredKey, 0, len(keys)),
+	}
+	for _, key := range keys {
+		stored := StoredKey{
+			ID:        key.ID,
+			CreatedAt: key.CreatedAt,
+			RetiredAt: key.RetiredAt,
+		}
+		publicKey, err := encodePublicKey(key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		stored.PublicKey = publicKey
+		if key.PrivateKey != nil {
+			der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+			if err != nil {
+				return nil, NewKeyInvalidError("failed to marshal private key", err)
+			}
+			stored.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		}
+		set.Keys = append(set.Keys, stored)
+	}
+	return set, nil
+}
+
+// decodeKeySet 解析 KeySet 中的密钥，签名方法必须与 method 一致
+func decodeKeySet(ctx context.Context, logger types.Logger, method jwt.SigningMethod, set *KeySet) (*Key, map[string]*Key, error) {
+	if set.Method != method {
+		return nil, nil, errors.NewAlgorithmMismatchError("stored keys use signing method "+string(set.Method), nil)
+	}
+
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, stored := range set.Keys {
+		key := &Key{
+			ID:        stored.ID,
+			Method:    method,
+			CreatedAt: stored.CreatedAt,
+			RetiredAt: stored.RetiredAt,
+		}
+		if stored.PrivateKey != "" {
+			privateKey, err := parsePrivateKey(ctx, logger, method, stored.PrivateKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			key.PrivateKey = privateKey
+			key.PublicKey = privateKey.Public()
+		} else {
+			publicKey, err := parsePublicKey(method, stored.PublicKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			key.PublicKey = publicKey
+		}
+		keys[key.ID] = key
+	}
+
+	active, ok := keys[set.ActiveID]
+	if !ok || active.PrivateKey == nil {
+		return nil, nil, errors.NewKeyInvalidError("stored active key is missing", nil)
+	}
+	return active, keys, nil
+}
+
+// encodePublicKey 将公钥编码为 PKIX 格式的 PEM
+func encodePublicKey(publicKey interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", NewKeyInvalidError("failed to marshal public key", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// newAEAD 创建 AES-256-GCM，加密密钥必须为 32 字节
+func newAEAD(encryptionKey []byte) (cipher.AEAD, error) {
+	if len(encryptionKey) != 32 {
+		return nil, errors.NewConfigInvalidError("encryption key must be 32 bytes", nil)
+	}
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, errors.NewConfigInvalidError("invalid encryption key", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.NewConfigInvalidError("invalid encryption key", err)
+	}
+	return aead, nil
+}
+
+// seal 加密数据，随机 nonce 放在密文前
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.NewSystemError("failed to generate nonce", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open 解密 seal 生成的数据
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, NewKeyInvalidError("encrypted key set is truncated", nil)
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, NewKeyInvalidError("failed to decrypt key set", err)
+	}
+	return plaintext, nil
+}
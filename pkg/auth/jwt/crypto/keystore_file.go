@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"gobase/pkg/errors"
+)
+
+// 确保 FileKeyStore 实现 KeyStore
+var _ KeyStore = (*FileKeyStore)(nil)
+
+// FileKeyStore 加密的本地文件密钥存储，适用于单机部署或挂载共享卷的多个实例
+type FileKeyStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewFileKeyStore 创建文件密钥存储，encryptionKey 必须为 32 字节，密钥集使用 AES-256-GCM 加密
+func NewFileKeyStore(path string, encryptionKey []byte) (*FileKeyStore, error) {
+	if path == "" {
+		return nil, errors.NewConfigInvalidError("key store path is required", nil)
+	}
+	aead, err := newAEAD(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &FileKeyStore{path: path, aead: aead}, nil
+}
+
+// Load 读取并解密密钥集，文件不存在时返回 nil, nil
+func (s *FileKeyStore) Load(ctx context.Context) (*KeySet, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.NewFileReadError("failed to read key store", err)
+	}
+
+	plaintext, err := open(s.aead, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var set KeySet
+	if err := json.Unmarshal(plaintext, &set); err != nil {
+		return nil, errors.NewSerializationError("failed to unmarshal key set", err)
+	}
+	return &set, nil
+}
+
+// Save 加密后写入临时文件再重命名，读取方不会看到写了一半的文件
+func (s *FileKeyStore) Save(ctx context.Context, set *KeySet) error {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return errors.NewSerializationError("failed to marshal key set", err)
+	}
+	ciphertext, err := seal(s.aead, data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return errors.NewFileOpenError("failed to create temporary key store file", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(ciphertext); err != nil {
+		tmp.Close()
+		return errors.NewFileWriteError("failed to write key store", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.NewFileFlushError("failed to sync key store", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.NewFileCloseError("failed to close key store", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.NewFileOperationError("failed to replace key store", err)
+	}
+	return nil
+}
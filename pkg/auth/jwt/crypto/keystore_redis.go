@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/json"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// 确保 RedisKeyStore 实现 KeyStore
+var _ KeyStore = (*RedisKeyStore)(nil)
+
+// RedisKeyStoreOption Redis 密钥存储选项
+type RedisKeyStoreOption func(*RedisKeyStore)
+
+// WithRedisKey 设置保存密钥集的 Redis 键，默认 jwt:keyring
+func WithRedisKey(key string) RedisKeyStoreOption {
+	return func(s *RedisKeyStore) {
+		s.key = key
+	}
+}
+
+// WithEncryptionKey 设置 32 字节的加密密钥，私钥使用 AES-256-GCM 加密后写入 Redis
+func WithEncryptionKey(key []byte) RedisKeyStoreOption {
+	return func(s *RedisKeyStore) {
+		s.encryptionKey = key
+	}
+}
+
+// RedisKeyStore 基于 Redis 的密钥存储，密钥集以 JSON 保存在单个键中，不设置过期时间
+type RedisKeyStore struct {
+	client        redis.Client
+	key           string
+	encryptionKey []byte
+	aead          cipher.AEAD
+}
+
+// NewRedisKeyStore 创建 Redis 密钥存储
+// 未配置加密密钥时私钥以明文保存，需要通过 Redis 的 ACL 和 TLS 保护
+func NewRedisKeyStore(client redis.Client, opts ...RedisKeyStoreOption) (*RedisKeyStore, error) {
+	if client == nil {
+		return nil, errors.NewConfigInvalidError("redis client is required", nil)
+	}
+
+	s := &RedisKeyStore{
+		client: client,
+		key:    "jwt:keyring",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.encryptionKey != nil {
+		aead, err := newAEAD(s.encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		s.aead = aead
+	}
+	return s, nil
+}
+
+// Load 读取密钥集
+func (s *RedisKeyStore) Load(ctx context.Context) (*KeySet, error) {
+	value, err := s.client.Get(ctx, s.key)
+	if err != nil {
+		if errors.HasErrorCode(err, codes.RedisKeyNotFoundError) {
+			return nil, nil
+		}
+		return nil, errors.NewRedisCommandError("failed to load key set", err)
+	}
+
+	data := []byte(value)
+	if s.aead != nil {
+		if data, err = open(s.aead, data); err != nil {
+			return nil, err
+		}
+	}
+
+	var set KeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.NewSerializationError("failed to unmarshal key set", err)
+	}
+	return &set, nil
+}
+
+// Save 保存密钥集
+func (s *RedisKeyStore) Save(ctx context.Context, set *KeySet) error {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return errors.NewSerializationError("failed to marshal key set", err)
+	}
+	if s.aead != nil {
+		if data, err = seal(s.aead, data); err != nil {
+			return err
+		}
+	}
+
+	if err := s.client.Set(ctx, s.key, string(data), 0); err != nil {
+		return errors.NewRedisCommandError("failed to save key set", err)
+	}
+	return nil
+}
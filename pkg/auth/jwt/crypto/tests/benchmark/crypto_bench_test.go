@@ -1,12 +1,14 @@
 package benchmark
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
 
 	"gobase/pkg/auth/jwt"
 	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/logger/types"
 )
 
 // generateTestRSAKey 生成用于测试的RSA密钥对
@@ -59,3 +61,50 @@ func BenchmarkRSA_Verify(b *testing.B) {
 		_ = alg.Verify(data, sig, &key.PublicKey)
 	}
 }
+
+// newBenchKeyManager 创建自动生成密钥的密钥管理器
+func newBenchKeyManager(b *testing.B, method jwt.SigningMethod) (crypto.Algorithm, interface{}, interface{}) {
+	km, err := crypto.NewKeyManager(method, &types.NoopLogger{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := km.InitializeKeys(context.Background(), nil); err != nil {
+		b.Fatal(err)
+	}
+	alg, _ := crypto.CreateAlgorithm(method)
+	privateKey, _ := km.GetSigningKey()
+	publicKey, _ := km.GetVerificationKey()
+	return alg, privateKey, publicKey
+}
+
+// asymmetricMethods 参与对比的非对称签名方法
+var asymmetricMethods = []jwt.SigningMethod{jwt.RS256, jwt.PS256, jwt.ES256, jwt.ES384, jwt.ES512, jwt.EdDSA}
+
+func BenchmarkAsymmetric_Sign(b *testing.B) {
+	data := []byte("test data")
+	for _, method := range asymmetricMethods {
+		b.Run(string(method), func(b *testing.B) {
+			alg, privateKey, _ := newBenchKeyManager(b, method)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = alg.Sign(data, privateKey)
+			}
+		})
+	}
+}
+
+func BenchmarkAsymmetric_Verify(b *testing.B) {
+	data := []byte("test data")
+	for _, method := range asymmetricMethods {
+		b.Run(string(method), func(b *testing.B) {
+			alg, privateKey, publicKey := newBenchKeyManager(b, method)
+			sig, _ := alg.Sign(data, privateKey)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = alg.Verify(data, sig, publicKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/auth/jwt/crypto/tests/mock"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+func TestKeyManager_EncryptionKeys(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewMockLogger()
+
+	signing, err := crypto.NewKeyManager(jwt.ES256, logger)
+	require.NoError(t, err)
+	require.NoError(t, signing.InitializeKeys(ctx, nil))
+
+	for _, alg := range []jwt.KeyAlgorithm{jwt.RSAOAEP, jwt.RSAOAEP256} {
+		t.Run(string(alg), func(t *testing.T) {
+			encryption, err := crypto.NewKeyManager(jwt.RS256, logger)
+			require.NoError(t, err)
+			require.NoError(t, encryption.InitializeKeys(ctx, nil))
+			keys, err := encryption.EncryptionKeys(alg)
+			require.NoError(t, err)
+
+			tm, err := crypto.NewTokenManager(signing, jwt.WithoutTracing(), jwt.WithoutMetrics(), jwt.WithEncryption(keys))
+			require.NoError(t, err)
+
+			claims := newTestClaims()
+			claims.UserName = "alice"
+			tokenString, err := tm.GenerateToken(ctx, claims)
+			require.NoError(t, err)
+			require.True(t, jwt.IsJWE(tokenString))
+
+			// 头部标明嵌套令牌，载荷不可读
+			header, err := base64.RawURLEncoding.DecodeString(strings.Split(tokenString, ".")[0])
+			require.NoError(t, err)
+			assert.Contains(t, string(header), `"alg":"`+string(alg)+`"`)
+			assert.Contains(t, string(header), `"cty":"JWT"`)
+			assert.NotContains(t, tokenString, base64.RawURLEncoding.EncodeToString([]byte("alice")))
+
+			token, err := tm.ValidateToken(ctx, tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", token.Claims.(*jwt.StandardClaims).UserName)
+
+			// 轮换加密密钥后旧令牌无法解密
+			require.NoError(t, encryption.RotateKeys(ctx))
+			_, err = tm.ValidateToken(ctx, tokenString)
+			assert.True(t, errors.HasErrorCode(err, codes.DecryptionError))
+		})
+	}
+
+	// 密钥类型与算法不匹配
+	_, err = signing.EncryptionKeys(jwt.RSAOAEP)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+	_, err = signing.EncryptionKeys(jwt.Direct)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+}
+
+func TestKeyManager_DirectEncryption(t *testing.T) {
+	ctx := context.Background()
+	km, err := crypto.NewKeyManager(jwt.HS256, mock.NewMockLogger())
+	require.NoError(t, err)
+	require.NoError(t, km.InitializeKeys(ctx, &jwt.KeyConfig{SecretKey: "0123456789abcdef0123456789abcdef"}))
+	keys, err := km.EncryptionKeys(jwt.Direct)
+	require.NoError(t, err)
+
+	tokenString, err := jwt.EncryptJWE(keys, []byte(`{"ip_address":"10.0.0.1"}`), "")
+	require.NoError(t, err)
+	plaintext, header, err := jwt.DecryptJWE(keys, tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.Direct, header.Algorithm)
+	assert.Equal(t, jwt.A256GCM, header.Encryption)
+	assert.JSONEq(t, `{"ip_address":"10.0.0.1"}`, string(plaintext))
+
+	// dir 要求 32 字节密钥
+	short, err := crypto.NewKeyManager(jwt.HS256, mock.NewMockLogger())
+	require.NoError(t, err)
+	require.NoError(t, short.InitializeKeys(ctx, &jwt.KeyConfig{SecretKey: "short"}))
+	keys, err = short.EncryptionKeys(jwt.Direct)
+	require.NoError(t, err)
+	_, err = jwt.EncryptJWE(keys, []byte("payload"), "")
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+}
@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/auth/jwt/crypto/tests/mock"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+var asymmetricMethods = []jwt.SigningMethod{
+	jwt.RS256, jwt.RS384, jwt.RS512,
+	jwt.PS256, jwt.PS384, jwt.PS512,
+	jwt.ES256, jwt.ES384, jwt.ES512,
+	jwt.EdDSA,
+}
+
+// newInitializedKeyManager 创建自动生成密钥的密钥管理器
+func newInitializedKeyManager(t *testing.T, method jwt.SigningMethod) *crypto.KeyManager {
+	km, err := crypto.NewKeyManager(method, mock.NewMockLogger())
+	require.NoError(t, err)
+	require.NoError(t, km.InitializeKeys(context.Background(), nil))
+	return km
+}
+
+// TestAlgorithm_CrossVerify 与 golang-jwt 互相验证签名
+func TestAlgorithm_CrossVerify(t *testing.T) {
+	for _, method := range asymmetricMethods {
+		t.Run(string(method), func(t *testing.T) {
+			km := newInitializedKeyManager(t, method)
+			privateKey, err := km.GetSigningKey()
+			require.NoError(t, err)
+			publicKey, err := km.GetVerificationKey()
+			require.NoError(t, err)
+
+			alg, err := crypto.CreateAlgorithm(method)
+			require.NoError(t, err)
+			assert.Equal(t, method, alg.Name())
+			lib := jwtlib.GetSigningMethod(string(method))
+			require.NotNil(t, lib)
+
+			signingString := "eyJhbGciOiJ0ZXN0In0.eyJzdWIiOiJ1c2VyLTEifQ"
+
+			// crypto 签名，golang-jwt 验证
+			signature, err := alg.Sign([]byte(signingString), privateKey)
+			require.NoError(t, err)
+			assert.NoError(t, lib.Verify(signingString, signature, publicKey))
+
+			// golang-jwt 签名，crypto 验证
+			signature, err = lib.Sign(signingString, privateKey)
+			require.NoError(t, err)
+			assert.NoError(t, alg.Verify([]byte(signingString), signature, publicKey))
+
+			// 篡改数据后验证失败
+			err = alg.Verify([]byte(signingString+"x"), signature, publicKey)
+			assert.True(t, errors.HasErrorCode(err, codes.SignatureInvalid))
+		})
+	}
+}
+
+// TestAlgorithm_CrossVerifyToken 完整令牌与 golang-jwt 互相解析
+func TestAlgorithm_CrossVerifyToken(t *testing.T) {
+	ctx := context.Background()
+	for _, method := range []jwt.SigningMethod{jwt.PS384, jwt.ES384, jwt.EdDSA} {
+		t.Run(string(method), func(t *testing.T) {
+			km := newInitializedKeyManager(t, method)
+			tm, err := crypto.NewTokenManager(km, jwt.WithoutTracing(), jwt.WithoutMetrics())
+			require.NoError(t, err)
+			privateKey, _ := km.GetSigningKey()
+			publicKey, _ := km.GetVerificationKey()
+
+			tokenString, err := tm.GenerateToken(ctx, newTestClaims())
+			require.NoError(t, err)
+			parsed, err := jwtlib.Parse(tokenString, func(*jwtlib.Token) (interface{}, error) {
+				return publicKey, nil
+			}, jwtlib.WithValidMethods([]string{string(method)}))
+			require.NoError(t, err)
+			assert.True(t, parsed.Valid)
+
+			tokenString, err = jwtlib.NewWithClaims(jwtlib.GetSigningMethod(string(method)), newTestClaims()).
+				SignedString(privateKey)
+			require.NoError(t, err)
+			_, err = tm.ValidateToken(ctx, tokenString)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestECDSA_SignatureFormat(t *testing.T) {
+	km := newInitializedKeyManager(t, jwt.ES512)
+	privateKey, _ := km.GetSigningKey()
+	alg, err := crypto.NewECDSA(jwt.ES512)
+	require.NoError(t, err)
+
+	// P-521 的 r||s 固定为 132 字节
+	signature, err := alg.Sign([]byte("data"), privateKey)
+	require.NoError(t, err)
+	assert.Len(t, signature, 132)
+
+	// 曲线与签名方法不一致
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = alg.Sign([]byte("data"), p256)
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+
+	err = alg.Verify([]byte("data"), signature[:64], &privateKey.(*ecdsa.PrivateKey).PublicKey)
+	assert.True(t, errors.HasErrorCode(err, codes.SignatureInvalid))
+}
+
+func TestKeyManager_InitializeKeysPEM(t *testing.T) {
+	ctx := context.Background()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	encode := func(typ string, der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}))
+	}
+
+	tests := []struct {
+		name      string
+		method    jwt.SigningMethod
+		pem       string
+		expectErr bool
+	}{
+		{name: "ES384 SEC1", method: jwt.ES384, pem: encode("EC PRIVATE KEY", sec1)},
+		{name: "ES384 PKCS8", method: jwt.ES384, pem: encode("PRIVATE KEY", ecPKCS8)},
+		{name: "EdDSA PKCS8", method: jwt.EdDSA, pem: encode("PRIVATE KEY", edPKCS8)},
+		{name: "PS256 PKCS8 RSA", method: jwt.PS256, pem: mock.TestRSAPrivateKeyPEM},
+		{name: "ES256 with P-384 key", method: jwt.ES256, pem: encode("EC PRIVATE KEY", sec1), expectErr: true},
+		{name: "EdDSA with RSA key", method: jwt.EdDSA, pem: mock.TestRSAPrivateKeyPEM, expectErr: true},
+		{name: "RS256 with EC key", method: jwt.RS256, pem: encode("PRIVATE KEY", ecPKCS8), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := crypto.NewKeyManager(tt.method, mock.NewMockLogger())
+			require.NoError(t, err)
+
+			err = km.InitializeKeys(ctx, &jwt.KeyConfig{PrivateKey: tt.pem})
+			if tt.expectErr {
+				assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+				return
+			}
+			require.NoError(t, err)
+
+			// 解析出的密钥可以签发令牌
+			tm, err := crypto.NewTokenManager(km, jwt.WithoutTracing(), jwt.WithoutMetrics())
+			require.NoError(t, err)
+			tokenString, err := tm.GenerateToken(ctx, newTestClaims())
+			require.NoError(t, err)
+			header, err := base64.RawURLEncoding.DecodeString(strings.Split(tokenString, ".")[0])
+			require.NoError(t, err)
+			assert.Contains(t, string(header), string(tt.method))
+		})
+	}
+}
+
+func TestKeyManager_RotateAsymmetricKeys(t *testing.T) {
+	for _, method := range []jwt.SigningMethod{jwt.ES256, jwt.PS512, jwt.EdDSA} {
+		t.Run(string(method), func(t *testing.T) {
+			km := newInitializedKeyManager(t, method)
+			before, _ := km.GetVerificationKey()
+			require.NoError(t, km.RotateKeys(context.Background()))
+			after, _ := km.GetVerificationKey()
+			assert.NotEqual(t, before, after)
+		})
+	}
+}
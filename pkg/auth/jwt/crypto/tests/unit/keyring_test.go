@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/auth/jwt/events"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// recordingPublisher 记录发布的事件
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (p *recordingPublisher) PublishEvent(_ context.Context, event *events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestKeyring_GracePeriod(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	keyring, err := crypto.NewKeyring(jwt.ES256,
+		crypto.WithGracePeriod(time.Hour),
+		crypto.WithKeyringClock(func() time.Time { return now }),
+	)
+	require.NoError(t, err)
+	tm, err := crypto.NewTokenManager(keyring, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	before, err := tm.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	token, err := tm.ValidateToken(ctx, before)
+	require.NoError(t, err)
+	oldKID := keyring.ActiveKeyID()
+	assert.Equal(t, oldKID, token.Header["kid"])
+
+	key, err := keyring.Rotate(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKID, key.ID)
+	assert.Len(t, keyring.Keys(), 2)
+
+	// 宽限期内旧令牌仍然有效，新令牌使用新密钥
+	_, err = tm.ValidateToken(ctx, before)
+	assert.NoError(t, err)
+	after, err := tm.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	token, err = tm.ValidateToken(ctx, after)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, token.Header["kid"])
+
+	// 超过宽限期后旧密钥被移除
+	now = now.Add(time.Hour)
+	_, err = tm.ValidateToken(ctx, before)
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+	_, err = tm.ValidateToken(ctx, after)
+	assert.NoError(t, err)
+	assert.Len(t, keyring.Keys(), 1)
+}
+
+func TestKeyring_KeyRotatedEvent(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	issuer, err := crypto.NewKeyring(jwt.EdDSA, crypto.WithKeyringPublisher(publisher))
+	require.NoError(t, err)
+	verifier, err := crypto.NewKeyring(jwt.EdDSA, crypto.WithEventKeyImport())
+	require.NoError(t, err)
+
+	issuerTM, err := crypto.NewTokenManager(issuer, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	verifierTM, err := crypto.NewTokenManager(verifier, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	key, err := issuer.Rotate(ctx)
+	require.NoError(t, err)
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, events.KeyRotated, event.Type)
+	assert.Equal(t, key.ID, event.KeyID)
+	assert.NotContains(t, event.Payload["public_key"], "PRIVATE")
+
+	tokenString, err := issuerTM.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+
+	// 导入前其他实例不认识该 kid
+	_, err = verifierTM.ValidateToken(ctx, tokenString)
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+
+	// 未开启 WithEventKeyImport 时不导入事件中的公钥
+	untrusted, err := crypto.NewKeyring(jwt.EdDSA)
+	require.NoError(t, err)
+	err = untrusted.HandleKeyRotated(event)
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+	assert.Len(t, untrusted.Keys(), 1)
+
+	require.NoError(t, verifier.HandleKeyRotated(event))
+	_, err = verifierTM.ValidateToken(ctx, tokenString)
+	assert.NoError(t, err)
+
+	// 导入的密钥只用于验证，本实例仍使用自己的签名密钥
+	assert.NotEqual(t, key.ID, verifier.ActiveKeyID())
+
+	// 签名方法不同的事件被拒绝
+	other, err := crypto.NewKeyring(jwt.ES256, crypto.WithEventKeyImport())
+	require.NoError(t, err)
+	err = other.HandleKeyRotated(event)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+}
+
+func TestKeyring_Invalid(t *testing.T) {
+	_, err := crypto.NewKeyring(jwt.HS256)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+
+	_, err = crypto.NewKeyring(jwt.RS256, crypto.WithGracePeriod(-time.Second))
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+}
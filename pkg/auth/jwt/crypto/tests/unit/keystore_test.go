@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/lock"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+// newRedisClient 创建连接到 miniredis 的客户端
+func newRedisClient(t *testing.T) (*miniredis.Miniredis, redis.Client) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestFileKeyStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keyring")
+	store, err := crypto.NewFileKeyStore(path, testEncryptionKey)
+	require.NoError(t, err)
+
+	first, err := crypto.LoadKeyring(ctx, jwt.ES256, store)
+	require.NoError(t, err)
+	second, err := crypto.LoadKeyring(ctx, jwt.ES256, store)
+	require.NoError(t, err)
+	assert.Equal(t, first.ActiveKeyID(), second.ActiveKeyID())
+
+	// 文件内容已加密
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "PRIVATE KEY")
+
+	// 轮换后其他实例重新加载即可验证新令牌
+	key, err := first.Rotate(ctx)
+	require.NoError(t, err)
+	require.NoError(t, second.Reload(ctx))
+	assert.Equal(t, key.ID, second.ActiveKeyID())
+	assert.Len(t, second.Keys(), 2)
+
+	issuer, err := crypto.NewTokenManager(first, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	verifier, err := crypto.NewTokenManager(second, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	tokenString, err := issuer.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	_, err = verifier.ValidateToken(ctx, tokenString)
+	assert.NoError(t, err)
+
+	// 加密密钥错误时无法读取
+	wrong, err := crypto.NewFileKeyStore(path, []byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	_, err = wrong.Load(ctx)
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+
+	_, err = crypto.NewFileKeyStore(path, []byte("short"))
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+}
+
+func TestRedisKeyStore_SharedRotation(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newRedisClient(t)
+	locker, err := lock.NewLocker(client, lock.WithAutoRenew(false))
+	require.NoError(t, err)
+
+	store, err := crypto.NewRedisKeyStore(client, crypto.WithEncryptionKey(testEncryptionKey))
+	require.NoError(t, err)
+
+	newReplica := func() *crypto.Keyring {
+		keyring, err := crypto.LoadKeyring(ctx, jwt.RS256, store,
+			crypto.WithRotationLock(locker, "jwt:keyring"),
+		)
+		require.NoError(t, err)
+		return keyring
+	}
+	leader, follower := newReplica(), newReplica()
+	assert.Equal(t, leader.ActiveKeyID(), follower.ActiveKeyID())
+
+	value, err := mr.Get("jwt:keyring")
+	require.NoError(t, err)
+	assert.NotContains(t, value, "PRIVATE KEY")
+
+	// 同一周期内第二个实例发现已经轮换，直接使用存储中的密钥
+	key, err := leader.Rotate(ctx)
+	require.NoError(t, err)
+	skipped, err := follower.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, skipped.ID)
+	assert.Equal(t, key.ID, follower.ActiveKeyID())
+
+	// 其他实例持有锁时不轮换
+	lk, err := locker.TryLock(ctx, "jwt:keyring")
+	require.NoError(t, err)
+	_, err = leader.Rotate(ctx)
+	assert.True(t, errors.HasErrorCode(err, codes.RotationFailed))
+	require.NoError(t, lk.Unlock(ctx))
+}
+
+func TestKeyring_ReloadOnEvent(t *testing.T) {
+	ctx := context.Background()
+	_, client := newRedisClient(t)
+	store, err := crypto.NewRedisKeyStore(client)
+	require.NoError(t, err)
+
+	publisher := &recordingPublisher{}
+	leader, err := crypto.LoadKeyring(ctx, jwt.EdDSA, store, crypto.WithKeyringPublisher(publisher))
+	require.NoError(t, err)
+	follower, err := crypto.LoadKeyring(ctx, jwt.EdDSA, store)
+	require.NoError(t, err)
+
+	key, err := leader.Rotate(ctx)
+	require.NoError(t, err)
+	require.Len(t, publisher.events, 1)
+
+	// 使用共享存储时事件触发重新加载，签名密钥随之切换
+	require.NoError(t, follower.HandleKeyRotated(publisher.events[0]))
+	assert.Equal(t, key.ID, follower.ActiveKeyID())
+
+	// 存储中的签名方法不一致时拒绝加载
+	_, err = crypto.LoadKeyring(ctx, jwt.ES256, store)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/config"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/auth/jwt/crypto/tests/mock"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+func newTestClaims() *jwt.StandardClaims {
+	return jwt.NewStandardClaims(
+		jwt.WithUserID("user-1"),
+		jwt.WithTokenType(jwt.AccessToken),
+		jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+	)
+}
+
+func newTokenManager(t *testing.T, cfg *config.Config) (*crypto.KeyManager, *jwt.TokenManager) {
+	km, err := crypto.NewKeyManagerFromConfig(context.Background(), cfg, mock.NewMockLogger())
+	require.NoError(t, err)
+	tm, err := crypto.NewTokenManager(km, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	return km, tm
+}
+
+func TestTokenManager_SigningMethods(t *testing.T) {
+	ctx := context.Background()
+
+	for _, cfg := range []*config.Config{
+		{SigningMethod: jwt.HS384, SecretKey: "secret"},
+		{SigningMethod: jwt.RS256, PrivateKey: mock.TestRSAPrivateKeyPEM},
+		{SigningMethod: jwt.RS512},
+		{SigningMethod: jwt.PS256},
+		{SigningMethod: jwt.ES256},
+		{SigningMethod: jwt.ES512},
+		{SigningMethod: jwt.EdDSA},
+	} {
+		t.Run(string(cfg.SigningMethod), func(t *testing.T) {
+			_, tm := newTokenManager(t, cfg)
+			assert.Equal(t, cfg.SigningMethod, tm.SigningMethod())
+
+			tokenString, err := tm.GenerateToken(ctx, newTestClaims())
+			require.NoError(t, err)
+
+			token, err := tm.ValidateToken(ctx, tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, string(cfg.SigningMethod), token.Method.Alg())
+		})
+	}
+}
+
+func TestTokenManager_AlgorithmConfusion(t *testing.T) {
+	ctx := context.Background()
+	_, rsaManager := newTokenManager(t, &config.Config{SigningMethod: jwt.RS256, PrivateKey: mock.TestRSAPrivateKeyPEM})
+
+	// 使用公钥作为 HMAC 密钥伪造的令牌
+	forged, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, newTestClaims()).
+		SignedString([]byte(mock.TestRSAPublicKeyPEM))
+	require.NoError(t, err)
+	_, err = rsaManager.ValidateToken(ctx, forged)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+
+	// 无签名的令牌
+	unsigned, err := jwtlib.NewWithClaims(jwtlib.SigningMethodNone, newTestClaims()).
+		SignedString(jwtlib.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = rsaManager.ValidateToken(ctx, unsigned)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+
+	// 同族的其他算法同样拒绝
+	_, rs512Manager := newTokenManager(t, &config.Config{SigningMethod: jwt.RS512, PrivateKey: mock.TestRSAPrivateKeyPEM})
+	tokenString, err := rs512Manager.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	_, err = rsaManager.ValidateToken(ctx, tokenString)
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+}
+
+func TestTokenManager_RotateKeys(t *testing.T) {
+	ctx := context.Background()
+	km, tm := newTokenManager(t, &config.Config{SigningMethod: jwt.RS256})
+
+	before, err := tm.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	require.NoError(t, km.RotateKeys(ctx))
+
+	_, err = tm.ValidateToken(ctx, before)
+	assert.True(t, errors.HasErrorCode(err, codes.SignatureInvalid))
+
+	after, err := tm.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	_, err = tm.ValidateToken(ctx, after)
+	assert.NoError(t, err)
+}
+
+func TestNewKeyManagerFromConfig_Invalid(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := crypto.NewKeyManagerFromConfig(ctx, &config.Config{SigningMethod: jwt.HS256}, mock.NewMockLogger())
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+
+	_, err = crypto.NewKeyManagerFromConfig(ctx, &config.Config{SigningMethod: "XS256"}, mock.NewMockLogger())
+	assert.True(t, errors.HasErrorCode(err, codes.AlgorithmMismatch))
+}
@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"context"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/config"
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// 确保 KeyManager 实现 KeyProvider
+var _ KeyProvider = (*KeyManager)(nil)
+
+// NewKeyManagerFromConfig 按配置的签名方法和密钥创建并初始化密钥管理器
+// RSA 未配置私钥时自动生成密钥对
+func NewKeyManagerFromConfig(ctx context.Context, cfg *config.Config, logger types.Logger) (*KeyManager, error) {
+	if cfg == nil {
+		return nil, errors.NewKeyInvalidError("jwt config is required", nil)
+	}
+
+	km, err := NewKeyManager(cfg.SigningMethod, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := km.InitializeKeys(ctx, &jwt.KeyConfig{
+		SecretKey:  cfg.SecretKey,
+		PrivateKey: cfg.PrivateKey,
+		PublicKey:  cfg.PublicKey,
+	}); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// NewTokenManager 创建使用密钥提供者签名和验证的 TokenManager
+// 轮换密钥后新签发的令牌使用新密钥，使用旧密钥签发的令牌不再能通过验证
+func NewTokenManager(provider KeyProvider, opts ...jwt.TokenManagerOption) (*jwt.TokenManager, error) {
+	return jwt.NewTokenManagerWithKeys(provider, opts...)
+}
+
+// NewTokenManagerFromConfig 按配置创建密钥管理器和 TokenManager
+// 访问令牌和刷新令牌的有效期取自配置，opts 在其后应用
+func NewTokenManagerFromConfig(ctx context.Context, cfg *config.Config, logger types.Logger, opts ...jwt.TokenManagerOption) (*jwt.TokenManager, error) {
+	km, err := NewKeyManagerFromConfig(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.NewTokenManagerWithKeys(km, append(cfg.TokenManagerOptions(), opts...)...)
+}
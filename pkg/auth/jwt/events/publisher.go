@@ -59,17 +59,25 @@ func NewPublisher(client redis.Client, logger types.Logger, options ...Option) (
 
 // Publish 发布事件
 func (p *Publisher) Publish(ctx context.Context, eventType EventType, data map[string]interface{}) error {
+	return p.PublishEvent(ctx, &Event{
+		Type:    eventType,
+		Payload: data,
+	})
+}
+
+// PublishEvent 发布完整事件，可以设置 UserID、TokenID、KeyID 等字段
+// 未设置 ID 和 Timestamp 时自动生成
+func (p *Publisher) PublishEvent(ctx context.Context, event *Event) error {
 	span, ctx := jaeger.StartSpanFromContext(ctx, "events.publish")
 	if span != nil {
 		defer span.Finish()
 	}
 
-	// 创建事件
-	event := &Event{
-		ID:        uuid.New().String(),
-		Type:      eventType,
-		Timestamp: time.Now(),
-		Payload:   data,
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
 	}
 
 	// 序列化事件
@@ -0,0 +1,61 @@
+package introspection
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/errors"
+)
+
+// ClientAuthenticator 认证调用内省和吊销端点的客户端
+type ClientAuthenticator interface {
+	// Authenticate 认证请求并返回客户端ID，认证失败时返回错误
+	Authenticate(c *gin.Context) (string, error)
+}
+
+// ClientAuthenticatorFunc 函数形式的客户端认证器
+type ClientAuthenticatorFunc func(c *gin.Context) (string, error)
+
+// Authenticate 实现 ClientAuthenticator 接口
+func (f ClientAuthenticatorFunc) Authenticate(c *gin.Context) (string, error) {
+	return f(c)
+}
+
+// staticClients 固定的客户端凭据，保存密钥的 SHA-256 摘要
+type staticClients map[string][32]byte
+
+// NewStaticClients 使用固定的 client_id 和 client_secret 创建认证器
+// 支持 HTTP Basic 认证(client_secret_basic)和表单参数(client_secret_post)
+func NewStaticClients(credentials map[string]string) (ClientAuthenticator, error) {
+	if len(credentials) == 0 {
+		return nil, errors.NewConfigInvalidError("at least one client is required", nil)
+	}
+	clients := make(staticClients, len(credentials))
+	for id, secret := range credentials {
+		if id == "" || secret == "" {
+			return nil, errors.NewConfigInvalidError("client id and secret are required", nil)
+		}
+		clients[id] = sha256.Sum256([]byte(secret))
+	}
+	return clients, nil
+}
+
+// Authenticate 实现 ClientAuthenticator 接口，比较摘要以避免时序泄露
+func (s staticClients) Authenticate(c *gin.Context) (string, error) {
+	id, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		id, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if id == "" || secret == "" {
+		return "", errors.NewUnauthorizedError("client credentials are required", nil)
+	}
+
+	expected, known := s[id]
+	actual := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(expected[:], actual[:]) != 1 || !known {
+		return "", errors.NewUnauthorizedError("invalid client credentials", nil)
+	}
+	return id, nil
+}
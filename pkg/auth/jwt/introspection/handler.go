@@ -0,0 +1,457 @@
+package introspection
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/session"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+)
+
+// 端点的默认路径
+const (
+	// IntrospectionPath 令牌内省端点(RFC 7662)
+	IntrospectionPath = "/oauth2/introspect"
+	// RevocationPath 令牌吊销端点(RFC 7009)
+	RevocationPath = "/oauth2/revoke"
+)
+
+// OAuth 2.0 错误码(RFC 6749 5.2)
+const (
+	errInvalidRequest         = "invalid_request"
+	errInvalidClient          = "invalid_client"
+	errUnauthorizedClient     = "unauthorized_client"
+	errTemporarilyUnavailable = "temporarily_unavailable"
+)
+
+// defaultRevocationTTL 令牌没有过期时间时黑名单条目的保留时间
+const defaultRevocationTTL = 24 * time.Hour
+
+// TokenValidator 令牌验证器，*jwt.TokenManager 实现了该接口
+// 验证包括签名、有效期、声明，以及 TokenManager 配置的黑名单
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, tokenString string) (*jwtlib.Token, error)
+}
+
+// FamilyRevoker 令牌族吊销，*jwt.TokenManager 实现了该接口
+// 吊销刷新令牌时同时吊销同一令牌族的访问令牌
+type FamilyRevoker interface {
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+// ClientIDProvider 记录了签发对象的claims，默认的吊销授权检查使用它
+// jwt.StandardClaims 实现了该接口，签发时设置 ClientID 即可
+type ClientIDProvider interface {
+	GetClientID() string
+}
+
+// RevocationAuthorizer 判断客户端能否吊销令牌，claims 为已通过验证的令牌声明
+type RevocationAuthorizer func(ctx context.Context, clientID string, claims jwt.Claims) bool
+
+// Option 处理器选项
+type Option func(*Handler)
+
+// WithBlacklist 设置黑名单，内省时检查，吊销时写入令牌ID和令牌原文
+func WithBlacklist(blacklist jwt.Blacklist) Option {
+	return func(h *Handler) {
+		h.blacklist = blacklist
+	}
+}
+
+// WithSessionStore 设置会话存储，内省时会话不存在的令牌视为无效，吊销时删除会话
+func WithSessionStore(store session.Store) Option {
+	return func(h *Handler) {
+		h.sessions = store
+	}
+}
+
+// WithRateLimiter 按客户端IP限制请求频率，在客户端认证之前执行
+func WithRateLimiter(limiter core.Limiter, limit int64, window time.Duration) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+		h.limit = limit
+		h.window = window
+	}
+}
+
+// WithRevocationAuthorizer 设置吊销授权检查，替代默认的签发对象比对
+// 默认只允许吊销 ClientID 与请求客户端一致的令牌，没有签发对象的令牌一律拒绝
+func WithRevocationAuthorizer(authorize RevocationAuthorizer) Option {
+	return func(h *Handler) {
+		h.authorize = authorize
+	}
+}
+
+// WithExtraClaims 在内省响应中额外返回指定的声明，如 "roles"
+// 默认只返回 RFC 7662 定义的成员，设备ID、IP地址等个人信息不应返回给所有客户端
+func WithExtraClaims(names ...string) Option {
+	return func(h *Handler) {
+		h.extraClaims = append(h.extraClaims, names...)
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// Handler 令牌内省和吊销端点
+type Handler struct {
+	validator   TokenValidator
+	clients     ClientAuthenticator
+	blacklist   jwt.Blacklist
+	sessions    session.Store
+	limiter     core.Limiter
+	limit       int64
+	window      time.Duration
+	authorize   RevocationAuthorizer
+	extraClaims []string
+	logger      types.Logger
+}
+
+// NewHandler 创建内省和吊销端点处理器，两个端点都要求客户端认证
+func NewHandler(validator TokenValidator, clients ClientAuthenticator, opts ...Option) (*Handler, error) {
+	if validator == nil {
+		return nil, errors.NewConfigInvalidError("token validator is required", nil)
+	}
+	if clients == nil {
+		return nil, errors.NewConfigInvalidError("client authenticator is required", nil)
+	}
+
+	h := &Handler{
+		validator: validator,
+		clients:   clients,
+		authorize: issuedToClient,
+		logger:    &types.NoopLogger{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.authorize == nil {
+		return nil, errors.NewConfigInvalidError("revocation authorizer is required", nil)
+	}
+	if h.limiter != nil && (h.limit <= 0 || h.window <= 0) {
+		return nil, errors.NewConfigInvalidError("rate limit and window must be positive", nil)
+	}
+	return h, nil
+}
+
+// Register 在 IntrospectionPath 和 RevocationPath 注册处理器
+func (h *Handler) Register(routes gin.IRoutes) {
+	routes.POST(IntrospectionPath, h.Introspect())
+	routes.POST(RevocationPath, h.Revoke())
+}
+
+// Introspect 令牌内省(RFC 7662)
+// 有效令牌返回 active 为 true 及 RFC 7662 定义的成员，无效、已吊销或会话不存在的令牌只返回 active 为 false
+func (h *Handler) Introspect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, tokenString, ok := h.prepare(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		response, active := h.introspect(ctx, tokenString)
+		if !active {
+			c.JSON(http.StatusOK, gin.H{"active": false})
+			return
+		}
+
+		h.logger.Debug(ctx, "token introspected",
+			types.Field{Key: "client_id", Value: clientID},
+			types.Field{Key: "jti", Value: response["jti"]},
+		)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// Revoke 令牌吊销(RFC 7009)
+// 无效或未知的令牌同样返回 200，令牌不属于请求客户端时拒绝吊销并返回 400，暂时无法吊销时返回 503
+func (h *Handler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, tokenString, ok := h.prepare(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		claims, ok := h.validate(ctx, tokenString)
+		if !ok {
+			// 无效的令牌无需吊销
+			c.Status(http.StatusOK)
+			return
+		}
+		if !h.authorize(ctx, clientID, claims) {
+			h.logger.Warn(ctx, "client is not allowed to revoke token",
+				types.Field{Key: "client_id", Value: clientID},
+				types.Field{Key: "jti", Value: claims.GetTokenID()},
+			)
+			writeError(c, http.StatusBadRequest, errUnauthorizedClient, "token was not issued to the client")
+			return
+		}
+
+		if err := h.revoke(ctx, tokenString, claims); err != nil {
+			h.logger.Error(ctx, "failed to revoke token",
+				types.Field{Key: "client_id", Value: clientID},
+				types.Error(err),
+			)
+			writeError(c, http.StatusServiceUnavailable, errTemporarilyUnavailable, "token could not be revoked")
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// prepare 限流、认证客户端并读取 token 参数，失败时已写入响应
+func (h *Handler) prepare(c *gin.Context) (string, string, bool) {
+	// 响应包含令牌信息，不能缓存
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if h.limiter != nil {
+		retryAfter, err := h.allow(c.Request.Context(), "jwt:introspection:"+c.ClientIP())
+		if err != nil {
+			h.logger.Error(c.Request.Context(), "rate limit check failed", types.Error(err))
+			writeError(c, http.StatusServiceUnavailable, errTemporarilyUnavailable, "rate limit check failed")
+			return "", "", false
+		}
+		if retryAfter > 0 {
+			// RFC 6749 没有对应的错误码，只返回 429 和 Retry-After
+			c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return "", "", false
+		}
+	}
+
+	clientID, err := h.clients.Authenticate(c)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="token"`)
+		writeError(c, http.StatusUnauthorized, errInvalidClient, "client authentication failed")
+		return "", "", false
+	}
+
+	// token_type_hint 只是提示，令牌类型从令牌本身得到
+	tokenString := c.PostForm("token")
+	if tokenString == "" {
+		writeError(c, http.StatusBadRequest, errInvalidRequest, "token parameter is required")
+		return "", "", false
+	}
+	return clientID, tokenString, true
+}
+
+// allow 执行限流判断，被拒绝时返回需要等待的时长
+// 限流器实现 core.ResultLimiter 时使用其重试时间，否则等待一个窗口
+func (h *Handler) allow(ctx context.Context, key string) (time.Duration, error) {
+	if limiter, ok := h.limiter.(core.ResultLimiter); ok {
+		result, err := limiter.Take(ctx, key, 1, h.limit, h.window)
+		if err != nil {
+			return 0, err
+		}
+		if result.Allowed {
+			return 0, nil
+		}
+		if result.RetryAfter > 0 {
+			return result.RetryAfter, nil
+		}
+		return h.window, nil
+	}
+
+	allowed, err := h.limiter.Allow(ctx, key, h.limit, h.window)
+	if err != nil || allowed {
+		return 0, err
+	}
+	return h.window, nil
+}
+
+// introspect 验证令牌并生成内省响应，令牌无效时返回 false
+func (h *Handler) introspect(ctx context.Context, tokenString string) (map[string]interface{}, bool) {
+	claims, ok := h.validate(ctx, tokenString)
+	if !ok {
+		return nil, false
+	}
+
+	if h.blacklist != nil {
+		revoked, err := h.blacklist.IsBlacklisted(ctx, tokenString)
+		if err != nil {
+			// 无法确认状态时视为无效
+			h.logger.Error(ctx, "failed to check token blacklist", types.Error(err))
+			return nil, false
+		}
+		if revoked {
+			return nil, false
+		}
+	}
+
+	if h.sessions != nil {
+		if _, err := h.sessions.Get(ctx, claims.GetTokenID()); err != nil {
+			if !isNotFound(err) {
+				h.logger.Error(ctx, "failed to load token session", types.Error(err))
+			}
+			return nil, false
+		}
+	}
+
+	response := rfc7662Response(claims)
+	if len(h.extraClaims) > 0 {
+		if err := h.addExtraClaims(response, claims); err != nil {
+			h.logger.Error(ctx, "failed to encode token claims", types.Error(err))
+			return nil, false
+		}
+	}
+	return response, true
+}
+
+// rfc7662Response 按 RFC 7662 2.2 定义的成员生成内省响应，不包含其他声明
+func rfc7662Response(claims jwt.Claims) map[string]interface{} {
+	response := map[string]interface{}{"active": true}
+	setString := func(name, value string) {
+		if value != "" {
+			response[name] = value
+		}
+	}
+	setTime := func(name string, date *jwtlib.NumericDate, err error) {
+		if err == nil && date != nil {
+			response[name] = date.Unix()
+		}
+	}
+
+	// 内省的 token_type 是 OAuth 令牌类型，只有访问令牌以 Bearer 方式使用
+	if claims.GetTokenType() == jwt.AccessToken {
+		response["token_type"] = "Bearer"
+	}
+	if scoped, ok := claims.(interface{ GetScope() string }); ok {
+		setString("scope", scoped.GetScope())
+	}
+	if provider, ok := claims.(ClientIDProvider); ok {
+		setString("client_id", provider.GetClientID())
+	}
+	setString("username", claims.GetUserName())
+
+	subject := claims.GetUserID()
+	if subject == "" {
+		subject, _ = claims.GetSubject()
+	}
+	setString("sub", subject)
+
+	exp, err := claims.GetExpirationTime()
+	setTime("exp", exp, err)
+	iat, err := claims.GetIssuedAt()
+	setTime("iat", iat, err)
+	nbf, err := claims.GetNotBefore()
+	setTime("nbf", nbf, err)
+	if issuer, err := claims.GetIssuer(); err == nil {
+		setString("iss", issuer)
+	}
+	if audience, err := claims.GetAudience(); err == nil && len(audience) > 0 {
+		response["aud"] = []string(audience)
+	}
+	setString("jti", claims.GetTokenID())
+	return response
+}
+
+// addExtraClaims 将 WithExtraClaims 指定的声明加入响应，不覆盖 RFC 7662 的成员
+func (h *Handler) addExtraClaims(response map[string]interface{}, claims jwt.Claims) error {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+	all := make(map[string]interface{})
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, name := range h.extraClaims {
+		if _, reserved := response[name]; reserved || name == "active" {
+			continue
+		}
+		if value, ok := all[name]; ok {
+			response[name] = value
+		}
+	}
+	return nil
+}
+
+// revoke 将令牌加入黑名单并删除会话，刷新令牌同时吊销其令牌族
+func (h *Handler) revoke(ctx context.Context, tokenString string, claims jwt.Claims) error {
+	ttl := defaultRevocationTTL
+	if exp := claims.GetExpiresAt(); !exp.IsZero() {
+		if ttl = time.Until(exp); ttl <= 0 {
+			return nil
+		}
+	}
+
+	if h.blacklist != nil {
+		// 中间件的黑名单验证器使用令牌原文，TokenManager 使用令牌ID
+		keys := []string{tokenString}
+		if tokenID := claims.GetTokenID(); tokenID != "" {
+			keys = append(keys, tokenID)
+		}
+		for _, key := range keys {
+			if err := h.blacklist.Add(ctx, key, ttl); err != nil {
+				return errors.NewTokenBlacklistError("failed to blacklist token", err)
+			}
+		}
+	}
+
+	if h.sessions != nil && claims.GetTokenID() != "" {
+		if err := h.sessions.Delete(ctx, claims.GetTokenID()); err != nil && !isNotFound(err) {
+			return errors.NewSessionInvalidError("failed to delete token session", err)
+		}
+	}
+
+	if family, ok := claims.(interface{ GetFamilyID() string }); ok && family.GetFamilyID() != "" &&
+		claims.GetTokenType() == jwt.RefreshToken {
+		if revoker, ok := h.validator.(FamilyRevoker); ok {
+			if err := revoker.RevokeFamily(ctx, family.GetFamilyID()); err != nil &&
+				!errors.HasErrorCode(err, codes.ConfigInvalid) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate 验证令牌，返回其声明
+func (h *Handler) validate(ctx context.Context, tokenString string) (jwt.Claims, bool) {
+	token, err := h.validator.ValidateToken(ctx, tokenString)
+	if err != nil {
+		h.logger.Debug(ctx, "token is not active", types.Error(err))
+		return nil, false
+	}
+	claims, ok := token.Claims.(jwt.Claims)
+	return claims, ok
+}
+
+// issuedToClient 默认的吊销授权检查，令牌的签发对象必须是请求客户端
+func issuedToClient(ctx context.Context, clientID string, claims jwt.Claims) bool {
+	provider, ok := claims.(ClientIDProvider)
+	return ok && provider.GetClientID() != "" && provider.GetClientID() == clientID
+}
+
+// writeError 按 RFC 6749 5.2 的格式返回错误
+func writeError(c *gin.Context, status int, code, description string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// isNotFound 判断会话存储返回的是否为不存在或已过期
+func isNotFound(err error) bool {
+	return errors.HasErrorCode(err, codes.RedisKeyNotFoundError) ||
+		errors.HasErrorCode(err, codes.SessionNotFound) ||
+		errors.HasErrorCode(err, codes.SessionExpired)
+}
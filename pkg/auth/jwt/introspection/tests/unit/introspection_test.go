@@ -0,0 +1,269 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/blacklist"
+	"gobase/pkg/auth/jwt/introspection"
+	"gobase/pkg/auth/jwt/session"
+	"gobase/pkg/auth/jwt/store"
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/memory"
+)
+
+// 内存黑名单会注册全局监控指标，测试之间共享同一个实例
+var (
+	testBlacklistOnce sync.Once
+	testBlacklist     blacklist.TokenBlacklist
+)
+
+func sharedBlacklist() blacklist.TokenBlacklist {
+	testBlacklistOnce.Do(func() {
+		testBlacklist = blacklist.NewStoreAdapter(blacklist.NewMemoryStore())
+	})
+	return testBlacklist
+}
+
+// testEnv 测试使用的令牌管理器、会话存储和路由
+type testEnv struct {
+	tm       *jwt.TokenManager
+	sessions session.Store
+	router   *gin.Engine
+}
+
+func newTestEnv(t *testing.T, opts ...introspection.Option) *testEnv {
+	gin.SetMode(gin.TestMode)
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()), redis.WithPoolSize(2))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	sessions := session.NewRedisStore(client, nil)
+
+	tokenStore, err := store.NewMemoryStore(store.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { tokenStore.Close() })
+	tm, err := jwt.NewTokenManager("test-secret-key",
+		jwt.WithoutTracing(),
+		jwt.WithoutMetrics(),
+		jwt.WithTokenStore(tokenStore),
+		jwt.WithBlacklist(sharedBlacklist()),
+	)
+	require.NoError(t, err)
+
+	clients, err := introspection.NewStaticClients(map[string]string{"gateway": "gateway-secret"})
+	require.NoError(t, err)
+	opts = append([]introspection.Option{
+		introspection.WithBlacklist(sharedBlacklist()),
+		introspection.WithSessionStore(sessions),
+	}, opts...)
+	handler, err := introspection.NewHandler(tm, clients, opts...)
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler.Register(router)
+	return &testEnv{tm: tm, sessions: sessions, router: router}
+}
+
+// issue 为 gateway 签发令牌对并为访问令牌创建会话
+func (e *testEnv) issue(t *testing.T) (*jwt.TokenPair, *jwt.StandardClaims) {
+	return e.issueTo(t, "gateway")
+}
+
+// issueTo 为指定客户端签发令牌对并为访问令牌创建会话
+func (e *testEnv) issueTo(t *testing.T, clientID string) (*jwt.TokenPair, *jwt.StandardClaims) {
+	ctx := context.Background()
+	pair, err := e.tm.IssuePair(ctx, &jwt.StandardClaims{
+		UserID:    "user-1",
+		UserName:  "alice",
+		IPAddress: "10.0.0.1",
+		ClientID:  clientID,
+	})
+	require.NoError(t, err)
+	token, err := e.tm.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	claims := token.Claims.(*jwt.StandardClaims)
+	require.NoError(t, e.sessions.Save(ctx, &session.Session{
+		UserID:    claims.UserID,
+		TokenID:   claims.TokenID,
+		ExpiresAt: claims.GetExpiresAt(),
+		CreatedAt: time.Now(),
+	}))
+	return pair, claims
+}
+
+// post 以 client_secret_basic 认证发送表单请求
+func (e *testEnv) post(path string, form url.Values, authenticate bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if authenticate {
+		req.SetBasicAuth("gateway", "gateway-secret")
+	}
+	resp := httptest.NewRecorder()
+	e.router.ServeHTTP(resp, req)
+	return resp
+}
+
+func decode(t *testing.T, resp *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	return body
+}
+
+func TestIntrospect(t *testing.T) {
+	env := newTestEnv(t)
+	pair, claims := env.issue(t)
+
+	resp := env.post(introspection.IntrospectionPath, url.Values{"token": {pair.AccessToken}}, true)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+	body := decode(t, resp)
+	assert.Equal(t, true, body["active"])
+	assert.Equal(t, "alice", body["username"])
+	assert.Equal(t, "user-1", body["sub"])
+	assert.Equal(t, "gateway", body["client_id"])
+	assert.Equal(t, "Bearer", body["token_type"])
+	assert.Equal(t, claims.TokenID, body["jti"])
+	assert.NotNil(t, body["exp"])
+	assert.NotNil(t, body["iat"])
+
+	// 只返回 RFC 7662 的成员，其他声明和个人信息不返回
+	for _, name := range []string{"user_id", "ip_address", "roles", "family_id"} {
+		assert.NotContains(t, body, name)
+	}
+
+	// 无效令牌和没有会话的令牌只返回 active
+	for _, token := range []string{"invalid-token", pair.RefreshToken} {
+		resp = env.post(introspection.IntrospectionPath, url.Values{"token": {token}}, true)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, map[string]interface{}{"active": false}, decode(t, resp))
+	}
+
+	// 客户端未认证
+	resp = env.post(introspection.IntrospectionPath, url.Values{"token": {pair.AccessToken}}, false)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.NotEmpty(t, resp.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "invalid_client", decode(t, resp)["error"])
+
+	// 缺少 token 参数
+	resp = env.post(introspection.IntrospectionPath, url.Values{}, true)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "invalid_request", decode(t, resp)["error"])
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	pair, claims := env.issue(t)
+
+	// client_secret_post 认证
+	form := url.Values{
+		"token":           {pair.AccessToken},
+		"token_type_hint": {"access_token"},
+		"client_id":       {"gateway"},
+		"client_secret":   {"gateway-secret"},
+	}
+	resp := env.post(introspection.RevocationPath, form, false)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = env.post(introspection.IntrospectionPath, url.Values{"token": {pair.AccessToken}}, true)
+	assert.Equal(t, false, decode(t, resp)["active"])
+	_, err := env.tm.ValidateToken(ctx, pair.AccessToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+	_, err = env.sessions.Get(ctx, claims.TokenID)
+	assert.Error(t, err)
+
+	// 吊销刷新令牌时整个令牌族失效
+	pair, _ = env.issue(t)
+	resp = env.post(introspection.RevocationPath, url.Values{"token": {pair.RefreshToken}}, true)
+	require.Equal(t, http.StatusOK, resp.Code)
+	_, err = env.tm.ValidateToken(ctx, pair.AccessToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+
+	// 无效令牌同样返回 200
+	resp = env.post(introspection.RevocationPath, url.Values{"token": {"invalid-token"}}, true)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = env.post(introspection.RevocationPath, url.Values{"token": {pair.AccessToken}}, false)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestIntrospect_ExtraClaims(t *testing.T) {
+	env := newTestEnv(t, introspection.WithExtraClaims("ip_address", "token_type"))
+	pair, _ := env.issue(t)
+
+	resp := env.post(introspection.IntrospectionPath, url.Values{"token": {pair.AccessToken}}, true)
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := decode(t, resp)
+	assert.Equal(t, "10.0.0.1", body["ip_address"])
+	// 额外的声明不覆盖 RFC 7662 的成员
+	assert.Equal(t, "Bearer", body["token_type"])
+}
+
+func TestRevoke_OtherClient(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+
+	// 签发给其他客户端的令牌不能吊销
+	pair, _ := env.issueTo(t, "mobile")
+	resp := env.post(introspection.RevocationPath, url.Values{"token": {pair.RefreshToken}}, true)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "unauthorized_client", decode(t, resp)["error"])
+	_, err := env.tm.ValidateToken(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+
+	// 没有签发对象的令牌默认拒绝
+	plain, err := env.tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "user-1"})
+	require.NoError(t, err)
+	resp = env.post(introspection.RevocationPath, url.Values{"token": {plain.AccessToken}}, true)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// 自定义授权检查
+	env = newTestEnv(t, introspection.WithRevocationAuthorizer(
+		func(ctx context.Context, clientID string, claims jwt.Claims) bool {
+			return clientID == "gateway" && claims.GetUserID() == "user-1"
+		},
+	))
+	plain, err = env.tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "user-1"})
+	require.NoError(t, err)
+	resp = env.post(introspection.RevocationPath, url.Values{"token": {plain.AccessToken}}, true)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	_, err = env.tm.ValidateToken(ctx, plain.AccessToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+}
+
+func TestRateLimit(t *testing.T) {
+	env := newTestEnv(t, introspection.WithRateLimiter(memory.NewSlidingWindowLimiter(), 2, time.Minute))
+
+	for i := 0; i < 2; i++ {
+		resp := env.post(introspection.IntrospectionPath, url.Values{"token": {"invalid-token"}}, true)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	}
+	resp := env.post(introspection.IntrospectionPath, url.Values{"token": {"invalid-token"}}, true)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	assert.Empty(t, resp.Body.String())
+
+	_, err := introspection.NewHandler(env.tm, nil)
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+	_, err = introspection.NewStaticClients(nil)
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+}
@@ -0,0 +1,289 @@
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"io"
+	"strings"
+
+	"gobase/pkg/errors"
+)
+
+// KeyAlgorithm 定义 JWE 密钥管理算法
+type KeyAlgorithm string
+
+const (
+	// Direct 直接使用共享的 256 位对称密钥作为内容加密密钥
+	Direct KeyAlgorithm = "dir"
+	// RSAOAEP RSAES-OAEP(SHA-1) 加密内容加密密钥
+	RSAOAEP KeyAlgorithm = "RSA-OAEP"
+	// RSAOAEP256 RSAES-OAEP(SHA-256) 加密内容加密密钥
+	RSAOAEP256 KeyAlgorithm = "RSA-OAEP-256"
+)
+
+// A256GCM 内容加密算法，AES-256-GCM
+const A256GCM = "A256GCM"
+
+// nestedContentType 嵌套令牌(先签名再加密)的 cty
+const nestedContentType = "JWT"
+
+// A256GCM 的密钥、IV 和认证标签长度
+const (
+	cekSize = 32
+	ivSize  = 12
+	tagSize = 16
+)
+
+// EncryptionKeyProvider JWE 加密和解密密钥提供者
+// crypto.KeyManager 的 EncryptionKeys 返回该接口的实现
+type EncryptionKeyProvider interface {
+	// KeyAlgorithm 获取密钥管理算法
+	KeyAlgorithm() KeyAlgorithm
+	// GetEncryptionKey 获取加密密钥，dir 为 32 字节的 []byte，RSA-OAEP 为 *rsa.PublicKey
+	GetEncryptionKey() (interface{}, error)
+	// GetDecryptionKey 获取解密密钥，dir 与加密密钥相同，RSA-OAEP 为 *rsa.PrivateKey
+	GetDecryptionKey() (interface{}, error)
+}
+
+// JWEHeader JWE 受保护头部
+type JWEHeader struct {
+	Algorithm   KeyAlgorithm `json:"alg"`
+	Encryption  string       `json:"enc"`
+	ContentType string       `json:"cty,omitempty"`
+	KeyID       string       `json:"kid,omitempty"`
+	// Compression 和 Critical 不支持，出现时拒绝解密
+	Compression string   `json:"zip,omitempty"`
+	Critical    []string `json:"crit,omitempty"`
+}
+
+// WithEncryption 设置 JWE 加密，GenerateToken 先签名再加密输出嵌套令牌
+// ValidateToken 解密后验证内层签名，未加密的令牌仍按原方式验证
+func WithEncryption(keys EncryptionKeyProvider) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.encryption = keys
+	}
+}
+
+// directKeyProvider 固定的 dir 对称密钥
+type directKeyProvider struct {
+	key []byte
+}
+
+// NewDirectKeyProvider 创建 dir 密钥提供者，key 必须为 32 字节
+func NewDirectKeyProvider(key []byte) (EncryptionKeyProvider, error) {
+	if len(key) != cekSize {
+		return nil, errors.NewKeyInvalidError("direct encryption key must be 32 bytes", nil)
+	}
+	return &directKeyProvider{key: key}, nil
+}
+
+func (p *directKeyProvider) KeyAlgorithm() KeyAlgorithm {
+	return Direct
+}
+
+func (p *directKeyProvider) GetEncryptionKey() (interface{}, error) {
+	return p.key, nil
+}
+
+func (p *directKeyProvider) GetDecryptionKey() (interface{}, error) {
+	return p.key, nil
+}
+
+// IsJWE 判断令牌是否为 JWE 紧凑序列化格式(五段)
+func IsJWE(token string) bool {
+	return strings.Count(token, ".") == 4
+}
+
+// EncryptJWE 加密数据，返回 JWE 紧凑序列化，内容加密使用 A256GCM
+// 加密已签名的令牌时 contentType 为 "JWT"
+func EncryptJWE(keys EncryptionKeyProvider, plaintext []byte, contentType string) (string, error) {
+	if keys == nil {
+		return "", errors.NewKeyInvalidError("encryption key provider is required", nil)
+	}
+	alg := keys.KeyAlgorithm()
+	key, err := keys.GetEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+
+	var cek, encryptedKey []byte
+	switch alg {
+	case Direct:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) != cekSize {
+			return "", errors.NewKeyInvalidError("direct encryption requires a 32 byte key", nil)
+		}
+		cek = secret
+	case RSAOAEP, RSAOAEP256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return "", errors.NewKeyInvalidError(string(alg)+" requires an RSA public key", nil)
+		}
+		cek = make([]byte, cekSize)
+		if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+			return "", errors.NewEncryptionError("failed to generate content encryption key", err)
+		}
+		encryptedKey, err = rsa.EncryptOAEP(oaepHash(alg), rand.Reader, publicKey, cek, nil)
+		if err != nil {
+			return "", errors.NewEncryptionError("failed to encrypt content encryption key", err)
+		}
+	default:
+		return "", errors.NewAlgorithmMismatchError("unsupported key management algorithm "+string(alg), nil)
+	}
+
+	header, err := json.Marshal(&JWEHeader{Algorithm: alg, Encryption: A256GCM, ContentType: contentType})
+	if err != nil {
+		return "", errors.NewSerializationError("failed to marshal JWE header", err)
+	}
+	protected := base64.RawURLEncoding.EncodeToString(header)
+
+	aead, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, ivSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", errors.NewEncryptionError("failed to generate initialization vector", err)
+	}
+	// 受保护头部的编码作为附加认证数据
+	sealed := aead.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-tagSize], sealed[len(sealed)-tagSize:]
+
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// DecryptJWE 解密 JWE 紧凑序列化，头部的 alg 必须与密钥提供者一致，enc 必须为 A256GCM
+func DecryptJWE(keys EncryptionKeyProvider, token string) ([]byte, *JWEHeader, error) {
+	if keys == nil {
+		return nil, nil, errors.NewKeyInvalidError("encryption key provider is required", nil)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, errors.NewTokenInvalidError("encrypted token must have five parts", nil)
+	}
+
+	segments := make([][]byte, len(parts))
+	for i, part := range parts {
+		segment, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, nil, errors.NewTokenInvalidError("encrypted token is malformed", err)
+		}
+		segments[i] = segment
+	}
+	encryptedKey, iv, ciphertext, tag := segments[1], segments[2], segments[3], segments[4]
+
+	var header JWEHeader
+	if err := json.Unmarshal(segments[0], &header); err != nil {
+		return nil, nil, errors.NewTokenInvalidError("encrypted token header is malformed", err)
+	}
+	alg := keys.KeyAlgorithm()
+	if header.Algorithm != alg {
+		return nil, nil, errors.NewAlgorithmMismatchError("unexpected key management algorithm "+string(header.Algorithm), nil)
+	}
+	if header.Encryption != A256GCM {
+		return nil, nil, errors.NewAlgorithmMismatchError("unexpected content encryption "+header.Encryption, nil)
+	}
+	if header.Compression != "" || len(header.Critical) > 0 {
+		return nil, nil, errors.NewTokenInvalidError("encrypted token uses unsupported header parameters", nil)
+	}
+	if len(iv) != ivSize || len(tag) != tagSize {
+		return nil, nil, errors.NewTokenInvalidError("encrypted token has invalid iv or tag length", nil)
+	}
+
+	key, err := keys.GetDecryptionKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cek []byte
+	switch alg {
+	case Direct:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) != cekSize {
+			return nil, nil, errors.NewKeyInvalidError("direct encryption requires a 32 byte key", nil)
+		}
+		if len(encryptedKey) != 0 {
+			return nil, nil, errors.NewTokenInvalidError("direct encryption must not carry an encrypted key", nil)
+		}
+		cek = secret
+	case RSAOAEP, RSAOAEP256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.NewKeyInvalidError(string(alg)+" requires an RSA private key", nil)
+		}
+		cek, err = rsa.DecryptOAEP(oaepHash(alg), nil, privateKey, encryptedKey, nil)
+		if err != nil || len(cek) != cekSize {
+			// 密钥解密失败时继续使用随机密钥，失败只体现为认证失败，不泄露填充错误
+			cek = make([]byte, cekSize)
+			if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+				return nil, nil, errors.NewDecryptionError("failed to generate content encryption key", err)
+			}
+		}
+	default:
+		return nil, nil, errors.NewAlgorithmMismatchError("unsupported key management algorithm "+string(alg), nil)
+	}
+
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, errors.NewDecryptionError("failed to decrypt token", err)
+	}
+	return plaintext, &header, nil
+}
+
+// encrypt 加密已签名的令牌
+func (tm *TokenManager) encrypt(tokenString string) (string, error) {
+	return EncryptJWE(tm.encryption, []byte(tokenString), nestedContentType)
+}
+
+// decrypt 解密嵌套令牌，返回内层已签名的令牌
+func (tm *TokenManager) decrypt(tokenString string) (string, error) {
+	if tm.encryption == nil {
+		return "", errors.NewTokenInvalidError("encrypted tokens are not enabled", nil)
+	}
+	plaintext, header, err := DecryptJWE(tm.encryption, tokenString)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(header.ContentType, nestedContentType) {
+		return "", errors.NewTokenInvalidError("encrypted token does not contain a signed JWT", nil)
+	}
+	return string(plaintext), nil
+}
+
+// oaepHash 返回 RSA-OAEP 使用的哈希函数
+func oaepHash(alg KeyAlgorithm) hash.Hash {
+	if alg == RSAOAEP256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+// newGCM 使用内容加密密钥创建 AES-256-GCM
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, errors.NewKeyInvalidError("invalid content encryption key", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.NewKeyInvalidError("invalid content encryption key", err)
+	}
+	return aead, nil
+}
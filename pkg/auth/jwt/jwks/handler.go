@@ -0,0 +1,103 @@
+package jwks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/logger/types"
+)
+
+// WellKnownPath JWKS 的标准路径
+const WellKnownPath = "/.well-known/jwks.json"
+
+// KeySource 可以公开的验证密钥来源，crypto.KeyManager 和 crypto.Keyring 都实现了该接口
+type KeySource interface {
+	Keys() []crypto.Key
+}
+
+// handlerOptions 处理器选项
+type handlerOptions struct {
+	maxAge time.Duration
+	logger types.Logger
+}
+
+// HandlerOption 处理器选项
+type HandlerOption func(*handlerOptions)
+
+// WithMaxAge 设置 Cache-Control 的 max-age，默认 10 分钟
+// 应明显小于密钥环的宽限期，保证验证方在旧密钥移除前拿到新密钥
+func WithMaxAge(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.maxAge = d
+	}
+}
+
+// WithHandlerLogger 设置日志记录器
+func WithHandlerLogger(logger types.Logger) HandlerOption {
+	return func(o *handlerOptions) {
+		o.logger = logger
+	}
+}
+
+// Register 在 WellKnownPath 注册 JWKS 处理器
+func Register(routes gin.IRoutes, source KeySource, opts ...HandlerOption) {
+	routes.GET(WellKnownPath, Handler(source, opts...))
+}
+
+// Handler 以 JSON Web Key Set 格式返回 source 中的公钥
+// 响应带有 Cache-Control 和 ETag，请求携带相同的 If-None-Match 时返回 304
+func Handler(source KeySource, opts ...HandlerOption) gin.HandlerFunc {
+	o := &handlerOptions{
+		maxAge: 10 * time.Minute,
+		logger: &types.NoopLogger{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	cacheControl := "public, max-age=" + strconv.Itoa(int(o.maxAge/time.Second))
+
+	return func(c *gin.Context) {
+		body, err := json.Marshal(buildSet(c, source, o.logger))
+		if err != nil {
+			o.logger.Error(c, "failed to marshal jwks", types.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		c.Header("Cache-Control", cacheControl)
+		c.Header("ETag", etag)
+
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// buildSet 转换 source 中的公钥，无法转换的密钥记录日志后跳过
+func buildSet(c *gin.Context, source KeySource, logger types.Logger) *Set {
+	keys := source.Keys()
+	set := &Set{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := NewJWK(key.ID, key.Method, key.PublicKey)
+		if err != nil {
+			logger.Error(c, "failed to convert public key to jwk",
+				types.Field{Key: "kid", Value: key.ID},
+				types.Error(err),
+			)
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,209 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+)
+
+// 密钥类型
+const (
+	KeyTypeRSA = "RSA"
+	KeyTypeEC  = "EC"
+	KeyTypeOKP = "OKP"
+)
+
+// JWK 单个 JSON Web Key (RFC 7517)，只包含公钥参数
+type JWK struct {
+	// KeyType 密钥类型: RSA、EC、OKP
+	KeyType string `json:"kty"`
+	// KeyID 密钥ID，对应令牌头的 kid
+	KeyID string `json:"kid,omitempty"`
+	// Use 用途，签名密钥为 sig
+	Use string `json:"use,omitempty"`
+	// Algorithm 签名算法，如 RS256
+	Algorithm string `json:"alg,omitempty"`
+	// N RSA 模数
+	N string `json:"n,omitempty"`
+	// E RSA 公共指数
+	E string `json:"e,omitempty"`
+	// Curve 曲线名称: P-256、P-384、P-521、Ed25519
+	Curve string `json:"crv,omitempty"`
+	// X EC/OKP 公钥 x 坐标
+	X string `json:"x,omitempty"`
+	// Y EC 公钥 y 坐标
+	Y string `json:"y,omitempty"`
+}
+
+// Set JSON Web Key Set
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Lookup 按 kid 查找密钥
+func (s *Set) Lookup(kid string) (JWK, bool) {
+	for _, key := range s.Keys {
+		if key.KeyID == kid {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+// NewJWK 将公钥转换为 JWK，kid 为空时使用 RFC 7638 指纹
+func NewJWK(kid string, method jwt.SigningMethod, publicKey crypto.PublicKey) (JWK, error) {
+	key := JWK{
+		KeyID:     kid,
+		Use:       "sig",
+		Algorithm: string(method),
+	}
+
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		key.KeyType = KeyTypeRSA
+		key.N = encode(pub.N.Bytes())
+		key.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		params := pub.Curve.Params()
+		size := (params.BitSize + 7) / 8
+		key.KeyType = KeyTypeEC
+		key.Curve = params.Name
+		key.X = encode(pub.X.FillBytes(make([]byte, size)))
+		key.Y = encode(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		key.KeyType = KeyTypeOKP
+		key.Curve = "Ed25519"
+		key.X = encode(pub)
+	default:
+		return JWK{}, errors.NewKeyInvalidError("unsupported public key type", nil)
+	}
+
+	if key.KeyID == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return JWK{}, err
+		}
+		key.KeyID = thumbprint
+	}
+	return key, nil
+}
+
+// PublicKey 将 JWK 转换为公钥
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case KeyTypeRSA:
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.NewKeyInvalidError("invalid RSA key parameters", nil)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case KeyTypeEC:
+		curve := curveByName(k.Curve)
+		if curve == nil {
+			return nil, errors.NewKeyInvalidError("unsupported curve: "+k.Curve, nil)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.NewKeyInvalidError("EC point is not on curve", nil)
+		}
+		return pub, nil
+	case KeyTypeOKP:
+		if k.Curve != "Ed25519" {
+			return nil, errors.NewKeyInvalidError("unsupported curve: "+k.Curve, nil)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.NewKeyInvalidError("invalid Ed25519 key size", nil)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.NewKeyInvalidError("unsupported key type: "+k.KeyType, nil)
+}
+
+// Thumbprint 计算 RFC 7638 指纹，只使用必需字段并按字典序排列
+func (k JWK) Thumbprint() (string, error) {
+	var members interface{}
+	switch k.KeyType {
+	case KeyTypeRSA:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.KeyType, k.N}
+	case KeyTypeEC:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Curve, k.KeyType, k.X, k.Y}
+	case KeyTypeOKP:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Curve, k.KeyType, k.X}
+	default:
+		return "", errors.NewKeyInvalidError("unsupported key type: "+k.KeyType, nil)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", errors.NewSerializationError("failed to marshal jwk thumbprint", err)
+	}
+	sum := sha256.Sum256(data)
+	return encode(sum[:]), nil
+}
+
+// curveByName 按 JWK 曲线名称返回曲线
+func curveByName(name string) elliptic.Curve {
+	switch name {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	}
+	return nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.NewKeyInvalidError("invalid base64url value in jwk", err)
+	}
+	return data, nil
+}
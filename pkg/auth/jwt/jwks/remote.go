@@ -0,0 +1,347 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// 确保 RemoteKeyProvider 实现 jwt.ContextKeyIDProvider
+var _ jwt.ContextKeyIDProvider = (*RemoteKeyProvider)(nil)
+
+// maxResponseSize JWKS 响应的最大字节数
+const maxResponseSize = 1 << 20
+
+// RemoteOption 远程密钥提供者选项
+type RemoteOption func(*RemoteKeyProvider)
+
+// WithHTTPClient 设置 HTTP 客户端，默认超时 10 秒
+func WithHTTPClient(client *http.Client) RemoteOption {
+	return func(p *RemoteKeyProvider) {
+		p.client = client
+	}
+}
+
+// WithRefreshInterval 设置刷新间隔，默认 1 小时
+// 响应带有 Cache-Control max-age 时以 max-age 为准
+func WithRefreshInterval(d time.Duration) RemoteOption {
+	return func(p *RemoteKeyProvider) {
+		p.refreshInterval = d
+	}
+}
+
+// WithMinRefreshInterval 设置两次拉取的最小间隔，默认 1 分钟
+// 遇到未知 kid 时会提前刷新，该间隔防止伪造 kid 的令牌频繁触发拉取
+func WithMinRefreshInterval(d time.Duration) RemoteOption {
+	return func(p *RemoteKeyProvider) {
+		p.minRefreshInterval = d
+	}
+}
+
+// WithRemoteLogger 设置日志记录器
+func WithRemoteLogger(logger types.Logger) RemoteOption {
+	return func(p *RemoteKeyProvider) {
+		p.logger = logger
+	}
+}
+
+// WithRemoteClock 设置时钟，主要用于测试
+func WithRemoteClock(now func() time.Time) RemoteOption {
+	return func(p *RemoteKeyProvider) {
+		p.now = now
+	}
+}
+
+// fetch 一次进行中的拉取，完成后关闭 done
+type fetch struct {
+	done chan struct{}
+	err  error
+}
+
+// RemoteKeyProvider 从远程 JWKS 获取验证密钥的密钥提供者，只能验证不能签名
+// 密钥按 kid 缓存，缓存过期时直接返回已缓存的密钥并在后台刷新，只有遇到未知 kid 时才等待拉取
+// 同一时间最多一个拉取请求，拉取失败时继续使用已缓存的密钥
+type RemoteKeyProvider struct {
+	url                string
+	method             jwt.SigningMethod
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	logger             types.Logger
+	now                func() time.Time
+
+	// refreshMu 保护 inflight 和 lastAttempt，拉取期间不持有
+	refreshMu   sync.Mutex
+	inflight    *fetch
+	lastAttempt time.Time
+
+	mutex     sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	expiresAt time.Time
+}
+
+// NewRemoteKeyProvider 创建远程 JWKS 密钥提供者，只使用 alg 为空或与 method 一致的签名密钥
+// 创建时不拉取，可以调用 Refresh 预热
+func NewRemoteKeyProvider(url string, method jwt.SigningMethod, opts ...RemoteOption) (*RemoteKeyProvider, error) {
+	if url == "" {
+		return nil, errors.NewConfigInvalidError("jwks url is required", nil)
+	}
+	switch method {
+	case jwt.RS256, jwt.RS384, jwt.RS512, jwt.PS256, jwt.PS384, jwt.PS512,
+		jwt.ES256, jwt.ES384, jwt.ES512, jwt.EdDSA:
+	default:
+		return nil, errors.NewAlgorithmMismatchError("remote jwks requires an asymmetric signing method", nil)
+	}
+
+	p := &RemoteKeyProvider{
+		url:                url,
+		method:             method,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    time.Hour,
+		minRefreshInterval: time.Minute,
+		logger:             &types.NoopLogger{},
+		now:                time.Now,
+		keys:               make(map[string]crypto.PublicKey),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// Method 获取签名方法
+func (p *RemoteKeyProvider) Method() jwt.SigningMethod {
+	return p.method
+}
+
+// GetSigningKey 远程密钥不能用于签名
+func (p *RemoteKeyProvider) GetSigningKey() (interface{}, error) {
+	return nil, errors.NewKeyInvalidError("remote jwks provider cannot sign tokens", nil)
+}
+
+// GetSigningKeyWithID 远程密钥不能用于签名
+func (p *RemoteKeyProvider) GetSigningKeyWithID() (string, interface{}, error) {
+	return "", nil, errors.NewKeyInvalidError("remote jwks provider cannot sign tokens", nil)
+}
+
+// GetVerificationKey 用于没有 kid 的令牌，只有 JWKS 中恰好一把密钥时可用
+// 尚未拉取过时等待首次拉取
+func (p *RemoteKeyProvider) GetVerificationKey() (interface{}, error) {
+	p.mutex.RLock()
+	empty := len(p.keys) == 0
+	p.mutex.RUnlock()
+	if empty {
+		p.wait(context.Background(), p.startRefresh(false))
+	} else {
+		p.refreshIfStale()
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if len(p.keys) != 1 {
+		return nil, errors.NewKeyInvalidError("token has no kid and jwks does not contain exactly one key", nil)
+	}
+	for _, key := range p.keys {
+		return key, nil
+	}
+	return nil, nil
+}
+
+// GetVerificationKeyByID 按 kid 获取验证密钥，等待不受请求 ctx 限制
+func (p *RemoteKeyProvider) GetVerificationKeyByID(kid string) (interface{}, error) {
+	return p.GetVerificationKeyByIDContext(context.Background(), kid)
+}
+
+// GetVerificationKeyByIDContext 按 kid 获取验证密钥
+// 已缓存的 kid 立即返回，缓存过期时在后台刷新；未知的 kid 等待一次受限频的刷新，等待受 ctx 限制
+func (p *RemoteKeyProvider) GetVerificationKeyByIDContext(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := p.lookup(kid); ok {
+		p.refreshIfStale()
+		return key, nil
+	}
+
+	if err := p.wait(ctx, p.startRefresh(false)); err != nil {
+		return nil, err
+	}
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errors.NewKeyInvalidError("unknown key id: "+kid, nil)
+}
+
+// Refresh 立即拉取远程 JWKS，已有进行中的拉取时等待其结果，等待受 ctx 限制
+func (p *RemoteKeyProvider) Refresh(ctx context.Context) error {
+	f := p.startRefresh(true)
+	if err := p.wait(ctx, f); err != nil {
+		return err
+	}
+	return f.err
+}
+
+// refreshIfStale 缓存过期时在后台刷新，不等待结果
+func (p *RemoteKeyProvider) refreshIfStale() {
+	if p.stale() {
+		p.startRefresh(false)
+	}
+}
+
+// startRefresh 在后台开始一次拉取并返回它，已有进行中的拉取时直接返回该拉取
+// force 为 false 时受最小间隔限制，距上次拉取不足最小间隔时返回 nil
+func (p *RemoteKeyProvider) startRefresh(force bool) *fetch {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	if p.inflight != nil {
+		return p.inflight
+	}
+	if !force && !p.lastAttempt.IsZero() && p.now().Sub(p.lastAttempt) < p.minRefreshInterval {
+		return nil
+	}
+
+	f := &fetch{done: make(chan struct{})}
+	p.inflight = f
+	p.lastAttempt = p.now()
+
+	// 拉取不绑定任何请求的 ctx，由 HTTP 客户端的超时限制
+	go func() {
+		ctx := context.Background()
+		f.err = p.refresh(ctx)
+		if f.err != nil {
+			p.logger.Warn(ctx, "failed to refresh remote jwks, using cached keys",
+				types.Field{Key: "url", Value: p.url},
+				types.Error(f.err),
+			)
+		}
+
+		p.refreshMu.Lock()
+		p.inflight = nil
+		p.refreshMu.Unlock()
+		close(f.done)
+	}()
+	return f
+}
+
+// wait 等待拉取完成，f 为 nil 时立即返回，ctx 结束时返回其错误
+func (p *RemoteKeyProvider) wait(ctx context.Context, f *fetch) error {
+	if f == nil {
+		return nil
+	}
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refresh 拉取并替换缓存的密钥，只在 startRefresh 启动的协程中调用
+func (p *RemoteKeyProvider) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return errors.NewConfigInvalidError("invalid jwks url", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.NewThirdPartyError("failed to fetch jwks", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.NewThirdPartyError("unexpected jwks response status "+strconv.Itoa(resp.StatusCode), nil)
+	}
+
+	var set Set
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&set); err != nil {
+		return errors.NewSerializationError("failed to decode jwks", err)
+	}
+
+	keys := p.parseSet(ctx, &set)
+	if len(keys) == 0 {
+		return errors.NewKeyInvalidError("jwks contains no usable "+string(p.method)+" keys", nil)
+	}
+
+	ttl := p.refreshInterval
+	if maxAge, ok := parseMaxAge(resp.Header.Get("Cache-Control")); ok {
+		ttl = maxAge
+	}
+	if ttl < p.minRefreshInterval {
+		ttl = p.minRefreshInterval
+	}
+
+	p.mutex.Lock()
+	p.keys = keys
+	p.expiresAt = p.now().Add(ttl)
+	p.mutex.Unlock()
+
+	p.logger.Debug(ctx, "remote jwks refreshed",
+		types.Field{Key: "url", Value: p.url},
+		types.Field{Key: "keys", Value: len(keys)},
+	)
+	return nil
+}
+
+// parseSet 筛选出可用于 p.method 的签名密钥，没有 kid 的密钥使用指纹作为 kid
+func (p *RemoteKeyProvider) parseSet(ctx context.Context, set *Set) map[string]crypto.PublicKey {
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if (jwk.Use != "" && jwk.Use != "sig") || (jwk.Algorithm != "" && jwk.Algorithm != string(p.method)) {
+			continue
+		}
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			p.logger.Warn(ctx, "skipping invalid jwk",
+				types.Field{Key: "kid", Value: jwk.KeyID},
+				types.Error(err),
+			)
+			continue
+		}
+		kid := jwk.KeyID
+		if kid == "" {
+			if kid, err = jwk.Thumbprint(); err != nil {
+				continue
+			}
+		}
+		keys[kid] = publicKey
+	}
+	return keys
+}
+
+func (p *RemoteKeyProvider) lookup(kid string) (crypto.PublicKey, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *RemoteKeyProvider) stale() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return !p.now().Before(p.expiresAt)
+}
+
+// parseMaxAge 解析 Cache-Control 中的 max-age
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,245 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/crypto"
+	"gobase/pkg/auth/jwt/jwks"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+func newTestClaims() *jwt.StandardClaims {
+	return jwt.NewStandardClaims(
+		jwt.WithUserID("user-1"),
+		jwt.WithTokenType(jwt.AccessToken),
+		jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+	)
+}
+
+// newJWKSServer 启动提供 JWKS 的测试服务器，返回服务器和请求计数
+func newJWKSServer(t *testing.T, source jwks.KeySource, opts ...jwks.HandlerOption) (*httptest.Server, *int32) {
+	gin.SetMode(gin.TestMode)
+	var requests int32
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		atomic.AddInt32(&requests, 1)
+	})
+	jwks.Register(router, source, opts...)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for method, pub := range map[jwt.SigningMethod]interface{}{
+		jwt.RS256: &rsaKey.PublicKey,
+		jwt.ES384: &ecKey.PublicKey,
+		jwt.EdDSA: edPub,
+	} {
+		jwk, err := jwks.NewJWK("", method, pub)
+		require.NoError(t, err)
+		assert.NotEmpty(t, jwk.KeyID)
+		assert.Equal(t, string(method), jwk.Algorithm)
+
+		parsed, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, pub, parsed)
+	}
+}
+
+func TestJWK_Thumbprint(t *testing.T) {
+	// RFC 7638 第 3.1 节示例
+	jwk := jwks.JWK{
+		KeyType: jwks.KeyTypeRSA,
+		N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:       "AQAB",
+	}
+	thumbprint, err := jwk.Thumbprint()
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestHandler(t *testing.T) {
+	keyring, err := crypto.NewKeyring(jwt.ES256)
+	require.NoError(t, err)
+	_, err = keyring.Rotate(context.Background())
+	require.NoError(t, err)
+
+	server, _ := newJWKSServer(t, keyring, jwks.WithMaxAge(5*time.Minute))
+
+	resp, err := http.Get(server.URL + jwks.WellKnownPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=300", resp.Header.Get("Cache-Control"))
+
+	var set jwks.Set
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+	require.Len(t, set.Keys, 2)
+	_, ok := set.Lookup(keyring.ActiveKeyID())
+	assert.True(t, ok)
+	for _, key := range set.Keys {
+		assert.Equal(t, jwks.KeyTypeEC, key.KeyType)
+		assert.Equal(t, "sig", key.Use)
+	}
+
+	// 内容未变化时返回 304
+	req, err := http.NewRequest(http.MethodGet, server.URL+jwks.WellKnownPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	notModified, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	notModified.Body.Close()
+	assert.Equal(t, http.StatusNotModified, notModified.StatusCode)
+}
+
+func TestHandler_HMACNotPublished(t *testing.T) {
+	km, err := crypto.NewKeyManager(jwt.HS256, nil)
+	require.NoError(t, err)
+	require.NoError(t, km.InitializeKeys(context.Background(), &jwt.KeyConfig{SecretKey: "secret"}))
+
+	server, _ := newJWKSServer(t, km)
+	resp, err := http.Get(server.URL + jwks.WellKnownPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var set jwks.Set
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+	assert.Empty(t, set.Keys)
+}
+
+func TestRemoteKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	keyring, err := crypto.NewKeyring(jwt.RS256)
+	require.NoError(t, err)
+	issuer, err := crypto.NewTokenManager(keyring, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	server, requests := newJWKSServer(t, keyring)
+	remote, err := jwks.NewRemoteKeyProvider(server.URL+jwks.WellKnownPath, jwt.RS256,
+		jwks.WithMinRefreshInterval(0),
+	)
+	require.NoError(t, err)
+	verifier, err := jwt.NewTokenManagerWithKeys(remote, jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	tokenString, err := issuer.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+
+	// 首次验证时拉取，之后使用缓存
+	for i := 0; i < 3; i++ {
+		_, err = verifier.ValidateToken(ctx, tokenString)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// 轮换后的新 kid 触发刷新
+	_, err = keyring.Rotate(ctx)
+	require.NoError(t, err)
+	tokenString, err = issuer.GenerateToken(ctx, newTestClaims())
+	require.NoError(t, err)
+	_, err = verifier.ValidateToken(ctx, tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+	// 远程密钥只能验证
+	_, err = verifier.GenerateToken(ctx, newTestClaims())
+	assert.Error(t, err)
+}
+
+func TestRemoteKeyProvider_UnknownKIDThrottled(t *testing.T) {
+	ctx := context.Background()
+	keyring, err := crypto.NewKeyring(jwt.ES256)
+	require.NoError(t, err)
+	server, requests := newJWKSServer(t, keyring)
+
+	remote, err := jwks.NewRemoteKeyProvider(server.URL+jwks.WellKnownPath, jwt.ES256)
+	require.NoError(t, err)
+	require.NoError(t, remote.Refresh(ctx))
+
+	// 未知 kid 在最小刷新间隔内不会重复拉取
+	for i := 0; i < 5; i++ {
+		_, err = remote.GetVerificationKeyByID("forged")
+		assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// 签名方法不同的密钥不可用
+	other, err := jwks.NewRemoteKeyProvider(server.URL+jwks.WellKnownPath, jwt.RS256)
+	require.NoError(t, err)
+	err = other.Refresh(ctx)
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+}
+
+func TestRemoteKeyProvider_SlowRefresh(t *testing.T) {
+	ctx := context.Background()
+	keyring, err := crypto.NewKeyring(jwt.ES256)
+	require.NoError(t, err)
+
+	// blocked 为 true 时请求一直阻塞到测试结束，模拟 IdP 无响应
+	var requests int32
+	var blocked atomic.Bool
+	release := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		atomic.AddInt32(&requests, 1)
+		if blocked.Load() {
+			<-release
+		}
+	})
+	jwks.Register(router, keyring)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	var offset atomic.Int64
+	remote, err := jwks.NewRemoteKeyProvider(server.URL+jwks.WellKnownPath, jwt.ES256,
+		jwks.WithRemoteClock(func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }),
+	)
+	require.NoError(t, err)
+	require.NoError(t, remote.Refresh(ctx))
+
+	// 缓存过期后已知的 kid 立即返回，刷新在后台进行且只有一个
+	blocked.Store(true)
+	offset.Store(int64(time.Hour))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err = remote.GetVerificationKeyByID(keyring.ActiveKeyID())
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 2 }, time.Second, 10*time.Millisecond)
+
+	// 未知的 kid 等待进行中的拉取，等待受 ctx 限制
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = remote.GetVerificationKeyByIDContext(waitCtx, "rotated")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
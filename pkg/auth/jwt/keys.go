@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"gobase/pkg/errors"
+)
+
+// KeyProvider 签名和验证密钥提供者
+// crypto.KeyProvider 包含该接口，crypto.KeyManager 可以直接用于创建 TokenManager
+type KeyProvider interface {
+	// Method 获取签名方法
+	Method() SigningMethod
+	// GetSigningKey 获取签名密钥，HMAC 为 []byte，RSA/RSA-PSS 为 *rsa.PrivateKey，
+	// ECDSA 为 *ecdsa.PrivateKey，EdDSA 为 ed25519.PrivateKey
+	GetSigningKey() (interface{}, error)
+	// GetVerificationKey 获取验证密钥，类型为签名密钥对应的公钥，HMAC 与签名密钥相同
+	GetVerificationKey() (interface{}, error)
+}
+
+// KeyIDProvider 持有多个密钥、按 kid 区分的密钥提供者，如 crypto.Keyring
+// TokenManager 签发令牌时在头部写入 kid，验证时按 kid 选择密钥
+type KeyIDProvider interface {
+	KeyProvider
+	// GetSigningKeyWithID 获取当前签名密钥及其 kid，两者必须来自同一把密钥
+	GetSigningKeyWithID() (string, interface{}, error)
+	// GetVerificationKeyByID 按 kid 获取验证密钥，未知或已超过宽限期的 kid 返回错误
+	GetVerificationKeyByID(kid string) (interface{}, error)
+}
+
+// ContextKeyIDProvider 按 kid 获取密钥时可能发起网络请求的密钥提供者，如 jwks.RemoteKeyProvider
+// TokenManager 验证令牌时传入请求的 ctx，等待受其限制
+type ContextKeyIDProvider interface {
+	KeyIDProvider
+	// GetVerificationKeyByIDContext 按 kid 获取验证密钥
+	GetVerificationKeyByIDContext(ctx context.Context, kid string) (interface{}, error)
+}
+
+// secretKeyProvider 固定的 HMAC 密钥
+type secretKeyProvider struct {
+	method SigningMethod
+	secret []byte
+}
+
+// NewSecretKeyProvider 创建使用固定 HMAC 密钥的密钥提供者
+func NewSecretKeyProvider(method SigningMethod, secret []byte) (KeyProvider, error) {
+	if !isHMAC(method) {
+		return nil, errors.NewAlgorithmMismatchError("secret key provider requires an HMAC signing method", nil)
+	}
+	if len(secret) == 0 {
+		return nil, errors.NewKeyInvalidError("secret key is required", nil)
+	}
+	return &secretKeyProvider{method: method, secret: secret}, nil
+}
+
+func (p *secretKeyProvider) Method() SigningMethod {
+	return p.method
+}
+
+func (p *secretKeyProvider) GetSigningKey() (interface{}, error) {
+	return p.secret, nil
+}
+
+func (p *secretKeyProvider) GetVerificationKey() (interface{}, error) {
+	return p.secret, nil
+}
+
+// 签名方法对应的密钥类型
+const (
+	keyFamilyHMAC  = "hmac"
+	keyFamilyRSA   = "rsa"
+	keyFamilyECDSA = "ecdsa"
+	keyFamilyEdDSA = "eddsa"
+)
+
+// keyFamily 返回签名方法使用的密钥类型，不支持的方法返回空字符串
+func keyFamily(method SigningMethod) string {
+	switch method {
+	case HS256, HS384, HS512:
+		return keyFamilyHMAC
+	case RS256, RS384, RS512, PS256, PS384, PS512:
+		return keyFamilyRSA
+	case ES256, ES384, ES512:
+		return keyFamilyECDSA
+	case EdDSA:
+		return keyFamilyEdDSA
+	}
+	return ""
+}
+
+// isHMAC 判断是否为 HMAC 签名方法
+func isHMAC(method SigningMethod) bool {
+	return keyFamily(method) == keyFamilyHMAC
+}
+
+// curveBits 返回 ECDSA 签名方法要求的曲线位数
+func curveBits(method SigningMethod) int {
+	switch method {
+	case ES256:
+		return 256
+	case ES384:
+		return 384
+	case ES512:
+		return 521
+	}
+	return 0
+}
+
+// signingMethod 返回签名方法对应的 jwt 库实现
+func signingMethod(method SigningMethod) (jwt.SigningMethod, error) {
+	if keyFamily(method) == "" {
+		return nil, errors.NewAlgorithmMismatchError("unsupported signing method: "+string(method), nil)
+	}
+	return jwt.GetSigningMethod(string(method)), nil
+}
+
+// checkKey 检查密钥类型与签名方法是否匹配，避免用 RSA 公钥作为 HMAC 密钥等算法混淆
+func checkKey(method SigningMethod, key interface{}, signing bool) error {
+	var ok bool
+	switch keyFamily(method) {
+	case keyFamilyHMAC:
+		var secret []byte
+		secret, ok = key.([]byte)
+		ok = ok && len(secret) > 0
+	case keyFamilyRSA:
+		if signing {
+			_, ok = key.(*rsa.PrivateKey)
+		} else {
+			_, ok = key.(*rsa.PublicKey)
+		}
+	case keyFamilyECDSA:
+		var pub *ecdsa.PublicKey
+		if signing {
+			if priv, isECDSA := key.(*ecdsa.PrivateKey); isECDSA {
+				pub = &priv.PublicKey
+			}
+		} else {
+			pub, _ = key.(*ecdsa.PublicKey)
+		}
+		// 曲线必须与签名方法一致，如 ES256 只能使用 P-256
+		ok = pub != nil && pub.Curve.Params().BitSize == curveBits(method)
+	case keyFamilyEdDSA:
+		if signing {
+			var priv ed25519.PrivateKey
+			priv, ok = key.(ed25519.PrivateKey)
+			ok = ok && len(priv) == ed25519.PrivateKeySize
+		} else {
+			var pub ed25519.PublicKey
+			pub, ok = key.(ed25519.PublicKey)
+			ok = ok && len(pub) == ed25519.PublicKeySize
+		}
+	}
+	if !ok {
+		return errors.NewKeyInvalidError("key type does not match signing method "+string(method), nil)
+	}
+	return nil
+}
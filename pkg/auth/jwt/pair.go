@@ -0,0 +1,373 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"gobase/pkg/auth/jwt/events"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/monitor/prometheus/metrics"
+)
+
+// 默认过期时间，与 config.DefaultConfig 一致
+const (
+	DefaultAccessTokenExpiration  = 2 * time.Hour
+	DefaultRefreshTokenExpiration = 24 * time.Hour
+)
+
+// familyLockStripes 令牌族锁的分段数
+const familyLockStripes = 64
+
+// familyKeyPrefix 令牌族在存储和黑名单中的键前缀
+const familyKeyPrefix = "family:"
+
+// TokenStore 令牌族存储，store.Store 实现了该接口
+type TokenStore interface {
+	Set(ctx context.Context, key string, value *TokenInfo, expiration time.Duration) error
+	Get(ctx context.Context, key string) (*TokenInfo, error)
+	Delete(ctx context.Context, key string) error
+	// CompareAndSwap 仅当 key 的记录存在、未吊销且 Raw 等于 old 时写入 value，返回是否写入
+	CompareAndSwap(ctx context.Context, key string, old string, value *TokenInfo, expiration time.Duration) (bool, error)
+}
+
+// Blacklist 令牌黑名单，blacklist.TokenBlacklist 实现了该接口
+type Blacklist interface {
+	Add(ctx context.Context, token string, expiration time.Duration) error
+	IsBlacklisted(ctx context.Context, token string) (bool, error)
+}
+
+// EventPublisher 事件发布者，events.Publisher 实现了该接口
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, event *events.Event) error
+}
+
+// WithTokenExpirations 设置访问令牌和刷新令牌的有效期，为0时使用默认值
+// 通常使用 config.Config 的 TokenManagerOptions
+func WithTokenExpirations(access, refresh time.Duration) TokenManagerOption {
+	return func(tm *TokenManager) {
+		if access > 0 {
+			tm.accessExpiration = access
+		}
+		if refresh > 0 {
+			tm.refreshExpiration = refresh
+		}
+	}
+}
+
+// WithTokenStore 设置令牌族存储，Refresh 需要
+func WithTokenStore(store TokenStore) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.store = store
+	}
+}
+
+// WithBlacklist 设置黑名单，ValidateToken 会拒绝已吊销的令牌及令牌族
+func WithBlacklist(blacklist Blacklist) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.blacklist = blacklist
+	}
+}
+
+// WithEventPublisher 设置事件发布者，吊销令牌族时发布 TokenRevoked 事件
+func WithEventPublisher(publisher EventPublisher) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.publisher = publisher
+	}
+}
+
+// standardHolder 嵌入 StandardClaims 的claims
+type standardHolder interface {
+	Claims
+	standardClaims() *StandardClaims
+}
+
+// IssuePair 签发访问令牌和刷新令牌，两者属于同一个新的令牌族
+// claims 提供用户信息，必须是 *StandardClaims 或嵌入 StandardClaims 的结构体指针，自定义字段随令牌对签发
+// 令牌类型、ID 和有效期由 TokenManager 设置，claims 本身不会被修改；配置了令牌族存储时记录当前有效的刷新令牌
+func (tm *TokenManager) IssuePair(ctx context.Context, claims Claims) (*TokenPair, error) {
+	base, err := pairBase(claims)
+	if err != nil {
+		return nil, err
+	}
+	return tm.issuePair(ctx, base, uuid.New().String())
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+// 已经使用过的刷新令牌再次出现时视为被盗用，吊销整个令牌族并返回 TokenRevoked 错误
+// 令牌族记录通过比较并交换更新，多个实例同时使用同一个刷新令牌时只有一个能完成轮换，其余按重复使用处理
+func (tm *TokenManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if tm.store == nil {
+		return nil, errors.NewConfigInvalidError("token store is required to refresh tokens", nil)
+	}
+
+	// 使用 WithClaimsFactory 配置的类型解析，新令牌对保留刷新令牌中的自定义字段
+	token, err := tm.validateInto(ctx, refreshToken, tm.newClaims())
+	if err != nil {
+		return nil, err
+	}
+	base, err := pairBase(token.Claims.(Claims))
+	if err != nil {
+		return nil, err
+	}
+	claims := base.standardClaims()
+	if claims.TokenType != RefreshToken {
+		return nil, errors.NewTokenTypeMismatchError("refresh token is required", nil)
+	}
+	if claims.FamilyID == "" || claims.TokenID == "" {
+		return nil, errors.NewTokenInvalidError("refresh token has no family", nil)
+	}
+
+	// 同一实例内串行处理同一令牌族的刷新，跨实例的并发由 CompareAndSwap 保证
+	mu := tm.familyLock(claims.FamilyID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	family, err := tm.store.Get(ctx, familyKeyPrefix+claims.FamilyID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, errors.NewTokenRevokedError("refresh token family has been revoked or expired", err)
+		}
+		return nil, errors.NewTokenInvalidError("failed to load token family", err)
+	}
+	if family.IsRevoked {
+		return nil, errors.NewTokenRevokedError("refresh token family has been revoked", nil)
+	}
+	if family.Raw != claims.TokenID {
+		// 已轮换的刷新令牌被再次使用
+		return nil, tm.reuseDetected(ctx, claims, family)
+	}
+
+	pair, next, err := tm.signPair(ctx, base, claims.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	swapped, err := tm.store.CompareAndSwap(ctx, familyKeyPrefix+claims.FamilyID, claims.TokenID, next, tm.refreshExpiration)
+	if err != nil {
+		return nil, errors.NewTokenGenerationError("failed to save token family", err)
+	}
+	if !swapped {
+		// 其他实例已经用同一个刷新令牌完成了轮换
+		return nil, tm.reuseDetected(ctx, claims, family)
+	}
+	return pair, nil
+}
+
+// RevokeFamily 吊销令牌族，族内所有访问令牌和刷新令牌都不再通过验证
+func (tm *TokenManager) RevokeFamily(ctx context.Context, familyID string) error {
+	if tm.store == nil {
+		return errors.NewConfigInvalidError("token store is required to revoke token families", nil)
+	}
+
+	mu := tm.familyLock(familyID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	family, err := tm.store.Get(ctx, familyKeyPrefix+familyID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return errors.NewTokenInvalidError("failed to load token family", err)
+	}
+	if family.IsRevoked {
+		return nil
+	}
+
+	claims := &StandardClaims{FamilyID: familyID}
+	if holder, ok := family.Claims.(standardHolder); ok {
+		claims = holder.standardClaims()
+	}
+	claims.FamilyID = familyID
+	return tm.revokeFamily(ctx, claims, family, "revoked")
+}
+
+// issuePair 按 base 中的用户信息签发属于 familyID 的令牌对，配置了令牌族存储时保存令牌族记录
+func (tm *TokenManager) issuePair(ctx context.Context, base standardHolder, familyID string) (*TokenPair, error) {
+	pair, family, err := tm.signPair(ctx, base, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if tm.store != nil {
+		if err := tm.store.Set(ctx, familyKeyPrefix+familyID, family, tm.refreshExpiration); err != nil {
+			return nil, errors.NewTokenGenerationError("failed to save token family", err)
+		}
+	}
+	return pair, nil
+}
+
+// signPair 签发令牌对，返回待保存的令牌族记录
+// 令牌族记录当前有效的刷新令牌ID，Raw 以外的字段用于吊销时发布事件
+func (tm *TokenManager) signPair(ctx context.Context, base standardHolder, familyID string) (*TokenPair, *TokenInfo, error) {
+	now := time.Now()
+	access := tm.pairClaims(base, familyID, AccessToken, now, tm.accessExpiration)
+	refresh := tm.pairClaims(base, familyID, RefreshToken, now, tm.refreshExpiration)
+
+	accessToken, err := tm.GenerateToken(ctx, access)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := tm.GenerateToken(ctx, refresh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	family := &TokenInfo{
+		Raw:       refresh.standardClaims().TokenID,
+		Type:      RefreshToken,
+		Claims:    refresh,
+		ExpiresAt: refresh.GetExpiresAt(),
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  access.GetExpiresAt(),
+		RefreshExpiresAt: refresh.GetExpiresAt(),
+	}, family, nil
+}
+
+// pairBase 检查claims是按值嵌入 StandardClaims 的结构体指针
+func pairBase(claims Claims) (standardHolder, error) {
+	if claims == nil {
+		return nil, errors.NewClaimsMissingError("claims are required", nil)
+	}
+	value := reflect.ValueOf(claims)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, errors.NewClaimsMissingError("claims are required", nil)
+	}
+	base, ok := claims.(standardHolder)
+	// 嵌入 *StandardClaims 时复制后仍共享同一份标准字段
+	if !ok || value.Kind() != reflect.Ptr || cloneClaims(base).standardClaims() == base.standardClaims() {
+		return nil, errors.NewClaimsInvalidError(fmt.Sprintf("claims type %T does not embed StandardClaims", claims), nil)
+	}
+	return base, nil
+}
+
+// cloneClaims 浅复制claims，复制结果与原claims类型相同
+func cloneClaims(base standardHolder) standardHolder {
+	value := reflect.New(reflect.TypeOf(base).Elem())
+	value.Elem().Set(reflect.ValueOf(base).Elem())
+	return value.Interface().(standardHolder)
+}
+
+// pairClaims 复制 base 并设置令牌类型、ID、令牌族和有效期
+func (tm *TokenManager) pairClaims(base standardHolder, familyID string, tokenType TokenType, now time.Time, expiration time.Duration) standardHolder {
+	copied := cloneClaims(base)
+	claims := copied.standardClaims()
+	claims.TokenType = tokenType
+	claims.TokenID = uuid.New().String()
+	claims.FamilyID = familyID
+	claims.ID = claims.TokenID
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expiration))
+	return copied
+}
+
+// reuseDetected 处理刷新令牌被重复使用，吊销令牌族并返回 TokenRevoked 错误
+func (tm *TokenManager) reuseDetected(ctx context.Context, claims *StandardClaims, family *TokenInfo) error {
+	tm.logger.Warn(ctx, "refresh token reuse detected",
+		types.Field{Key: "user_id", Value: claims.UserID},
+		types.Field{Key: "family_id", Value: claims.FamilyID},
+		types.Field{Key: "token_id", Value: claims.TokenID},
+	)
+	if tm.metrics {
+		metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("refresh", "reuse_detected").Inc()
+	}
+	if err := tm.revokeFamily(ctx, claims, family, "refresh_token_reuse"); err != nil {
+		return err
+	}
+	return errors.NewTokenRevokedError("refresh token reuse detected, token family revoked", nil)
+}
+
+// revokeFamily 标记令牌族已吊销，加入黑名单并发布 TokenRevoked 事件，调用方需持有令牌族锁
+func (tm *TokenManager) revokeFamily(ctx context.Context, claims *StandardClaims, family *TokenInfo, reason string) error {
+	ttl := time.Until(family.ExpiresAt)
+	if ttl <= 0 {
+		ttl = tm.refreshExpiration
+	}
+
+	family.IsRevoked = true
+	if err := tm.store.Set(ctx, familyKeyPrefix+claims.FamilyID, family, ttl); err != nil {
+		return errors.NewTokenBlacklistError("failed to revoke token family", err)
+	}
+	if tm.blacklist != nil {
+		// 访问令牌有效期不超过刷新令牌，黑名单保留到最后一个刷新令牌过期即可
+		if err := tm.blacklist.Add(ctx, familyKeyPrefix+claims.FamilyID, ttl); err != nil {
+			return errors.NewTokenBlacklistError("failed to blacklist token family", err)
+		}
+	}
+
+	tm.logger.Info(ctx, "token family revoked",
+		types.Field{Key: "user_id", Value: claims.UserID},
+		types.Field{Key: "family_id", Value: claims.FamilyID},
+		types.Field{Key: "reason", Value: reason},
+	)
+
+	if tm.publisher != nil {
+		event := &events.Event{
+			Type:    events.TokenRevoked,
+			UserID:  claims.UserID,
+			TokenID: claims.TokenID,
+			Payload: map[string]interface{}{
+				"family_id": claims.FamilyID,
+				"reason":    reason,
+			},
+		}
+		if err := tm.publisher.PublishEvent(ctx, event); err != nil {
+			tm.logger.Error(ctx, "failed to publish token revoked event",
+				types.Field{Key: "family_id", Value: claims.FamilyID},
+				types.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// checkRevoked 检查令牌ID和令牌族是否在黑名单中
+func (tm *TokenManager) checkRevoked(ctx context.Context, claims Claims) error {
+	if tm.blacklist == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, 2)
+	if tokenID := claims.GetTokenID(); tokenID != "" {
+		keys = append(keys, tokenID)
+	}
+	// 自定义claims嵌入 StandardClaims 时同样带有令牌族ID
+	if family, ok := claims.(interface{ GetFamilyID() string }); ok && family.GetFamilyID() != "" {
+		keys = append(keys, familyKeyPrefix+family.GetFamilyID())
+	}
+	for _, key := range keys {
+		revoked, err := tm.blacklist.IsBlacklisted(ctx, key)
+		if err != nil {
+			return errors.NewTokenBlacklistError("failed to check token blacklist", err)
+		}
+		if revoked {
+			return errors.NewTokenRevokedError("token has been revoked", nil)
+		}
+	}
+	return nil
+}
+
+// familyLock 返回令牌族对应的分段锁
+func (tm *TokenManager) familyLock(familyID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(familyID))
+	return &tm.familyLocks[h.Sum32()%familyLockStripes]
+}
+
+// isNotFound 判断存储返回的是否为不存在或已过期
+func isNotFound(err error) bool {
+	return errors.HasErrorCode(err, codes.StoreErrNotFound) ||
+		errors.HasErrorCode(err, codes.RedisKeyNotFoundError) ||
+		errors.HasErrorCode(err, codes.TokenExpired)
+}
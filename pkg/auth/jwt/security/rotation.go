@@ -13,7 +13,7 @@ import (
 
 // KeyRotator 密钥轮换管理器
 type KeyRotator struct {
-	keyManager *crypto.KeyManager
+	keyManager crypto.KeyProvider
 	policy     *Policy
 	stopCh     chan struct{}
 	mutex      sync.RWMutex
@@ -22,7 +22,9 @@ type KeyRotator struct {
 }
 
 // NewKeyRotator 创建密钥轮换管理器
-func NewKeyRotator(keyManager *crypto.KeyManager, policy *Policy, logger types.Logger) (*KeyRotator, error) {
+// keyManager 可以是 crypto.KeyManager 或 crypto.Keyring，使用 Keyring 时旧密钥在宽限期内仍可验证，
+// 并在配置了事件发布者时发布 KeyRotated 事件
+func NewKeyRotator(keyManager crypto.KeyProvider, policy *Policy, logger types.Logger) (*KeyRotator, error) {
 	if keyManager == nil {
 		return nil, errors.NewConfigInvalidError("key manager is required", nil)
 	}
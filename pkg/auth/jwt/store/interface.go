@@ -17,6 +17,9 @@ type Store interface {
 	// Delete 删除JWT令牌
 	Delete(ctx context.Context, key string) error
 
+	// CompareAndSwap 仅当记录存在、未吊销且 Raw 等于 old 时写入，返回是否写入
+	CompareAndSwap(ctx context.Context, key string, old string, value *jwt.TokenInfo, expiration time.Duration) (bool, error)
+
 	// Close 关闭存储连接
 	Close() error
 }
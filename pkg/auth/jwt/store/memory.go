@@ -110,6 +110,35 @@ func (s *MemoryStore) Set(ctx context.Context, token string, tokenInfo *jwt.Toke
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.save(ctx, token, tokenInfo)
+}
+
+// CompareAndSwap 仅当 token 的记录存在、未过期、未吊销且 Raw 等于 old 时写入 tokenInfo
+func (s *MemoryStore) CompareAndSwap(ctx context.Context, token string, old string, tokenInfo *jwt.TokenInfo, expiration time.Duration) (bool, error) {
+	span, ctx := jaeger.StartSpanFromContext(ctx, "store.memory.compare_and_swap")
+	if span != nil {
+		defer span.Finish()
+	}
+
+	if tokenInfo == nil {
+		return false, errors.NewTokenInvalidError("token info cannot be nil", nil)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, exists := s.tokens[token]
+	if !exists || time.Now().After(current.ExpiresAt) || current.IsRevoked || current.Raw != old {
+		return false, nil
+	}
+	if err := s.save(ctx, token, tokenInfo); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// save 保存Token信息并更新用户Token映射，调用方需持有写锁
+func (s *MemoryStore) save(ctx context.Context, token string, tokenInfo *jwt.TokenInfo) error {
 	// 检查Token是否已过期
 	if time.Now().After(tokenInfo.ExpiresAt) {
 		s.logger.WithFields(types.Field{
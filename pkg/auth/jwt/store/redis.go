@@ -12,6 +12,25 @@ import (
 	"gobase/pkg/trace/jaeger"
 )
 
+// compareAndSwapScript 比较记录中的 raw 并写入新值
+// KEYS[1] 记录键，ARGV[1] 期望的 raw，ARGV[2] 新值，ARGV[3] 过期时间(毫秒，0 表示不过期)
+const compareAndSwapScript = `
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local info = cjson.decode(current)
+if info.raw ~= ARGV[1] or info.is_revoked then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`
+
 // RedisTokenStore Redis实现的Token存储
 type RedisTokenStore struct {
 	client redis.Client
@@ -85,6 +104,32 @@ func (s *RedisTokenStore) Get(ctx context.Context, token string) (*jwt.TokenInfo
 	return &info, nil
 }
 
+// CompareAndSwap 仅当 token 的记录存在、未吊销且 Raw 等于 old 时写入 info，比较和写入在同一个脚本中原子执行
+func (s *RedisTokenStore) CompareAndSwap(ctx context.Context, token string, old string, info *jwt.TokenInfo, expiration time.Duration) (bool, error) {
+	span, ctx := jaeger.StartSpanFromContext(ctx, "store.redis.compare_and_swap")
+	if span != nil {
+		defer span.Finish()
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return false, errors.NewSerializationError("failed to marshal token info", err)
+	}
+
+	key := s.prefix + token
+	result, err := s.client.Eval(ctx, compareAndSwapScript, []string{key}, old, string(data), expiration.Milliseconds())
+	if err != nil {
+		s.logger.Error(ctx, "failed to swap token",
+			types.Field{Key: "token", Value: token},
+			types.Field{Key: "error", Value: err},
+		)
+		return false, errors.NewRedisCommandError("failed to swap token", err)
+	}
+
+	swapped, _ := result.(int64)
+	return swapped == 1, nil
+}
+
 // Delete 删除Token信息
 func (s *RedisTokenStore) Delete(ctx context.Context, token string) error {
 	span, ctx := jaeger.StartSpanFromContext(ctx, "store.redis.delete")
@@ -14,7 +14,9 @@ import (
 	"gobase/pkg/errors/types"
 	"gobase/pkg/logger/logrus"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type redisStoreTestSuite struct {
@@ -101,3 +103,51 @@ func TestRedisStore_Get(t *testing.T) {
 		assert.Equal(t, info.Raw, retrieved.Raw)
 	})
 }
+
+func TestRedisStore_CompareAndSwap(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	s := store.NewRedisTokenStore(client, &store.Options{KeyPrefix: "test:"}, logrus.NewNopLogger())
+	newInfo := func(raw string) *jwt.TokenInfo {
+		return &jwt.TokenInfo{
+			Raw:       raw,
+			Type:      jwt.RefreshToken,
+			ExpiresAt: time.Now().Add(time.Hour),
+			Claims:    createTestClaims("test_user"),
+		}
+	}
+
+	// 记录不存在时不写入
+	swapped, err := s.CompareAndSwap(ctx, "family", "v1", newInfo("v2"), time.Hour)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	require.NoError(t, s.Set(ctx, "family", newInfo("v1"), time.Hour))
+
+	// 只有期望值匹配的一次交换成功
+	swapped, err = s.CompareAndSwap(ctx, "family", "v1", newInfo("v2"), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = s.CompareAndSwap(ctx, "family", "v1", newInfo("v3"), time.Hour)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	info, err := s.Get(ctx, "family")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", info.Raw)
+	assert.Greater(t, mr.TTL("test:token:family"), time.Duration(0))
+
+	// 已吊销的记录不再被覆盖
+	revoked := newInfo("v2")
+	revoked.IsRevoked = true
+	require.NoError(t, s.Set(ctx, "family", revoked, time.Hour))
+	swapped, err = s.CompareAndSwap(ctx, "family", "v2", newInfo("v3"), time.Hour)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+}
@@ -1,12 +1,16 @@
 package jwt_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
 )
 
 func TestStandardClaims_NewClaims(t *testing.T) {
@@ -244,3 +248,52 @@ func TestStandardClaims_Validate(t *testing.T) {
 		})
 	}
 }
+
+// tenantClaims 应用自定义的claims
+type tenantClaims struct {
+	jwt.StandardClaims
+	TenantID string   `json:"tenant_id"`
+	Scopes   []string `json:"scopes"`
+}
+
+func TestParseInto(t *testing.T) {
+	ctx := context.Background()
+	tm, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	claims := &tenantClaims{
+		StandardClaims: *jwt.NewStandardClaims(
+			jwt.WithUserID("test-user"),
+			jwt.WithTokenType(jwt.AccessToken),
+			jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+		),
+		TenantID: "tenant-1",
+		Scopes:   []string{"orders:read"},
+	}
+	tokenString, err := tm.GenerateToken(ctx, claims)
+	require.NoError(t, err)
+
+	// 自定义字段在签发和解析后保留
+	parsed, err := jwt.ParseInto[tenantClaims](ctx, tm, tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", parsed.TenantID)
+	assert.Equal(t, []string{"orders:read"}, parsed.Scopes)
+	assert.Equal(t, "test-user", parsed.GetUserID())
+
+	_, err = jwt.ParseInto[tenantClaims](ctx, tm, "invalid-token")
+	assert.Error(t, err)
+
+	// 配置claims工厂后 ValidateToken 返回自定义类型
+	tm, err = jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics(),
+		jwt.WithClaimsFactory(func() jwt.Claims { return &tenantClaims{} }),
+	)
+	require.NoError(t, err)
+	token, err := tm.ValidateToken(ctx, tokenString)
+	require.NoError(t, err)
+	typed, err := jwt.ClaimsAs[*tenantClaims](token.Claims.(jwt.Claims))
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", typed.TenantID)
+
+	_, err = jwt.ClaimsAs[*jwt.StandardClaims](typed)
+	assert.True(t, errors.HasErrorCode(err, codes.ClaimsInvalid))
+}
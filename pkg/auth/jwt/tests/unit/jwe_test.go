@@ -0,0 +1,90 @@
+package jwt_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptJWE(t *testing.T) {
+	keys, err := jwt.NewDirectKeyProvider(testEncryptionKey)
+	require.NoError(t, err)
+
+	tokenString, err := jwt.EncryptJWE(keys, []byte("confidential"), "")
+	require.NoError(t, err)
+	parts := strings.Split(tokenString, ".")
+	require.Len(t, parts, 5)
+	assert.Empty(t, parts[1], "dir 不携带加密密钥")
+
+	plaintext, header, err := jwt.DecryptJWE(keys, tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, "confidential", string(plaintext))
+	assert.Equal(t, jwt.Direct, header.Algorithm)
+
+	// 篡改密文或头部后认证失败
+	tampered := append([]string(nil), parts...)
+	tampered[3] = strings.Repeat("A", len(parts[3]))
+	_, _, err = jwt.DecryptJWE(keys, strings.Join(tampered, "."))
+	assert.True(t, errors.HasErrorCode(err, codes.DecryptionError))
+
+	// 密钥不同无法解密
+	other, err := jwt.NewDirectKeyProvider([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	_, _, err = jwt.DecryptJWE(other, tokenString)
+	assert.True(t, errors.HasErrorCode(err, codes.DecryptionError))
+
+	_, err = jwt.NewDirectKeyProvider([]byte("short"))
+	assert.True(t, errors.HasErrorCode(err, codes.KeyInvalid))
+
+	_, _, err = jwt.DecryptJWE(keys, "a.b.c")
+	assert.True(t, errors.HasErrorCode(err, codes.TokenInvalid))
+}
+
+func TestTokenManager_WithEncryption(t *testing.T) {
+	ctx := context.Background()
+	keys, err := jwt.NewDirectKeyProvider(testEncryptionKey)
+	require.NoError(t, err)
+	tm, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics(), jwt.WithEncryption(keys))
+	require.NoError(t, err)
+
+	claims := jwt.NewStandardClaims(
+		jwt.WithUserID("test-user"),
+		jwt.WithIPAddress("10.0.0.1"),
+		jwt.WithTokenType(jwt.AccessToken),
+		jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+	)
+	tokenString, err := tm.GenerateToken(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, jwt.IsJWE(tokenString))
+
+	// 内层为签名令牌
+	signed, _, err := jwt.DecryptJWE(keys, tokenString)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(string(signed), "."), 3)
+
+	token, err := tm.ValidateToken(ctx, tokenString)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", token.Claims.(*jwt.StandardClaims).IPAddress)
+
+	// 加密的内容不是签名令牌时拒绝
+	plain, err := jwt.EncryptJWE(keys, []byte(`{"user_id":"test-user"}`), "")
+	require.NoError(t, err)
+	_, err = tm.ValidateToken(ctx, plain)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenInvalid))
+
+	// 未配置加密时拒绝加密令牌
+	unencrypted, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	_, err = unencrypted.ValidateToken(ctx, tokenString)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenInvalid))
+}
@@ -0,0 +1,259 @@
+package jwt_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/blacklist"
+	"gobase/pkg/auth/jwt/config"
+	"gobase/pkg/auth/jwt/events"
+	"gobase/pkg/auth/jwt/store"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// recordingPublisher 记录发布的事件
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (p *recordingPublisher) PublishEvent(ctx context.Context, event *events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// 内存黑名单会注册全局监控指标，测试之间共享同一个实例
+var (
+	testBlacklistOnce sync.Once
+	testBlacklist     blacklist.TokenBlacklist
+)
+
+// newPairTokenManager 创建带令牌族存储和黑名单的 TokenManager
+func newPairTokenManager(t *testing.T, opts ...jwt.TokenManagerOption) (*jwt.TokenManager, *recordingPublisher) {
+	memoryStore, err := store.NewMemoryStore(store.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { memoryStore.Close() })
+
+	testBlacklistOnce.Do(func() {
+		testBlacklist = blacklist.NewStoreAdapter(blacklist.NewMemoryStore())
+	})
+
+	publisher := &recordingPublisher{}
+	opts = append([]jwt.TokenManagerOption{
+		jwt.WithoutTracing(),
+		jwt.WithoutMetrics(),
+		jwt.WithTokenStore(memoryStore),
+		jwt.WithBlacklist(testBlacklist),
+		jwt.WithEventPublisher(publisher),
+	}, opts...)
+	tm, err := jwt.NewTokenManager("test-secret-key", opts...)
+	require.NoError(t, err)
+	return tm, publisher
+}
+
+func TestTokenManager_IssuePair(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.AccessTokenExpiration = 10 * time.Minute
+	cfg.RefreshTokenExpiration = time.Hour
+	tm, _ := newPairTokenManager(t, cfg.TokenManagerOptions()...)
+
+	before := time.Now().Truncate(time.Second)
+	pair, err := tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+	require.NoError(t, err)
+
+	// 有效期来自配置
+	assert.WithinDuration(t, before.Add(10*time.Minute), pair.AccessExpiresAt, 2*time.Second)
+	assert.WithinDuration(t, before.Add(time.Hour), pair.RefreshExpiresAt, 2*time.Second)
+
+	access, err := tm.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	refresh, err := tm.ValidateToken(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+
+	accessClaims := access.Claims.(*jwt.StandardClaims)
+	refreshClaims := refresh.Claims.(*jwt.StandardClaims)
+	assert.Equal(t, jwt.AccessToken, accessClaims.TokenType)
+	assert.Equal(t, jwt.RefreshToken, refreshClaims.TokenType)
+	assert.Equal(t, "test-user", refreshClaims.UserID)
+	assert.NotEmpty(t, accessClaims.FamilyID)
+	assert.Equal(t, accessClaims.FamilyID, refreshClaims.FamilyID)
+	assert.NotEqual(t, accessClaims.TokenID, refreshClaims.TokenID)
+
+	// 访问令牌不能用于刷新
+	_, err = tm.Refresh(ctx, pair.AccessToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenTypeMismatch))
+
+	_, err = tm.IssuePair(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestTokenManager_Refresh(t *testing.T) {
+	ctx := context.Background()
+	tm, publisher := newPairTokenManager(t)
+
+	pair, err := tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+	require.NoError(t, err)
+
+	// 刷新后得到新的令牌对，仍属于同一令牌族
+	rotated, err := tm.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+
+	token, err := tm.ValidateToken(ctx, rotated.AccessToken)
+	require.NoError(t, err)
+	familyID := token.Claims.(*jwt.StandardClaims).FamilyID
+
+	next, err := tm.Refresh(ctx, rotated.RefreshToken)
+	require.NoError(t, err)
+	assert.Empty(t, publisher.events)
+
+	// 已使用的刷新令牌再次出现时吊销整个令牌族
+	_, err = tm.Refresh(ctx, pair.RefreshToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+
+	for _, tokenString := range []string{next.AccessToken, next.RefreshToken, rotated.AccessToken} {
+		_, err = tm.ValidateToken(ctx, tokenString)
+		assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+	}
+	_, err = tm.Refresh(ctx, next.RefreshToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, events.TokenRevoked, event.Type)
+	assert.Equal(t, "test-user", event.UserID)
+	assert.Equal(t, familyID, event.Payload["family_id"])
+	assert.Equal(t, "refresh_token_reuse", event.Payload["reason"])
+
+	// 其他令牌族不受影响
+	other, err := tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+	require.NoError(t, err)
+	_, err = tm.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenManager_RefreshCustomClaims(t *testing.T) {
+	ctx := context.Background()
+	tm, _ := newPairTokenManager(t, jwt.WithClaimsFactory(func() jwt.Claims { return &tenantClaims{} }))
+
+	claims := &tenantClaims{
+		StandardClaims: jwt.StandardClaims{UserID: "test-user"},
+		TenantID:       "tenant-1",
+		Scopes:         []string{"orders:read"},
+	}
+	pair, err := tm.IssuePair(ctx, claims)
+	require.NoError(t, err)
+	// 传入的claims不被修改
+	assert.Empty(t, claims.TokenID)
+
+	// 自定义字段随刷新保留到新的令牌对
+	rotated, err := tm.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	for _, tokenString := range []string{rotated.AccessToken, rotated.RefreshToken} {
+		token, err := tm.ValidateToken(ctx, tokenString)
+		require.NoError(t, err)
+		typed, err := jwt.ClaimsAs[*tenantClaims](token.Claims.(jwt.Claims))
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1", typed.TenantID)
+		assert.Equal(t, []string{"orders:read"}, typed.Scopes)
+		assert.Equal(t, "test-user", typed.GetUserID())
+		assert.NotEmpty(t, typed.FamilyID)
+	}
+
+	// 未嵌入 StandardClaims 的类型被拒绝
+	_, err = tm.IssuePair(ctx, &pointerClaims{StandardClaims: &jwt.StandardClaims{UserID: "test-user"}})
+	assert.True(t, errors.HasErrorCode(err, codes.ClaimsInvalid))
+	_, err = tm.IssuePair(ctx, nil)
+	assert.True(t, errors.HasErrorCode(err, codes.ClaimsMissing))
+}
+
+// pointerClaims 嵌入 *StandardClaims，复制后仍共享标准字段
+type pointerClaims struct {
+	*jwt.StandardClaims
+}
+
+// slowStore 读取后延迟返回，让并发的刷新都在对方写入前读到令牌族记录
+type slowStore struct {
+	*store.MemoryStore
+}
+
+func (s *slowStore) Get(ctx context.Context, key string) (*jwt.TokenInfo, error) {
+	info, err := s.MemoryStore.Get(ctx, key)
+	time.Sleep(20 * time.Millisecond)
+	return info, err
+}
+
+func TestTokenManager_RefreshConcurrent(t *testing.T) {
+	ctx := context.Background()
+	memoryStore, err := store.NewMemoryStore(store.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { memoryStore.Close() })
+	shared := &slowStore{MemoryStore: memoryStore}
+
+	// 两个实例共享令牌族存储，各自的令牌族锁互不可见
+	first, _ := newPairTokenManager(t, jwt.WithTokenStore(shared))
+	second, _ := newPairTokenManager(t, jwt.WithTokenStore(shared))
+
+	for i := 0; i < 5; i++ {
+		pair, err := first.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, tm := range []*jwt.TokenManager{first, second} {
+			wg.Add(1)
+			go func(j int, tm *jwt.TokenManager) {
+				defer wg.Done()
+				_, errs[j] = tm.Refresh(ctx, pair.RefreshToken)
+			}(j, tm)
+		}
+		wg.Wait()
+
+		// 同一个刷新令牌只能轮换一次，另一个实例按重复使用处理
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+		}
+		assert.Equal(t, 1, succeeded)
+	}
+}
+
+func TestTokenManager_RevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	tm, publisher := newPairTokenManager(t)
+
+	pair, err := tm.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+	require.NoError(t, err)
+	token, err := tm.ValidateToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, tm.RevokeFamily(ctx, token.Claims.(*jwt.StandardClaims).FamilyID))
+	_, err = tm.ValidateToken(ctx, pair.AccessToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+	_, err = tm.Refresh(ctx, pair.RefreshToken)
+	assert.True(t, errors.HasErrorCode(err, codes.TokenRevoked))
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "revoked", publisher.events[0].Payload["reason"])
+
+	// 未配置存储时无法刷新
+	plain, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+	pair, err = plain.IssuePair(ctx, &jwt.StandardClaims{UserID: "test-user"})
+	require.NoError(t, err)
+	_, err = plain.Refresh(ctx, pair.RefreshToken)
+	assert.True(t, errors.HasErrorCode(err, codes.ConfigInvalid))
+}
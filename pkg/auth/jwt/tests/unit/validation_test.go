@@ -0,0 +1,135 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/auth/jwt/config"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+func TestTokenManager_ClaimsValidation(t *testing.T) {
+	ctx := context.Background()
+	issuer, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	cfg := config.DefaultConfig()
+	config.WithClaimsValidation([]string{"auth-service"}, []string{"orders", "billing"}, []string{"exp", "user_id"})(cfg)
+	config.WithLeeway(30 * time.Second)(cfg)
+	config.WithMaxTokenAge(time.Hour)(cfg)
+	opts := append([]jwt.TokenManagerOption{jwt.WithoutTracing(), jwt.WithoutMetrics()}, cfg.TokenManagerOptions()...)
+	verifier, err := jwt.NewTokenManager("test-secret-key", opts...)
+	require.NoError(t, err)
+
+	now := time.Now()
+	newClaims := func(opts ...jwt.ClaimsOption) *jwt.StandardClaims {
+		claims := jwt.NewStandardClaims(
+			jwt.WithUserID("test-user"),
+			jwt.WithTokenType(jwt.AccessToken),
+			jwt.WithIssuer("auth-service"),
+			jwt.WithAudience([]string{"orders"}),
+			jwt.WithExpiresAt(now.Add(time.Hour)),
+		)
+		for _, opt := range opts {
+			opt(claims)
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		claims   *jwt.StandardClaims
+		wantCode string
+	}{
+		{
+			name:   "声明符合要求",
+			claims: newClaims(),
+		},
+		{
+			name:   "时钟偏差内尚未生效",
+			claims: newClaims(jwt.WithNotBefore(now.Add(10 * time.Second))),
+		},
+		{
+			name:     "签发者不被接受",
+			claims:   newClaims(jwt.WithIssuer("other-service")),
+			wantCode: codes.ClaimsInvalid,
+		},
+		{
+			name:     "受众不匹配",
+			claims:   newClaims(jwt.WithAudience([]string{"payments"})),
+			wantCode: codes.ClaimsInvalid,
+		},
+		{
+			name:     "超过时钟偏差尚未生效",
+			claims:   newClaims(jwt.WithNotBefore(now.Add(time.Minute))),
+			wantCode: codes.ClaimsInvalid,
+		},
+		{
+			name: "签发时间晚于当前时间",
+			claims: newClaims(func(c *jwt.StandardClaims) {
+				c.IssuedAt = jwtlib.NewNumericDate(now.Add(time.Minute))
+			}),
+			wantCode: codes.ClaimsInvalid,
+		},
+		{
+			name: "超过最长有效时间",
+			claims: newClaims(func(c *jwt.StandardClaims) {
+				c.IssuedAt = jwtlib.NewNumericDate(now.Add(-2 * time.Hour))
+			}),
+			wantCode: codes.TokenExpired,
+		},
+		{
+			name: "缺少必需声明",
+			claims: newClaims(func(c *jwt.StandardClaims) {
+				c.ExpiresAt = nil
+			}),
+			wantCode: codes.ClaimsMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := issuer.GenerateToken(ctx, tt.claims)
+			require.NoError(t, err)
+
+			_, err = verifier.ValidateToken(ctx, tokenString)
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, errors.GetErrorCode(err))
+		})
+	}
+}
+
+func TestTokenManager_Leeway(t *testing.T) {
+	ctx := context.Background()
+	issuer, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics())
+	require.NoError(t, err)
+
+	// 刚刚过期的令牌
+	claims := jwt.NewStandardClaims(
+		jwt.WithUserID("test-user"),
+		jwt.WithTokenType(jwt.AccessToken),
+		jwt.WithExpiresAt(time.Now().Add(2*time.Second)),
+	)
+	tokenString, err := issuer.GenerateToken(ctx, claims)
+	require.NoError(t, err)
+	time.Sleep(3 * time.Second)
+
+	_, err = issuer.ValidateToken(ctx, tokenString)
+	assert.Equal(t, codes.TokenExpired, errors.GetErrorCode(err))
+
+	lenient, err := jwt.NewTokenManager("test-secret-key", jwt.WithoutTracing(), jwt.WithoutMetrics(), jwt.WithLeeway(time.Minute))
+	require.NoError(t, err)
+	_, err = lenient.ValidateToken(ctx, tokenString)
+	assert.NoError(t, err)
+}
@@ -20,11 +20,32 @@ import (
 
 // TokenManager JWT token管理器
 type TokenManager struct {
-	secretKey  []byte
-	logger     types.Logger
-	provider   *jaeger.Provider
-	metrics    bool
-	claimsPool sync.Pool
+	keys     KeyProvider
+	method   jwt.SigningMethod
+	parser   *jwt.Parser
+	logger   types.Logger
+	provider *jaeger.Provider
+	metrics  bool
+
+	// newClaims 创建解析令牌使用的claims对象
+	newClaims ClaimsFactory
+	// encryption 配置后签发嵌套 JWE 令牌
+	encryption EncryptionKeyProvider
+
+	// 声明验证
+	issuers        []string
+	audiences      []string
+	leeway         time.Duration
+	maxTokenAge    time.Duration
+	requiredClaims []string
+
+	// 令牌对与刷新
+	accessExpiration  time.Duration
+	refreshExpiration time.Duration
+	store             TokenStore
+	blacklist         Blacklist
+	publisher         EventPublisher
+	familyLocks       [familyLockStripes]sync.Mutex
 }
 
 // TokenManagerOption 定义 TokenManager 的选项
@@ -51,8 +72,36 @@ func WithoutMetrics() TokenManagerOption {
 	}
 }
 
-// NewTokenManager 创建新的token管理器
+// WithClaimsFactory 设置解析令牌使用的claims工厂，用于应用自定义的claims结构
+// 工厂每次调用都必须返回新的对象，默认创建 *StandardClaims
+func WithClaimsFactory(factory ClaimsFactory) TokenManagerOption {
+	return func(tm *TokenManager) {
+		if factory != nil {
+			tm.newClaims = factory
+		}
+	}
+}
+
+// NewTokenManager 创建使用 HS256 和固定密钥的token管理器
 func NewTokenManager(secretKey string, opts ...TokenManagerOption) (*TokenManager, error) {
+	keys, err := NewSecretKeyProvider(HS256, []byte(secretKey))
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenManagerWithKeys(keys, opts...)
+}
+
+// NewTokenManagerWithKeys 创建使用密钥提供者的token管理器
+// 按 keys.Method() 签名，验证时只接受该签名方法，其他算法的令牌一律拒绝
+func NewTokenManagerWithKeys(keys KeyProvider, opts ...TokenManagerOption) (*TokenManager, error) {
+	if keys == nil {
+		return nil, errors.NewKeyInvalidError("key provider is required", nil)
+	}
+	method, err := signingMethod(keys.Method())
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建logger
 	log, err := logger.NewLogger(
 		logger.WithLevel(types.InfoLevel),
@@ -63,14 +112,15 @@ func NewTokenManager(secretKey string, opts ...TokenManagerOption) (*TokenManage
 	}
 
 	tm := &TokenManager{
-		secretKey: []byte(secretKey),
-		logger:    log,
-		metrics:   true,
-		claimsPool: sync.Pool{
-			New: func() interface{} {
-				return &StandardClaims{}
-			},
-		},
+		keys:    keys,
+		method:  method,
+		logger:  log,
+		metrics: true,
+
+		newClaims: func() Claims { return &StandardClaims{} },
+
+		accessExpiration:  DefaultAccessTokenExpiration,
+		refreshExpiration: DefaultRefreshTokenExpiration,
 	}
 
 	// 默认创建 jaeger provider
@@ -83,6 +133,9 @@ func NewTokenManager(secretKey string, opts ...TokenManagerOption) (*TokenManage
 		opt(tm)
 	}
 
+	// 解析器依赖时钟偏差等选项，在应用选项后创建
+	tm.parser = jwt.NewParser(tm.parserOptions()...)
+
 	return tm, nil
 }
 
@@ -109,11 +162,25 @@ func (tm *TokenManager) GenerateToken(ctx context.Context, claims Claims) (strin
 		return "", errors.NewError(codes.TokenExpired, "token is expired", nil)
 	}
 
+	kid, key, err := tm.signingKey()
+	if err == nil {
+		err = checkKey(tm.keys.Method(), key, true)
+	}
+	if err != nil {
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("generate", "signing_key").Inc()
+		}
+		return "", errors.NewError(codes.TokenSignFailed, "failed to get signing key", err)
+	}
+
 	// 创建token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(tm.method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
 	// 签名token
-	tokenString, err := token.SignedString(tm.secretKey)
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		if tm.metrics {
 			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("generate", err.Error()).Inc()
@@ -121,11 +188,26 @@ func (tm *TokenManager) GenerateToken(ctx context.Context, claims Claims) (strin
 		return "", errors.NewError(codes.TokenSignFailed, "failed to sign token", err)
 	}
 
+	// 先签名再加密
+	if tm.encryption != nil {
+		if tokenString, err = tm.encrypt(tokenString); err != nil {
+			if tm.metrics {
+				metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("generate", "encrypt").Inc()
+			}
+			return "", err
+		}
+	}
+
 	return tokenString, nil
 }
 
-// ValidateToken 验证令牌
+// ValidateToken 验证令牌，token.Claims 由 WithClaimsFactory 配置的工厂创建
 func (tm *TokenManager) ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, error) {
+	return tm.validateInto(ctx, tokenString, tm.newClaims())
+}
+
+// validateInto 验证令牌并解析到 claims
+func (tm *TokenManager) validateInto(ctx context.Context, tokenString string, claims Claims) (*jwt.Token, error) {
 	start := time.Now()
 	defer func() {
 		if tm.metrics {
@@ -133,16 +215,26 @@ func (tm *TokenManager) ValidateToken(ctx context.Context, tokenString string) (
 		}
 	}()
 
-	// 从对象池获取claims对象
-	claims := tm.claimsPool.Get().(*StandardClaims)
-	defer tm.claimsPool.Put(claims)
-
-	// 使用预分配的claims对象
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.NewSignatureInvalidError("invalid signing method", nil)
+	// 嵌套令牌先解密，再验证内层签名
+	if IsJWE(tokenString) {
+		signed, err := tm.decrypt(tokenString)
+		if err != nil {
+			if tm.metrics {
+				metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "decrypt").Inc()
+			}
+			return nil, err
 		}
-		return tm.secretKey, nil
+		tokenString = signed
+	}
+
+	// 解析器会调用 claims 的 Validate，嵌入 StandardClaims 的claims按相同的时钟偏差检查过期时间
+	if c, ok := claims.(interface{ setLeeway(time.Duration) }); ok {
+		c.setLeeway(tm.leeway)
+	}
+
+	// 返回的 token.Claims 由调用方持有，每次使用新的claims对象，解析器只接受配置的签名方法
+	token, err := tm.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return tm.verificationKey(ctx, token)
 	})
 
 	if err != nil {
@@ -152,9 +244,66 @@ func (tm *TokenManager) ValidateToken(ctx context.Context, tokenString string) (
 		return nil, tm.HandleValidationError(err)
 	}
 
+	// 检查签发者、受众、令牌年龄和必需声明
+	if err := tm.validateClaims(token, claims); err != nil {
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "claims").Inc()
+		}
+		return nil, err
+	}
+
+	// 配置了黑名单时检查令牌及其所属的令牌族是否已吊销
+	if err := tm.checkRevoked(ctx, claims); err != nil {
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "revoked").Inc()
+		}
+		return nil, err
+	}
+
 	return token, nil
 }
 
+// SigningMethod 获取签名方法
+func (tm *TokenManager) SigningMethod() SigningMethod {
+	return tm.keys.Method()
+}
+
+// verificationKey 返回令牌的验证密钥
+// 令牌头中的算法必须与配置的签名方法一致，密钥类型必须与签名方法匹配，防止算法混淆攻击
+// 密钥提供者实现 ContextKeyIDProvider 时，获取密钥的等待受 ctx 限制
+func (tm *TokenManager) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	if token.Method == nil || token.Method.Alg() != tm.method.Alg() {
+		return nil, errors.NewAlgorithmMismatchError("unexpected signing method", nil)
+	}
+	var key interface{}
+	var err error
+	kid, _ := token.Header["kid"].(string)
+	if ring, ok := tm.keys.(ContextKeyIDProvider); ok && kid != "" {
+		key, err = ring.GetVerificationKeyByIDContext(ctx, kid)
+	} else if ring, ok := tm.keys.(KeyIDProvider); ok && kid != "" {
+		key, err = ring.GetVerificationKeyByID(kid)
+	} else {
+		// 没有 kid 的令牌使用当前密钥验证
+		key, err = tm.keys.GetVerificationKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKey(tm.keys.Method(), key, false); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// signingKey 返回签名密钥，密钥提供者支持 kid 时同时返回 kid
+func (tm *TokenManager) signingKey() (string, interface{}, error) {
+	if ring, ok := tm.keys.(KeyIDProvider); ok {
+		return ring.GetSigningKeyWithID()
+	}
+	key, err := tm.keys.GetSigningKey()
+	return "", key, err
+}
+
 // ParseToken 解析JWT token
 func (tm *TokenManager) ParseToken(ctx context.Context, tokenString string) (jwt.Claims, error) {
 	// 验证token
@@ -187,6 +336,21 @@ func (tm *TokenManager) HandleValidationError(tokenErr error) error {
 
 	var err error
 	switch {
+	case errors.Is(tokenErr, jwt.ErrTokenSignatureInvalid) && strings.Contains(tokenErr.Error(), "signing method"),
+		errors.GetErrorCode(tokenErr) == codes.AlgorithmMismatch:
+		// 令牌使用了未配置的签名方法
+		span.SetTag("error.reason", "algorithm_mismatch")
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "algorithm_mismatch").Inc()
+		}
+		err = errors.NewAlgorithmMismatchError("token signing method is not allowed", tokenErr)
+	case errors.GetErrorCode(tokenErr) == codes.KeyInvalid:
+		// kid 未知、密钥已超过宽限期或密钥类型不匹配
+		span.SetTag("error.reason", "key_invalid")
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "key_invalid").Inc()
+		}
+		err = errors.NewKeyInvalidError("token verification key is not available", tokenErr)
 	case errors.Is(tokenErr, jwt.ErrTokenMalformed):
 		span.SetTag("error.reason", "token_malformed")
 		if tm.metrics {
@@ -203,6 +367,13 @@ func (tm *TokenManager) HandleValidationError(tokenErr error) error {
 		err = errors.NewTokenExpiredError("token has expired", tokenErr)
 		tm.logger.Debug(span.Context(), "mapped to token expired error",
 			types.Field{Key: "error_code", Value: "TokenExpired"})
+	case errors.Is(tokenErr, jwt.ErrTokenNotValidYet) || errors.Is(tokenErr, jwt.ErrTokenUsedBeforeIssued):
+		// nbf 或 iat 晚于当前时间加时钟偏差
+		span.SetTag("error.reason", "token_not_valid_yet")
+		if tm.metrics {
+			metrics.DefaultJWTMetrics.TokenErrors.WithLabelValues("validate", "not_valid_yet").Inc()
+		}
+		err = errors.NewClaimsInvalidError("token is not valid yet", tokenErr)
 	case errors.Is(tokenErr, jwt.ErrSignatureInvalid) ||
 		strings.Contains(strings.ToLower(tokenErr.Error()), "signature is invalid") ||
 		strings.Contains(strings.ToLower(tokenErr.Error()), "invalid signature"):
@@ -277,8 +448,11 @@ func ParseToken(tokenString string, opts ...TokenOption) (*Token, error) {
 		return nil, errors.NewError(codes.TokenInvalid, "secret is required", nil)
 	}
 
-	// 解析token
-	token, err := jwt.ParseWithClaims(tokenString, &StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+	// 解析token，WithClaims 提供的claims作为解析目标，用于自定义claims
+	if t.claims == nil {
+		t.claims = &StandardClaims{}
+	}
+	token, err := jwt.ParseWithClaims(tokenString, t.claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(t.secret), nil
 	})
 
@@ -287,7 +461,6 @@ func ParseToken(tokenString string, opts ...TokenOption) (*Token, error) {
 	}
 
 	t.token = token
-	t.claims = token.Claims.(*StandardClaims)
 
 	return t, nil
 }
@@ -372,8 +545,11 @@ func ParseTokenWithContext(ctx context.Context, tokenString string, opts ...Toke
 		return nil, errors.NewError(codes.TokenInvalid, "secret is required", nil)
 	}
 
-	// 解析token
-	token, err := jwt.ParseWithClaims(tokenString, &StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+	// 解析token，WithClaims 提供的claims作为解析目标，用于自定义claims
+	if t.claims == nil {
+		t.claims = &StandardClaims{}
+	}
+	token, err := jwt.ParseWithClaims(tokenString, t.claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(t.secret), nil
 	})
 
@@ -382,7 +558,6 @@ func ParseTokenWithContext(ctx context.Context, tokenString string, opts ...Toke
 	}
 
 	t.token = token
-	t.claims = token.Claims.(*StandardClaims)
 
 	return t, nil
 }
@@ -121,6 +121,20 @@ const (
 	RS384 SigningMethod = "RS384"
 	// RS512 RSA SHA512
 	RS512 SigningMethod = "RS512"
+	// ES256 ECDSA P-256 SHA256
+	ES256 SigningMethod = "ES256"
+	// ES384 ECDSA P-384 SHA384
+	ES384 SigningMethod = "ES384"
+	// ES512 ECDSA P-521 SHA512
+	ES512 SigningMethod = "ES512"
+	// PS256 RSA-PSS SHA256
+	PS256 SigningMethod = "PS256"
+	// PS384 RSA-PSS SHA384
+	PS384 SigningMethod = "PS384"
+	// PS512 RSA-PSS SHA512
+	PS512 SigningMethod = "PS512"
+	// EdDSA Ed25519
+	EdDSA SigningMethod = "EdDSA"
 )
 
 // KeyPair 存储密钥对
@@ -148,6 +162,6 @@ const (
 // KeyConfig JWT密钥配置
 type KeyConfig struct {
 	SecretKey  string // HMAC密钥
-	PrivateKey string // RSA私钥
-	PublicKey  string // RSA公钥
+	PrivateKey string // RSA/ECDSA/Ed25519私钥(PEM)
+	PublicKey  string // RSA/ECDSA/Ed25519公钥(PEM)
 }
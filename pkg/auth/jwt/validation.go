@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"gobase/pkg/errors"
+)
+
+// WithIssuers 设置可接受的签发者，令牌的 iss 必须是其中之一，为空时不检查
+func WithIssuers(issuers ...string) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.issuers = issuers
+	}
+}
+
+// WithAudiences 设置本服务的受众标识，令牌的 aud 至少包含其中一个，为空时不检查
+func WithAudiences(audiences ...string) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.audiences = audiences
+	}
+}
+
+// WithLeeway 设置验证 exp、nbf 和 iat 时允许的时钟偏差
+func WithLeeway(leeway time.Duration) TokenManagerOption {
+	return func(tm *TokenManager) {
+		if leeway >= 0 {
+			tm.leeway = leeway
+		}
+	}
+}
+
+// WithMaxTokenAge 设置令牌自签发(iat)起的最长有效时间，超过时按过期处理，为0时不检查
+// 设置后令牌必须带有 iat，且 iat 不能晚于当前时间加时钟偏差
+func WithMaxTokenAge(maxAge time.Duration) TokenManagerOption {
+	return func(tm *TokenManager) {
+		if maxAge >= 0 {
+			tm.maxTokenAge = maxAge
+		}
+	}
+}
+
+// WithRequiredClaims 设置令牌必须包含的声明名称，如 "exp"、"iss"、"user_id"
+func WithRequiredClaims(names ...string) TokenManagerOption {
+	return func(tm *TokenManager) {
+		tm.requiredClaims = names
+	}
+}
+
+// parserOptions 返回解析器选项，解析器负责签名方法、exp 和 nbf 的检查
+func (tm *TokenManager) parserOptions() []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithValidMethods([]string{tm.method.Alg()}),
+		jwt.WithLeeway(tm.leeway),
+	}
+}
+
+// validateClaims 检查签发者、受众、令牌年龄和必需声明
+// 签发者、受众和缺少声明返回 ClaimsInvalid 或 ClaimsMissing 错误，超过最长有效时间返回 TokenExpired 错误
+func (tm *TokenManager) validateClaims(token *jwt.Token, claims Claims) error {
+	if len(tm.requiredClaims) > 0 {
+		if err := tm.checkRequiredClaims(token); err != nil {
+			return err
+		}
+	}
+
+	if len(tm.issuers) > 0 {
+		issuer, _ := claims.GetIssuer()
+		if !contains(tm.issuers, issuer) {
+			return errors.NewClaimsInvalidError("token issuer is not accepted", nil)
+		}
+	}
+
+	if len(tm.audiences) > 0 {
+		audience, _ := claims.GetAudience()
+		accepted := false
+		for _, aud := range audience {
+			if contains(tm.audiences, aud) {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return errors.NewClaimsInvalidError("token audience is not accepted", nil)
+		}
+	}
+
+	if tm.maxTokenAge > 0 {
+		issuedAt, _ := claims.GetIssuedAt()
+		if issuedAt == nil {
+			return errors.NewClaimsMissingError("iat is required to check token age", nil)
+		}
+		now := time.Now()
+		if issuedAt.After(now.Add(tm.leeway)) {
+			return errors.NewClaimsInvalidError("token is issued in the future", nil)
+		}
+		if now.Sub(issuedAt.Time) > tm.maxTokenAge+tm.leeway {
+			return errors.NewTokenExpiredError("token exceeds maximum age", nil)
+		}
+	}
+
+	return nil
+}
+
+// checkRequiredClaims 检查载荷中的必需声明存在且不为空
+func (tm *TokenManager) checkRequiredClaims(token *jwt.Token) error {
+	parts := strings.Split(token.Raw, ".")
+	if len(parts) != 3 {
+		return errors.NewTokenInvalidError("token format is invalid", nil)
+	}
+	payload, err := tm.parser.DecodeSegment(parts[1])
+	if err != nil {
+		return errors.NewTokenInvalidError("token payload is malformed", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return errors.NewTokenInvalidError("token payload is malformed", err)
+	}
+
+	for _, name := range tm.requiredClaims {
+		value, ok := values[name]
+		if !ok || isEmptyClaim(value) {
+			return errors.NewClaimsMissingError("required claim "+name+" is missing", nil)
+		}
+	}
+	return nil
+}
+
+// isEmptyClaim 判断声明值是否为 null、空字符串或空数组
+func isEmptyClaim(value json.RawMessage) bool {
+	value = bytes.TrimSpace(value)
+	return len(value) == 0 || bytes.Equal(value, []byte("null")) ||
+		bytes.Equal(value, []byte(`""`)) || bytes.Equal(value, []byte("[]"))
+}
+
+// contains 判断 values 中是否包含 value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -27,4 +27,14 @@ func (s *Store) Del(ctx context.Context, keys ...string) error {
 	return err
 }
 
+// Publish 发布消息到指定的频道
+func (s *Store) Publish(ctx context.Context, channel string, message interface{}) error {
+	return s.client.Publish(ctx, channel, message)
+}
+
+// Subscribe 订阅频道
+func (s *Store) Subscribe(ctx context.Context, channels ...string) redis.PubSub {
+	return s.client.Subscribe(ctx, channels...)
+}
+
 // 实现其他必要的存储方法...
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -15,6 +16,32 @@ type Config struct {
 	ELK    ELKConfig          `mapstructure:"elk" yaml:"elk"`
 	Logger LoggerConfig       `mapstructure:"logger" yaml:"logger"`
 	Jaeger types.JaegerConfig `mapstructure:"jaeger" yaml:"jaeger"`
+	Redis  RedisConfig        `mapstructure:"redis" yaml:"redis"`
+	Worker WorkerConfig       `mapstructure:"worker" yaml:"worker"`
+}
+
+type RedisConfig struct {
+	Addresses     []string      `mapstructure:"addresses"`
+	Username      string        `mapstructure:"username"`
+	Password      string        `mapstructure:"password"`
+	Database      int           `mapstructure:"database"`
+	PoolSize      int           `mapstructure:"poolSize"`
+	MaxRetries    *int          `mapstructure:"maxRetries"` // 未配置时使用客户端默认值，0 表示不重试
+	DialTimeout   time.Duration `mapstructure:"dialTimeout"`
+	ReadTimeout   time.Duration `mapstructure:"readTimeout"`
+	WriteTimeout  time.Duration `mapstructure:"writeTimeout"`
+	EnableMetrics bool          `mapstructure:"enableMetrics"`
+	EnableTracing bool          `mapstructure:"enableTracing"`
+}
+
+type WorkerConfig struct {
+	Queues            []string      `mapstructure:"queues"`
+	Concurrency       int           `mapstructure:"concurrency"`
+	PollInterval      time.Duration `mapstructure:"pollInterval"`
+	VisibilityTimeout time.Duration `mapstructure:"visibilityTimeout"`
+	JobTimeout        time.Duration `mapstructure:"jobTimeout"`
+	MaxRetries        *int          `mapstructure:"maxRetries"` // 未配置时使用队列默认值，0 表示不重试
+	ShutdownTimeout   time.Duration `mapstructure:"shutdownTimeout"`
 }
 
 type ELKConfig struct {
@@ -242,4 +242,16 @@ const (
 	RateLimitConfigError = "3601" // 限流配置错误
 	RateLimitInitError   = "3602" // 限流初始化错误
 	RateLimitStoreError  = "3603" // 限流存储错误
+
+	// 分布式锁相关错误码 (3700-3799)
+	LockNotAcquired  = "3700" // 锁已被其他持有者占用
+	LockNotHeld      = "3701" // 锁未被当前持有者持有
+	LockTimeout      = "3702" // 获取锁超时
+	LockQuorumFailed = "3703" // 未能获得多数节点确认
+
+	// 配额相关错误码 (3800-3899)
+	QuotaExceeded      = "3800" // 超出配额
+	QuotaNotConfigured = "3801" // 租户未配置配额
+	QuotaConfigError   = "3802" // 配额配置错误
+	QuotaStoreError    = "3803" // 配额存储错误
 )
@@ -0,0 +1,27 @@
+package errors
+
+import (
+	"gobase/pkg/errors/codes"
+)
+
+// 分布式锁相关错误 (3700-3799)
+
+// NewLockNotAcquiredError 创建锁已被占用错误
+func NewLockNotAcquiredError(message string, cause error) error {
+	return NewError(codes.LockNotAcquired, message, cause)
+}
+
+// NewLockNotHeldError 创建锁未被持有错误
+func NewLockNotHeldError(message string, cause error) error {
+	return NewError(codes.LockNotHeld, message, cause)
+}
+
+// NewLockTimeoutError 创建获取锁超时错误
+func NewLockTimeoutError(message string, cause error) error {
+	return NewError(codes.LockTimeout, message, cause)
+}
+
+// NewLockQuorumFailedError 创建多数节点确认失败错误
+func NewLockQuorumFailedError(message string, cause error) error {
+	return NewError(codes.LockQuorumFailed, message, cause)
+}
@@ -0,0 +1,27 @@
+package errors
+
+import (
+	"gobase/pkg/errors/codes"
+)
+
+// 配额相关错误 (3800-3899)
+
+// NewQuotaExceededError 创建超出配额错误
+func NewQuotaExceededError(message string, cause error) error {
+	return NewError(codes.QuotaExceeded, message, cause)
+}
+
+// NewQuotaNotConfiguredError 创建租户未配置配额错误
+func NewQuotaNotConfiguredError(message string, cause error) error {
+	return NewError(codes.QuotaNotConfigured, message, cause)
+}
+
+// NewQuotaConfigError 创建配额配置错误
+func NewQuotaConfigError(message string, cause error) error {
+	return NewError(codes.QuotaConfigError, message, cause)
+}
+
+// NewQuotaStoreError 创建配额存储错误
+func NewQuotaStoreError(message string, cause error) error {
+	return NewError(codes.QuotaStoreError, message, cause)
+}
@@ -0,0 +1,40 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+// Locker 分布式锁接口
+type Locker interface {
+	// Lock 阻塞获取锁，直到成功、ctx结束或等待超时
+	Lock(ctx context.Context, key string) (Lock, error)
+
+	// TryLock 尝试获取一次锁，锁被占用时返回 LockNotAcquired 错误
+	TryLock(ctx context.Context, key string) (Lock, error)
+}
+
+// Lock 已获取的锁
+type Lock interface {
+	// Key 返回锁的名称
+	Key() string
+
+	// Token 返回持有者的唯一令牌
+	Token() string
+
+	// FencingToken 返回单调递增的栅栏令牌，Redlock 模式下不提供，返回 0
+	// 下游存储应拒绝携带比已见过的更小栅栏令牌的写入
+	FencingToken() int64
+
+	// TTL 返回锁的剩余有效时间
+	TTL(ctx context.Context) (time.Duration, error)
+
+	// Refresh 延长锁的有效时间
+	Refresh(ctx context.Context, ttl time.Duration) error
+
+	// Unlock 释放锁，只有持有者才能释放
+	Unlock(ctx context.Context) error
+
+	// Lost 返回一个通道，自动续期失败导致锁丢失时关闭
+	Lost() <-chan struct{}
+}
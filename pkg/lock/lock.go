@@ -0,0 +1,194 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+)
+
+// redisLock 已获取的Redis锁
+type redisLock struct {
+	locker  *redisLocker
+	key     string
+	lockKey string
+	token   string
+	fence   int64
+
+	lost     chan struct{}
+	lostOnce sync.Once
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newRedisLock(locker *redisLocker, key, lockKey, token string, fence int64) *redisLock {
+	return &redisLock{
+		locker:  locker,
+		key:     key,
+		lockKey: lockKey,
+		token:   token,
+		fence:   fence,
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Key 返回锁的名称
+func (lk *redisLock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者令牌
+func (lk *redisLock) Token() string {
+	return lk.token
+}
+
+// FencingToken 返回栅栏令牌
+func (lk *redisLock) FencingToken() int64 {
+	return lk.fence
+}
+
+// Lost 返回锁丢失通知通道
+func (lk *redisLock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// TTL 返回锁的剩余有效时间
+func (lk *redisLock) TTL(ctx context.Context) (time.Duration, error) {
+	results := lk.locker.evalAll(ctx, ttlScript, []string{lk.lockKey}, lk.token)
+
+	var (
+		held    int
+		minTTL  int64 = -1
+		lastErr error
+	)
+	for _, r := range results {
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		if r.value >= 0 {
+			held++
+			if minTTL < 0 || r.value < minTTL {
+				minTTL = r.value
+			}
+		}
+	}
+
+	if held >= lk.locker.quorum {
+		return time.Duration(minTTL) * time.Millisecond, nil
+	}
+	if lastErr != nil {
+		return 0, errors.NewRedisLockError("failed to get lock ttl", lastErr)
+	}
+	return 0, errors.NewLockNotHeldError("lock is not held", nil)
+}
+
+// Refresh 延长锁的有效时间
+func (lk *redisLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.NewInvalidParamsError("lock ttl must be positive", nil)
+	}
+	return lk.quorumCall(ctx, refreshScript, "refresh", ttl.Milliseconds())
+}
+
+// Unlock 释放锁
+func (lk *redisLock) Unlock(ctx context.Context) error {
+	lk.stopWatchdog()
+	return lk.quorumCall(ctx, releaseScript, "release")
+}
+
+// quorumCall 在所有节点上执行比较令牌的脚本，多数节点成功时返回nil
+func (lk *redisLock) quorumCall(ctx context.Context, script, operation string, args ...interface{}) error {
+	results := lk.locker.evalAll(ctx, script, []string{lk.lockKey}, append([]interface{}{lk.token}, args...)...)
+
+	var (
+		succeeded int
+		lastErr   error
+	)
+	for _, r := range results {
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		if r.value == 1 {
+			succeeded++
+		}
+	}
+
+	if succeeded >= lk.locker.quorum {
+		return nil
+	}
+	if lastErr != nil {
+		return errors.NewRedisLockError("failed to "+operation+" lock", lastErr)
+	}
+	return errors.NewLockNotHeldError("lock is not held by current owner", nil)
+}
+
+// startWatchdog 启动看门狗，定期续期直到释放或续期失败
+func (lk *redisLock) startWatchdog() {
+	lk.done = make(chan struct{})
+	go lk.watch()
+}
+
+func (lk *redisLock) watch() {
+	defer close(lk.done)
+
+	opts := lk.locker.opts
+	ticker := time.NewTicker(opts.RenewInterval)
+	defer ticker.Stop()
+
+	lastRenew := time.Now()
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), opts.RenewInterval)
+			err := lk.Refresh(ctx, opts.TTL)
+			cancel()
+
+			if err == nil {
+				lastRenew = time.Now()
+				continue
+			}
+
+			// 锁已被他人持有，或连续续期失败直到锁过期，视为丢失
+			if errors.HasErrorCode(err, codes.LockNotHeld) || time.Since(lastRenew) >= opts.TTL {
+				opts.Logger.Error(context.Background(), "distributed lock lost",
+					types.Field{Key: "key", Value: lk.key},
+					types.Error(err),
+				)
+				lk.markLost()
+				return
+			}
+
+			opts.Logger.Warn(context.Background(), "failed to renew distributed lock",
+				types.Field{Key: "key", Value: lk.key},
+				types.Error(err),
+			)
+		}
+	}
+}
+
+// stopWatchdog 停止看门狗并等待其退出
+func (lk *redisLock) stopWatchdog() {
+	lk.stopOnce.Do(func() {
+		close(lk.stop)
+	})
+	if lk.done != nil {
+		<-lk.done
+	}
+}
+
+// markLost 标记锁已丢失
+func (lk *redisLock) markLost() {
+	lk.lostOnce.Do(func() {
+		close(lk.lost)
+	})
+}
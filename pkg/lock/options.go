@@ -0,0 +1,146 @@
+package lock
+
+import (
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// Option 定义选项函数类型
+type Option func(*Options)
+
+// Options 分布式锁配置
+type Options struct {
+	// KeyPrefix 锁键前缀
+	KeyPrefix string
+
+	// TTL 锁的有效时间
+	TTL time.Duration
+
+	// RetryInterval 阻塞获取时的重试间隔
+	RetryInterval time.Duration
+
+	// WaitTimeout 阻塞获取的最长等待时间，ctx 未设置截止时间时生效，0 表示一直等待
+	WaitTimeout time.Duration
+
+	// AutoRenew 是否启用看门狗自动续期
+	AutoRenew bool
+
+	// RenewInterval 续期间隔，默认为 TTL 的三分之一
+	RenewInterval time.Duration
+
+	// DriftFactor 时钟漂移系数，仅用于计算锁的有效期
+	DriftFactor float64
+
+	// NodeTimeout 单个节点操作超时时间
+	NodeTimeout time.Duration
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		KeyPrefix:     "lock:",
+		TTL:           30 * time.Second,
+		RetryInterval: 100 * time.Millisecond,
+		AutoRenew:     true,
+		DriftFactor:   0.01,
+		NodeTimeout:   500 * time.Millisecond,
+		Logger:        &types.NoopLogger{},
+	}
+}
+
+// WithKeyPrefix 设置锁键前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = prefix
+	}
+}
+
+// WithTTL 设置锁的有效时间
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithRetryInterval 设置重试间隔
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithWaitTimeout 设置阻塞获取的最长等待时间
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTimeout = timeout
+	}
+}
+
+// WithAutoRenew 设置是否自动续期
+func WithAutoRenew(enabled bool) Option {
+	return func(o *Options) {
+		o.AutoRenew = enabled
+	}
+}
+
+// WithRenewInterval 设置续期间隔
+func WithRenewInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RenewInterval = interval
+	}
+}
+
+// WithDriftFactor 设置时钟漂移系数
+func WithDriftFactor(factor float64) Option {
+	return func(o *Options) {
+		o.DriftFactor = factor
+	}
+}
+
+// WithNodeTimeout 设置单个节点操作超时时间
+func WithNodeTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.NodeTimeout = timeout
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 验证配置
+func (o *Options) Validate() error {
+	if o.TTL <= 0 {
+		return errors.NewInvalidParamsError("lock ttl must be positive", nil)
+	}
+	if o.RetryInterval <= 0 {
+		return errors.NewInvalidParamsError("retry interval must be positive", nil)
+	}
+	if o.WaitTimeout < 0 {
+		return errors.NewInvalidParamsError("wait timeout cannot be negative", nil)
+	}
+	if o.DriftFactor < 0 || o.DriftFactor >= 1 {
+		return errors.NewInvalidParamsError("drift factor must be in [0, 1)", nil)
+	}
+	if o.RenewInterval <= 0 {
+		o.RenewInterval = o.TTL / 3
+	}
+	if o.RenewInterval >= o.TTL {
+		return errors.NewInvalidParamsError("renew interval must be less than ttl", nil)
+	}
+	if o.NodeTimeout <= 0 {
+		o.NodeTimeout = o.TTL
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
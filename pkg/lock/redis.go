@@ -0,0 +1,216 @@
+package lock
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// redisLocker 基于Redis的分布式锁实现
+// 单节点模式下 quorum 为 1，Redlock 模式下需要多数节点确认
+type redisLocker struct {
+	nodes  []redis.Client
+	quorum int
+	opts   *Options
+
+	// fencing 是否生成栅栏令牌，只有单节点模式下计数器才是单调递增的
+	fencing bool
+}
+
+// NewLocker 创建单节点Redis分布式锁
+func NewLocker(client redis.Client, opts ...Option) (Locker, error) {
+	if client == nil {
+		return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+	}
+	return newRedisLocker([]redis.Client{client}, opts...)
+}
+
+// NewRedlock 创建基于多个独立Redis节点的Redlock分布式锁
+// 只有在多数节点上获取成功且剩余有效期大于0时才视为获取成功
+// 各节点的计数器无法保证单调递增，Redlock 模式下不提供栅栏令牌，FencingToken 返回 0
+func NewRedlock(clients []redis.Client, opts ...Option) (Locker, error) {
+	if len(clients) == 0 {
+		return nil, errors.NewInvalidParamsError("at least one redis client is required", nil)
+	}
+	for _, c := range clients {
+		if c == nil {
+			return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+		}
+	}
+	return newRedisLocker(clients, opts...)
+}
+
+func newRedisLocker(nodes []redis.Client, opts ...Option) (*redisLocker, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &redisLocker{
+		nodes:   nodes,
+		quorum:  len(nodes)/2 + 1,
+		opts:    options,
+		fencing: len(nodes) == 1,
+	}, nil
+}
+
+// TryLock 尝试获取一次锁
+func (l *redisLocker) TryLock(ctx context.Context, key string) (Lock, error) {
+	if key == "" {
+		return nil, errors.NewInvalidParamsError("lock key is required", nil)
+	}
+	return l.acquire(ctx, key, uuid.New().String())
+}
+
+// Lock 阻塞获取锁
+func (l *redisLocker) Lock(ctx context.Context, key string) (Lock, error) {
+	if key == "" {
+		return nil, errors.NewInvalidParamsError("lock key is required", nil)
+	}
+
+	// ctx 未设置截止时间时使用配置的等待超时
+	if _, ok := ctx.Deadline(); !ok && l.opts.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.WaitTimeout)
+		defer cancel()
+	}
+
+	// 重试期间使用同一个令牌，上次失败后未能释放的残留锁仍属于自己，获取脚本会直接覆盖
+	token := uuid.New().String()
+	for {
+		lk, err := l.acquire(ctx, key, token)
+		if err == nil {
+			return lk, nil
+		}
+		if !errors.HasErrorCode(err, codes.LockNotAcquired) &&
+			!errors.HasErrorCode(err, codes.LockQuorumFailed) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(l.retryDelay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.NewLockTimeoutError("timed out waiting for lock", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// acquire 在所有节点上获取锁
+func (l *redisLocker) acquire(ctx context.Context, key, token string) (*redisLock, error) {
+	lockKey, fenceKey := l.keys(key)
+	ttl := l.opts.TTL
+	start := time.Now()
+
+	fencing := 0
+	if l.fencing {
+		fencing = 1
+	}
+	results := l.evalAll(ctx, acquireScript, []string{lockKey, fenceKey}, token, ttl.Milliseconds(), fencing)
+
+	var (
+		succeeded int
+		fence     int64
+		lastErr   error
+	)
+	for _, r := range results {
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		if r.value > 0 {
+			succeeded++
+			fence = r.value
+		}
+	}
+
+	// 扣除获取耗时和时钟漂移后的剩余有效期
+	drift := time.Duration(float64(ttl)*l.opts.DriftFactor) + 2*time.Millisecond
+	validity := ttl - time.Since(start) - drift
+
+	if succeeded >= l.quorum && validity > 0 {
+		if !l.fencing {
+			fence = 0
+		}
+		lk := newRedisLock(l, key, lockKey, token, fence)
+		if l.opts.AutoRenew {
+			lk.startWatchdog()
+		}
+		return lk, nil
+	}
+
+	// 获取失败时释放已在部分节点上获取的锁
+	if succeeded > 0 {
+		l.evalAll(context.WithoutCancel(ctx), releaseScript, []string{lockKey}, token)
+	}
+
+	switch {
+	case len(l.nodes) == 1 && lastErr != nil:
+		return nil, errors.NewRedisLockError("failed to acquire lock", lastErr)
+	case succeeded >= l.quorum:
+		return nil, errors.NewLockQuorumFailedError("lock validity expired during acquisition", nil)
+	case lastErr != nil:
+		return nil, errors.NewLockQuorumFailedError("failed to acquire lock on a quorum of nodes", lastErr)
+	default:
+		return nil, errors.NewLockNotAcquiredError("lock is held by another owner", nil)
+	}
+}
+
+// keys 返回锁键和栅栏计数键，使用 hash tag 保证两者落在同一个槽
+func (l *redisLocker) keys(key string) (string, string) {
+	lockKey := l.opts.KeyPrefix + "{" + key + "}"
+	return lockKey, lockKey + ":fence"
+}
+
+// retryDelay 返回带随机抖动的重试间隔，避免多个等待者同时重试
+func (l *redisLocker) retryDelay() time.Duration {
+	interval := l.opts.RetryInterval
+	return interval + time.Duration(rand.Int63n(int64(interval)/2+1))
+}
+
+// nodeResult 单个节点的脚本执行结果
+type nodeResult struct {
+	value int64
+	err   error
+}
+
+// evalAll 并发在所有节点上执行脚本
+func (l *redisLocker) evalAll(ctx context.Context, script string, keys []string, args ...interface{}) []nodeResult {
+	results := make([]nodeResult, len(l.nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range l.nodes {
+		wg.Add(1)
+		go func(i int, node redis.Client) {
+			defer wg.Done()
+			nodeCtx, cancel := context.WithTimeout(ctx, l.opts.NodeTimeout)
+			defer cancel()
+
+			result, err := node.Eval(nodeCtx, script, keys, args...)
+			if err != nil {
+				results[i] = nodeResult{err: err}
+				return
+			}
+			value, ok := result.(int64)
+			if !ok {
+				results[i] = nodeResult{err: errors.NewRedisScriptError("unexpected lock script result", nil)}
+				return
+			}
+			results[i] = nodeResult{value: value}
+		}(i, node)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,49 @@
+package lock
+
+// 所有脚本都只操作同一个 hash tag 下的键，可在集群模式下执行
+
+// acquireScript 获取锁，锁已属于同一持有者令牌时重新设置有效时间
+// KEYS[1]: 锁键 KEYS[2]: 栅栏计数键
+// ARGV[1]: 持有者令牌 ARGV[2]: 有效时间(毫秒) ARGV[3]: 是否生成栅栏令牌(1/0)
+// 返回: 成功时返回栅栏令牌(不生成时返回 1)，锁被占用时返回 0
+const acquireScript = `
+local owner = redis.call('GET', KEYS[1])
+if owner and owner ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+if ARGV[3] == '1' then
+	return redis.call('INCR', KEYS[2])
+end
+return 1
+`
+
+// releaseScript 比较持有者令牌后删除锁
+// KEYS[1]: 锁键 ARGV[1]: 持有者令牌
+// 返回: 1 释放成功，0 锁不属于当前持有者
+const releaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// refreshScript 比较持有者令牌后延长有效时间
+// KEYS[1]: 锁键 ARGV[1]: 持有者令牌 ARGV[2]: 有效时间(毫秒)
+// 返回: 1 续期成功，0 锁不属于当前持有者
+const refreshScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// ttlScript 比较持有者令牌后返回剩余有效时间
+// KEYS[1]: 锁键 ARGV[1]: 持有者令牌
+// 返回: 剩余毫秒数，锁不属于当前持有者时返回 -1
+const ttlScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PTTL', KEYS[1])
+end
+return -1
+`
@@ -0,0 +1,212 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/lock"
+)
+
+// newTestClient 创建连接到 miniredis 的客户端
+func newTestClient(t *testing.T) (*miniredis.Miniredis, redis.Client) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return mr, client
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("try lock and unlock", func(t *testing.T) {
+		_, client := newTestClient(t)
+		locker, err := lock.NewLocker(client, lock.WithAutoRenew(false))
+		require.NoError(t, err)
+
+		lk, err := locker.TryLock(ctx, "job")
+		require.NoError(t, err)
+		assert.Equal(t, "job", lk.Key())
+		assert.NotEmpty(t, lk.Token())
+
+		_, err = locker.TryLock(ctx, "job")
+		assert.True(t, errors.HasErrorCode(err, codes.LockNotAcquired))
+
+		require.NoError(t, lk.Unlock(ctx))
+
+		lk2, err := locker.TryLock(ctx, "job")
+		require.NoError(t, err)
+		assert.NoError(t, lk2.Unlock(ctx))
+	})
+
+	t.Run("fencing token increases", func(t *testing.T) {
+		_, client := newTestClient(t)
+		locker, err := lock.NewLocker(client, lock.WithAutoRenew(false))
+		require.NoError(t, err)
+
+		var last int64
+		for i := 0; i < 3; i++ {
+			lk, err := locker.TryLock(ctx, "fence")
+			require.NoError(t, err)
+			assert.Greater(t, lk.FencingToken(), last)
+			last = lk.FencingToken()
+			require.NoError(t, lk.Unlock(ctx))
+		}
+	})
+
+	t.Run("expired holder cannot release new owner's lock", func(t *testing.T) {
+		mr, client := newTestClient(t)
+		locker, err := lock.NewLocker(client, lock.WithAutoRenew(false), lock.WithTTL(time.Second))
+		require.NoError(t, err)
+
+		slow, err := locker.TryLock(ctx, "owner")
+		require.NoError(t, err)
+
+		mr.FastForward(2 * time.Second)
+
+		fast, err := locker.TryLock(ctx, "owner")
+		require.NoError(t, err)
+		assert.Greater(t, fast.FencingToken(), slow.FencingToken())
+
+		err = slow.Unlock(ctx)
+		assert.True(t, errors.HasErrorCode(err, codes.LockNotHeld))
+
+		err = slow.Refresh(ctx, time.Second)
+		assert.True(t, errors.HasErrorCode(err, codes.LockNotHeld))
+
+		ttl, err := fast.TTL(ctx)
+		require.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+		assert.NoError(t, fast.Unlock(ctx))
+	})
+
+	t.Run("blocking lock honours deadline", func(t *testing.T) {
+		_, client := newTestClient(t)
+		locker, err := lock.NewLocker(client,
+			lock.WithAutoRenew(false),
+			lock.WithRetryInterval(10*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		held, err := locker.TryLock(ctx, "blocking")
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = locker.Lock(waitCtx, "blocking")
+		assert.True(t, errors.HasErrorCode(err, codes.LockTimeout))
+
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			_ = held.Unlock(ctx)
+		}()
+
+		waitCtx2, cancel2 := context.WithTimeout(ctx, time.Second)
+		defer cancel2()
+		lk, err := locker.Lock(waitCtx2, "blocking")
+		require.NoError(t, err)
+		assert.NoError(t, lk.Unlock(ctx))
+	})
+
+	t.Run("watchdog renews lock", func(t *testing.T) {
+		mr, client := newTestClient(t)
+		locker, err := lock.NewLocker(client,
+			lock.WithTTL(300*time.Millisecond),
+			lock.WithRenewInterval(20*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		lk, err := locker.TryLock(ctx, "renew")
+		require.NoError(t, err)
+		defer lk.Unlock(ctx)
+
+		mr.FastForward(250 * time.Millisecond)
+		time.Sleep(60 * time.Millisecond)
+
+		assert.Greater(t, mr.TTL("lock:{renew}"), 200*time.Millisecond)
+	})
+
+	t.Run("watchdog reports lost lock", func(t *testing.T) {
+		mr, client := newTestClient(t)
+		locker, err := lock.NewLocker(client,
+			lock.WithTTL(300*time.Millisecond),
+			lock.WithRenewInterval(20*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		lk, err := locker.TryLock(ctx, "lost")
+		require.NoError(t, err)
+
+		mr.Del("lock:{lost}")
+
+		select {
+		case <-lk.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("expected lock to be reported as lost")
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, client := newTestClient(t)
+		_, err := lock.NewLocker(client, lock.WithTTL(0))
+		assert.Error(t, err)
+
+		_, err = lock.NewLocker(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+
+	servers := make([]*miniredis.Miniredis, 3)
+	clients := make([]redis.Client, 3)
+	for i := range servers {
+		servers[i], clients[i] = newTestClient(t)
+	}
+
+	locker, err := lock.NewRedlock(clients, lock.WithAutoRenew(false), lock.WithNodeTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+
+	t.Run("acquire on quorum", func(t *testing.T) {
+		lk, err := locker.TryLock(ctx, "quorum")
+		require.NoError(t, err)
+		// 各节点计数器不能保证单调，Redlock 不提供栅栏令牌
+		assert.Zero(t, lk.FencingToken())
+
+		_, err = locker.TryLock(ctx, "quorum")
+		assert.True(t, errors.HasErrorCode(err, codes.LockNotAcquired))
+
+		require.NoError(t, lk.Unlock(ctx))
+	})
+
+	t.Run("minority node down", func(t *testing.T) {
+		servers[0].Close()
+
+		lk, err := locker.TryLock(ctx, "degraded")
+		require.NoError(t, err)
+		assert.NoError(t, lk.Unlock(ctx))
+	})
+
+	t.Run("majority nodes down", func(t *testing.T) {
+		servers[1].Close()
+
+		_, err := locker.TryLock(ctx, "down")
+		assert.True(t, errors.HasErrorCode(err, codes.LockQuorumFailed))
+	})
+}
@@ -15,6 +15,17 @@ func GetClaims(ctx context.Context) (jwt.Claims, error) {
 	return nil, errors.NewClaimsMissingError("claims not found in context", nil)
 }
 
+// GetClaimsAs 从上下文中获取指定类型的JWT Claims，用于应用自定义的claims结构
+// 例如 GetClaimsAs[*TenantClaims](ctx)
+func GetClaimsAs[C jwt.Claims](ctx context.Context) (C, error) {
+	claims, err := GetClaims(ctx)
+	if err != nil {
+		var zero C
+		return zero, err
+	}
+	return jwt.ClaimsAs[C](claims)
+}
+
 // GetToken 从上下文中获取JWT Token
 func GetToken(ctx context.Context) (string, error) {
 	if token, ok := ctx.Value(TokenKey).(string); ok {
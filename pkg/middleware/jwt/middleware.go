@@ -5,6 +5,7 @@ import (
 
 	"gobase/pkg/auth/jwt"
 	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
 	"gobase/pkg/logger"
 	"gobase/pkg/logger/types"
 	"gobase/pkg/middleware/jwt/context"
@@ -75,12 +76,16 @@ func (m *Middleware) Handle() gin.HandlerFunc {
 		token, err := m.tokenManager.ValidateToken(c.Request.Context(), tokenStr)
 		if err != nil {
 			IncTokenValidationError("validation_failed")
-			m.handleError(c, errors.NewTokenInvalidError("failed to validate token", err))
+			// 保留 TokenManager 返回的错误码，客户端据此区分过期、声明无效等原因
+			if errors.GetErrorCode(err) == codes.SystemError {
+				err = errors.NewTokenInvalidError("failed to validate token", err)
+			}
+			m.handleError(c, err)
 			return
 		}
 
-		// 转换claims类型
-		claims, ok := token.Claims.(*jwt.StandardClaims)
+		// 转换claims类型，具体类型由 TokenManager 的 claims 工厂决定
+		claims, ok := token.Claims.(jwt.Claims)
 		if !ok {
 			IncTokenValidationError("invalid_claims_type")
 			m.handleError(c, errors.NewClaimsInvalidError("invalid claims type", nil))
@@ -126,6 +131,11 @@ func (m *Middleware) handleError(c *gin.Context, err error) {
 			"code":    errors.GetErrorCode(err),
 			"message": "token has expired",
 		})
+	case errors.HasErrorCode(err, codes.ClaimsInvalid) || errors.HasErrorCode(err, codes.ClaimsMissing):
+		c.AbortWithStatusJSON(401, gin.H{
+			"code":    errors.GetErrorCode(err),
+			"message": "invalid token claims",
+		})
 	case errors.IsTokenInvalidError(err):
 		c.AbortWithStatusJSON(401, gin.H{
 			"code":    errors.GetErrorCode(err),
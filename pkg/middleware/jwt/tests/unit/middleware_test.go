@@ -2,17 +2,22 @@ package unit
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors/codes"
 	jwtmw "gobase/pkg/middleware/jwt"
+	jwtContext "gobase/pkg/middleware/jwt/context"
+	"gobase/pkg/middleware/jwt/validator"
 )
 
 func TestNew(t *testing.T) {
@@ -121,3 +126,115 @@ func TestMiddleware_Handle(t *testing.T) {
 		})
 	}
 }
+
+// tenantClaims 应用自定义的claims
+type tenantClaims struct {
+	jwt.StandardClaims
+	TenantID string `json:"tenant_id"`
+}
+
+func TestMiddleware_CustomClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokenManager, err := jwt.NewTokenManager("test-secret",
+		jwt.WithClaimsFactory(func() jwt.Claims { return &tenantClaims{} }),
+	)
+	require.NoError(t, err)
+
+	claims := &tenantClaims{
+		StandardClaims: *jwt.NewStandardClaims(
+			jwt.WithUserID("test-user"),
+			jwt.WithTokenType(jwt.AccessToken),
+			jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+		),
+		TenantID: "tenant-1",
+	}
+	token, err := tokenManager.GenerateToken(context.Background(), claims)
+	require.NoError(t, err)
+
+	m, err := jwtmw.New(tokenManager, jwtmw.WithValidator(validator.NewClaimsValidator()))
+	require.NoError(t, err)
+
+	var tenantID, userID string
+	router := gin.New()
+	router.Use(m.Handle())
+	router.GET("/test", func(c *gin.Context) {
+		// 处理函数取得自定义claims
+		typed, err := jwtContext.GetClaimsAs[*tenantClaims](c.Request.Context())
+		require.NoError(t, err)
+		tenantID = typed.TenantID
+		userID, _ = jwtContext.GetUserID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "tenant-1", tenantID)
+	assert.Equal(t, "test-user", userID)
+}
+
+func TestMiddleware_ValidationErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	verifier, err := jwt.NewTokenManager("test-secret", jwt.WithIssuers("auth-service"))
+	require.NoError(t, err)
+
+	m, err := jwtmw.New(verifier)
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(m.Handle())
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		claims   *jwt.StandardClaims
+		wantCode string
+	}{
+		{
+			name: "签发者不被接受",
+			claims: jwt.NewStandardClaims(
+				jwt.WithUserID("test-user"),
+				jwt.WithTokenType(jwt.AccessToken),
+				jwt.WithIssuer("other-service"),
+				jwt.WithExpiresAt(time.Now().Add(time.Hour)),
+			),
+			wantCode: codes.ClaimsInvalid,
+		},
+		{
+			name: "令牌已过期",
+			claims: &jwt.StandardClaims{
+				RegisteredClaims: jwtlib.RegisteredClaims{
+					Issuer:    "auth-service",
+					ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(-time.Minute)),
+				},
+				UserID:    "test-user",
+				TokenType: jwt.AccessToken,
+			},
+			wantCode: codes.TokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GenerateToken 拒绝签发已过期的令牌，直接签名
+			token, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, tt.claims).SignedString([]byte("test-secret"))
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body["code"])
+		})
+	}
+}
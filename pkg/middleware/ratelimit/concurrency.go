@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// 并发限流范围
+const (
+	ConcurrencyScopeKey    = "key"
+	ConcurrencyScopeGlobal = "global"
+)
+
+// ConcurrencyConfig 并发限流中间件配置
+type ConcurrencyConfig struct {
+	// Limiter 并发限流器实例
+	Limiter core.ConcurrencyLimiter
+	// Name 限流名称，用于生成限流键和指标标签
+	Name string
+	// KeyFunc 限流键生成函数，默认使用客户端IP
+	KeyFunc func(*gin.Context) string
+	// Limit 每个键的最大在途请求数，为0时不限制
+	Limit int64
+	// GlobalLimit 所有键合计的最大在途请求数，为0时不限制
+	GlobalLimit int64
+	// KeyPrefix 限流键前缀
+	KeyPrefix string
+	// WaitTimeout 获取许可的最长排队时间，为0时超过上限立即拒绝
+	WaitTimeout time.Duration
+	// OutcomeFunc 根据响应判断请求处理结果，反馈给自适应限流器，默认 5xx 视为失败
+	OutcomeFunc func(*gin.Context) core.Outcome
+	// 响应格式化器
+	Formatter ResponseFormatter
+	// 自定义错误消息
+	Messages *ErrorMessages
+	// 自定义状态码
+	Status *StatusCodes
+}
+
+// DefaultConcurrencyConfig 默认并发限流配置
+func DefaultConcurrencyConfig() *ConcurrencyConfig {
+	defaults := DefaultConfig()
+	return &ConcurrencyConfig{
+		Name:      "default",
+		KeyFunc:   defaults.KeyFunc,
+		KeyPrefix: "concurrency:",
+		OutcomeFunc: func(c *gin.Context) core.Outcome {
+			if c.Writer.Status() >= http.StatusInternalServerError {
+				return core.OutcomeDropped
+			}
+			return core.OutcomeSuccess
+		},
+		Formatter: defaults.Formatter,
+		Messages:  defaults.Messages,
+		Status:    defaults.Status,
+		// Limiter 必须由用户提供，因为它需要依赖外部存储
+	}
+}
+
+// ConcurrencyLimit 创建并发限流中间件
+// 请求进入时获取许可(先按键再全局)，处理完成后释放
+func ConcurrencyLimit(cfg *ConcurrencyConfig) gin.HandlerFunc {
+	if cfg == nil || cfg.Limiter == nil {
+		panic("concurrency middleware requires a limiter instance")
+	}
+	if cfg.Limit <= 0 && cfg.GlobalLimit <= 0 {
+		panic("concurrency middleware requires limit or global limit")
+	}
+
+	defaults := DefaultConcurrencyConfig()
+	if cfg.Name == "" {
+		cfg.Name = defaults.Name
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = defaults.KeyFunc
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = defaults.KeyPrefix
+	}
+	if cfg.OutcomeFunc == nil {
+		cfg.OutcomeFunc = defaults.OutcomeFunc
+	}
+	if cfg.Formatter == nil {
+		cfg.Formatter = defaults.Formatter
+	}
+	if cfg.Messages == nil {
+		cfg.Messages = defaults.Messages
+	}
+	if cfg.Status == nil {
+		cfg.Status = defaults.Status
+	}
+
+	prefix := cfg.KeyPrefix + cfg.Name + ":"
+	globalKey := prefix + ConcurrencyScopeGlobal
+
+	return func(c *gin.Context) {
+		log := logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "middleware"},
+			types.Field{Key: "component", Value: "concurrency"},
+			types.Field{Key: "name", Value: cfg.Name},
+		)
+
+		ctx := c.Request.Context()
+		if cfg.WaitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.WaitTimeout)
+			defer cancel()
+		}
+
+		var leases []core.Lease
+		admitted := false
+		outcome := core.OutcomeIgnored
+		defer func() {
+			// 使用独立的 ctx 释放，避免请求取消导致许可未释放
+			releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			for i := len(leases) - 1; i >= 0; i-- {
+				if err := releaseLease(releaseCtx, leases[i], outcome); err != nil {
+					log.Error(c, "failed to release concurrency lease", types.Error(err))
+				}
+			}
+			if admitted {
+				metrics.Collector.AddInFlight(cfg.Name, -1)
+			}
+		}()
+
+		acquire := func(scope, key string, limit int64) bool {
+			start := time.Now()
+			lease, err := acquireLease(ctx, cfg.Limiter, key, limit, cfg.WaitTimeout > 0)
+			metrics.Collector.ObserveConcurrencyWait(cfg.Name, scope, time.Since(start).Seconds())
+
+			switch {
+			case err != nil && !errors.HasErrorCode(err, codes.TooManyRequests):
+				log.Error(c, "concurrency check failed",
+					types.Field{Key: "key", Value: key},
+					types.Error(err),
+				)
+				c.Error(errors.NewError(codes.RateLimitError, cfg.Messages.CheckFailedMessage, err))
+				c.AbortWithStatusJSON(cfg.Status.CheckFailed,
+					cfg.Formatter.FormatError(codes.RateLimitError, cfg.Messages.CheckFailedMessage))
+				return false
+			case lease == nil:
+				metrics.Collector.ObserveConcurrencyRejected(cfg.Name, scope)
+				c.Error(errors.NewError(codes.TooManyRequests, cfg.Messages.LimitExceededMessage, nil))
+				c.AbortWithStatusJSON(cfg.Status.LimitExceeded,
+					cfg.Formatter.FormatError(codes.TooManyRequests, cfg.Messages.LimitExceededMessage))
+				return false
+			}
+
+			leases = append(leases, lease)
+			return true
+		}
+
+		if cfg.Limit > 0 && !acquire(ConcurrencyScopeKey, prefix+cfg.KeyFunc(c), cfg.Limit) {
+			return
+		}
+		if cfg.GlobalLimit > 0 && !acquire(ConcurrencyScopeGlobal, globalKey, cfg.GlobalLimit) {
+			return
+		}
+
+		admitted = true
+		metrics.Collector.AddInFlight(cfg.Name, 1)
+
+		// 处理函数 panic 时按失败反馈
+		outcome = core.OutcomeDropped
+		c.Next()
+		outcome = cfg.OutcomeFunc(c)
+	}
+}
+
+// acquireLease 获取许可，超过上限时返回 nil
+func acquireLease(ctx context.Context, limiter core.ConcurrencyLimiter, key string, limit int64, wait bool) (core.Lease, error) {
+	if !wait {
+		lease, _, err := limiter.TryAcquire(ctx, key, limit)
+		return lease, err
+	}
+	return limiter.Acquire(ctx, key, limit)
+}
+
+// releaseLease 释放许可，许可支持结果反馈时同时反馈处理结果
+func releaseLease(ctx context.Context, lease core.Lease, outcome core.Outcome) error {
+	if fl, ok := lease.(core.FeedbackLease); ok {
+		return fl.Done(ctx, outcome)
+	}
+	return lease.Release(ctx)
+}
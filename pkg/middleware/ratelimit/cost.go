@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+)
+
+// reportedCostKey 处理函数上报的实际成本在 gin.Context 中的键
+const reportedCostKey = "ratelimit.reported_cost"
+
+// CostTable 按路由配置请求成本
+// 键为 "METHOD /path" 或 "/path"，路径使用 gin 的路由模板(c.FullPath())，带方法的配置优先
+type CostTable map[string]int64
+
+// CostFunc 返回按表查找成本的函数，未命中时返回 defaultCost
+func (t CostTable) CostFunc(defaultCost int64) func(*gin.Context) int64 {
+	return func(c *gin.Context) int64 {
+		path := c.FullPath()
+		if cost, ok := t[c.Request.Method+" "+path]; ok {
+			return cost
+		}
+		if cost, ok := t[path]; ok {
+			return cost
+		}
+		return defaultCost
+	}
+}
+
+// SetCost 由处理函数上报请求的实际成本，配合 ReportedCost 使用
+func SetCost(c *gin.Context, units int64) {
+	c.Set(reportedCostKey, units)
+}
+
+// ReportedCost 读取处理函数通过 SetCost 上报的实际成本，未上报时不结算
+func ReportedCost(c *gin.Context) (int64, bool) {
+	v, ok := c.Get(reportedCostKey)
+	if !ok {
+		return 0, false
+	}
+	units, ok := v.(int64)
+	return units, ok
+}
+
+// ResponseSizeCost 按响应体大小计算实际成本，每 bytesPerUnit 字节计为一个单位，不足一个单位按一个单位计
+func ResponseSizeCost(bytesPerUnit int64) func(*gin.Context) (int64, bool) {
+	if bytesPerUnit <= 0 {
+		panic("ratelimit response size cost requires a positive bytes per unit")
+	}
+	return func(c *gin.Context) (int64, bool) {
+		size := int64(c.Writer.Size())
+		if size <= 0 {
+			return 0, true
+		}
+		return (size + bytesPerUnit - 1) / bytesPerUnit, true
+	}
+}
+
+// requestCost 计算请求的预估成本，未配置 CostFunc 时为1
+func requestCost(c *gin.Context, cfg *Config) int64 {
+	if cfg.CostFunc == nil {
+		return 1
+	}
+	return cfg.CostFunc(c)
+}
+
+// settleCost 按实际成本与已扣减成本的差额退还或追加扣减
+// 请求已处理完成，结算失败只记录日志
+func settleCost(c *gin.Context, cfg *Config, log types.Logger, key string, charged, actual int64) {
+	delta := actual - charged
+	if delta == 0 {
+		return
+	}
+
+	limiter := cfg.Limiter.(core.SettleLimiter)
+	// 响应已写出，请求 ctx 可能随时被取消
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	var err error
+	if delta > 0 {
+		err = limiter.Charge(ctx, key, delta, cfg.Limit, cfg.Window)
+	} else {
+		err = limiter.Refund(ctx, key, -delta, cfg.Limit, cfg.Window)
+	}
+	if err != nil {
+		log.Warn(c, "failed to settle rate limit cost",
+			types.Field{Key: "charged", Value: charged},
+			types.Field{Key: "actual", Value: actual},
+			types.Error(err),
+		)
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/ratelimit/core"
+)
+
+// HeaderMode 限流响应头模式
+type HeaderMode int
+
+const (
+	// HeaderModeStandard 输出 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset，Reset 为距重置的秒数
+	HeaderModeStandard HeaderMode = iota
+	// HeaderModeLegacy 输出 X-RateLimit-Limit、X-RateLimit-Remaining、X-RateLimit-Reset，Reset 为重置时刻的 Unix 时间戳(秒)
+	HeaderModeLegacy
+	// HeaderModeNone 不输出限流响应头
+	HeaderModeNone
+)
+
+// 限流响应头名称
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+
+	HeaderXRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
+
+	HeaderRetryAfter = "Retry-After"
+)
+
+// writeHeaders 根据限流结果写入响应头，被拒绝时同时写入 Retry-After
+func writeHeaders(c *gin.Context, mode HeaderMode, result *core.Result) {
+	if result == nil || mode == HeaderModeNone {
+		return
+	}
+
+	limit := strconv.FormatInt(result.Limit, 10)
+	remaining := strconv.FormatInt(result.Remaining, 10)
+
+	switch mode {
+	case HeaderModeLegacy:
+		c.Header(HeaderXRateLimitLimit, limit)
+		c.Header(HeaderXRateLimitRemaining, remaining)
+		if !result.ResetAt.IsZero() {
+			reset := result.ResetAt.Unix()
+			if result.ResetAt.Nanosecond() > 0 {
+				reset++
+			}
+			c.Header(HeaderXRateLimitReset, strconv.FormatInt(reset, 10))
+		}
+	default:
+		c.Header(HeaderRateLimitLimit, limit)
+		c.Header(HeaderRateLimitRemaining, remaining)
+		if !result.ResetAt.IsZero() {
+			c.Header(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(time.Until(result.ResetAt)), 10))
+		}
+	}
+
+	if !result.Allowed && result.RetryAfter > 0 {
+		c.Header(HeaderRetryAfter, strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+	}
+}
+
+// ceilSeconds 将时长向上取整为秒，负数按0处理
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -12,61 +12,8 @@ import (
 	"gobase/pkg/errors/codes"
 	"gobase/pkg/logger"
 	"gobase/pkg/logger/types"
-	"gobase/pkg/monitor/prometheus/metric"
 	"gobase/pkg/ratelimit/core"
-)
-
-var (
-	// RequestsTotal 记录所有限流请求
-	RequestsTotal = metric.NewCounter(metric.CounterOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "requests_total",
-		Help:      "Total number of requests handled by rate limiter",
-	}).WithLabels("key", "result") // result: allowed/rejected/error
-
-	// RejectedTotal 记录被限流的请求
-	RejectedTotal = metric.NewCounter(metric.CounterOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "rejected_total",
-		Help:      "Total number of requests rejected by rate limiter",
-	}).WithLabels("key")
-
-	// LimiterLatency 记录限流器处理延迟
-	LimiterLatency = metric.NewHistogram(metric.HistogramOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "latency_seconds",
-		Help:      "Latency of rate limiter operations",
-		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-	}).WithLabels("key", "operation") // operation: allow/wait/total
-
-	// WaitDuration 记录等待时间分布
-	WaitDuration = metric.NewHistogram(metric.HistogramOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "wait_duration_seconds",
-		Help:      "Distribution of waiting time in wait mode",
-		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-	}).WithLabels("key")
-
-	// RetryCount 记录重试次数分布
-	RetryCount = metric.NewHistogram(metric.HistogramOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "retry_count",
-		Help:      "Distribution of retry attempts",
-		Buckets:   []float64{0, 1, 2, 3, 4, 5},
-	}).WithLabels("key", "result") // result: success/failure
-
-	// ErrorsTotal 记录错误类型分布
-	ErrorsTotal = metric.NewCounter(metric.CounterOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "errors_total",
-		Help:      "Total number of errors by type",
-	}).WithLabels("key", "type") // type: timeout/connection/other
+	"gobase/pkg/ratelimit/metrics"
 )
 
 // ResponseFormatter 定义响应格式化接口
@@ -102,6 +49,8 @@ type ErrorMessages struct {
 	CheckFailedMessage string
 	// 请求被限流时的错误消息
 	LimitExceededMessage string
+	// 请求命中黑名单时的错误消息，仅规则模式使用
+	BlacklistedMessage string
 }
 
 // StatusCodes 自定义状态码
@@ -110,6 +59,8 @@ type StatusCodes struct {
 	CheckFailed int
 	// 请求被限流时的状态码
 	LimitExceeded int
+	// 请求命中黑名单时的状态码，仅规则模式使用
+	Blacklisted int
 }
 
 // Config 限流中间件配置
@@ -134,6 +85,14 @@ type Config struct {
 	Messages *ErrorMessages
 	// 自定义状态码
 	Status *StatusCodes
+	// 限流响应头模式，限流器实现 core.ResultLimiter 时生效，默认输出标准 RateLimit-* 响应头
+	HeaderMode HeaderMode
+	// 请求成本(扣减的配额数)计算函数，为空时成本为1，返回值小于等于0时不扣减配额
+	// 等待模式下 Wait 只等待一个单位，其余成本通过 core.SettleLimiter 追加扣减
+	CostFunc func(*gin.Context) int64
+	// 请求处理完成后计算实际成本，返回 false 时不结算，需要限流器实现 core.SettleLimiter
+	// 实际成本与预扣成本的差额通过 Refund 退还或 Charge 追加扣减
+	SettleFunc func(*gin.Context) (int64, bool)
 }
 
 // DefaultConfig 默认配置
@@ -156,10 +115,12 @@ func DefaultConfig() *Config {
 		Messages: &ErrorMessages{
 			CheckFailedMessage:   "rate limit check failed",
 			LimitExceededMessage: "too many requests",
+			BlacklistedMessage:   "access denied",
 		},
 		Status: &StatusCodes{
 			CheckFailed:   http.StatusInternalServerError,
 			LimitExceeded: http.StatusTooManyRequests,
+			Blacklisted:   http.StatusForbidden,
 		},
 		// Limiter 必须由用户提供，因为它需要依赖外部存储
 	}
@@ -195,7 +156,7 @@ func doWithRetry(c *gin.Context, key string, strategy *RetryStrategy, operation
 		)
 
 		if err == nil && allowed {
-			RetryCount.WithLabelValues(key, "success").Observe(float64(attempts))
+			metrics.Collector.ObserveRetry(key, true, attempts)
 			log.Info(c, "rate limit operation succeeded after retries",
 				types.Field{Key: "attempts", Value: attempts},
 				types.Field{Key: "total_duration", Value: time.Since(start)},
@@ -208,13 +169,10 @@ func doWithRetry(c *gin.Context, key string, strategy *RetryStrategy, operation
 			errType := "other"
 			if err == context.DeadlineExceeded {
 				errType = "timeout"
-				ErrorsTotal.WithLabelValues(key, "timeout").Inc()
 			} else if strings.Contains(err.Error(), "connection") {
 				errType = "connection"
-				ErrorsTotal.WithLabelValues(key, "connection").Inc()
-			} else {
-				ErrorsTotal.WithLabelValues(key, "other").Inc()
 			}
+			metrics.Collector.ObserveError(key, errType)
 
 			log.Warn(c, "rate limit operation failed",
 				types.Field{Key: "attempt", Value: attempts},
@@ -242,7 +200,7 @@ func doWithRetry(c *gin.Context, key string, strategy *RetryStrategy, operation
 
 		select {
 		case <-c.Request.Context().Done():
-			RetryCount.WithLabelValues(key, "failure").Observe(float64(attempts))
+			metrics.Collector.ObserveRetry(key, false, attempts)
 			log.Warn(c, "rate limit operation cancelled",
 				types.Field{Key: "attempts", Value: attempts},
 				types.Field{Key: "total_duration", Value: time.Since(start)},
@@ -254,7 +212,7 @@ func doWithRetry(c *gin.Context, key string, strategy *RetryStrategy, operation
 		}
 	}
 
-	RetryCount.WithLabelValues(key, "failure").Observe(float64(attempts))
+	metrics.Collector.ObserveRetry(key, false, attempts)
 	log.Error(c, "rate limit operation failed after all retries",
 		types.Field{Key: "attempts", Value: attempts},
 		types.Field{Key: "total_duration", Value: time.Since(start)},
@@ -288,6 +246,15 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 		panic("ratelimit middleware requires a positive window")
 	}
 
+	// 结算和等待模式下的多单位成本依赖 core.SettleLimiter
+	_, canSettle := cfg.Limiter.(core.SettleLimiter)
+	if cfg.SettleFunc != nil && !canSettle {
+		panic("ratelimit middleware settlement requires a core.SettleLimiter")
+	}
+	if cfg.CostFunc != nil && cfg.WaitMode && !canSettle {
+		panic("ratelimit middleware cost in wait mode requires a core.SettleLimiter")
+	}
+
 	// 确保有默认的KeyFunc
 	if cfg.KeyFunc == nil {
 		cfg.KeyFunc = DefaultConfig().KeyFunc
@@ -343,9 +310,25 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 
 		log.Debug(c, "starting rate limit check")
 
+		cost := requestCost(c, cfg)
+		if cost <= 0 {
+			// 不扣减配额的请求，仍按实际成本结算
+			log.Debug(c, "request cost is zero, skipping rate limit check")
+			c.Next()
+			if cfg.SettleFunc != nil {
+				if actual, ok := cfg.SettleFunc(c); ok {
+					settleCost(c, cfg, log, key, 0, actual)
+				}
+			}
+			return
+		}
+
 		var allowed bool
 		var err error
 		var duration time.Duration
+		// result 最近一次判断的详细结果，仅限流器实现 core.ResultLimiter 时可用
+		var result *core.Result
+		resultLimiter, hasResult := cfg.Limiter.(core.ResultLimiter)
 
 		operation := func() (bool, error) {
 			operationStart := time.Now()
@@ -356,7 +339,7 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 
 				err := cfg.Limiter.Wait(ctx, key, cfg.Limit, cfg.Window)
 				waitDuration := time.Since(waitStart)
-				WaitDuration.WithLabelValues(key).Observe(waitDuration.Seconds())
+				metrics.Collector.ObserveWait(key, waitDuration.Seconds())
 
 				log.Debug(c, "wait operation completed",
 					types.Field{Key: "duration", Value: waitDuration},
@@ -369,7 +352,14 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 				return err == nil, err
 			}
 
-			allowed, err := cfg.Limiter.AllowN(c, key, 1, cfg.Limit, cfg.Window)
+			var allowed bool
+			var err error
+			if hasResult {
+				result, err = resultLimiter.Take(c, key, cost, cfg.Limit, cfg.Window)
+				allowed = err == nil && result.Allowed
+			} else {
+				allowed, err = cfg.Limiter.AllowN(c, key, cost, cfg.Limit, cfg.Window)
+			}
 			log.Debug(c, "allow operation completed",
 				types.Field{Key: "duration", Value: time.Since(operationStart)},
 				types.Field{Key: "allowed", Value: allowed},
@@ -386,15 +376,22 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 			waitStart := time.Now()
 			err = cfg.Limiter.Wait(ctx, key, cfg.Limit, cfg.Window)
 			duration = time.Since(waitStart)
-			LimiterLatency.WithLabelValues(key, "total").Observe(duration.Seconds())
+			metrics.Collector.ObserveLatency(key, "total", duration.Seconds())
+
+			// Wait 只扣减一个单位，其余成本追加扣减
+			if err == nil && cost > 1 {
+				err = cfg.Limiter.(core.SettleLimiter).Charge(c, key, cost-1, cfg.Limit, cfg.Window)
+			}
 
-			if err != nil {
+			// 等待超时、等待队列已满都按限流处理，直接返回 429
+			waitExceeded := errors.HasErrorCode(err, codes.TooManyRequests) || errors.Is(err, context.DeadlineExceeded)
+			if err != nil && !waitExceeded {
 				log.Error(c, "rate limit check failed",
 					types.Field{Key: "error", Value: err},
 					types.Field{Key: "duration", Value: duration},
 				)
 
-				RequestsTotal.WithLabelValues(key, "error").Inc()
+				metrics.Collector.ObserveCheckFailed(key)
 				err = errors.NewError(codes.RateLimitError, cfg.Messages.CheckFailedMessage, err)
 				c.Error(err)
 				c.AbortWithStatusJSON(cfg.Status.CheckFailed,
@@ -407,7 +404,7 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 			var retryErr error
 			allowed, retryErr = doWithRetry(c, key, cfg.Retry, operation)
 			duration = time.Since(start)
-			LimiterLatency.WithLabelValues(key, "total").Observe(duration.Seconds())
+			metrics.Collector.ObserveLatency(key, "total", duration.Seconds())
 
 			if retryErr != nil {
 				log.Error(c, "rate limit operation failed",
@@ -415,7 +412,7 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 					types.Field{Key: "duration", Value: duration},
 				)
 
-				RequestsTotal.WithLabelValues(key, "error").Inc()
+				metrics.Collector.ObserveCheckFailed(key)
 				err = errors.NewError(codes.RateLimitError, cfg.Messages.CheckFailedMessage, retryErr)
 				c.Error(err)
 				c.AbortWithStatusJSON(cfg.Status.CheckFailed,
@@ -424,13 +421,13 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 			}
 		}
 
+		writeHeaders(c, cfg.HeaderMode, result)
+
 		if !allowed {
 			log.Debug(c, "rate limit exceeded",
 				types.Field{Key: "duration", Value: duration},
 			)
 
-			RequestsTotal.WithLabelValues(key, "rejected").Inc()
-			RejectedTotal.WithLabelValues(key).Inc()
 			err = errors.NewError(codes.TooManyRequests, cfg.Messages.LimitExceededMessage, nil)
 			c.Error(err)
 			c.AbortWithStatusJSON(cfg.Status.LimitExceeded,
@@ -440,9 +437,15 @@ func RateLimit(cfg *Config) gin.HandlerFunc {
 
 		log.Debug(c, "request allowed",
 			types.Field{Key: "duration", Value: duration},
+			types.Field{Key: "cost", Value: cost},
 		)
 
-		RequestsTotal.WithLabelValues(key, "allowed").Inc()
 		c.Next()
+
+		if cfg.SettleFunc != nil {
+			if actual, ok := cfg.SettleFunc(c); ok {
+				settleCost(c, cfg, log, key, cost, actual)
+			}
+		}
 	}
 }
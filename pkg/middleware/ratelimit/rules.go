@@ -0,0 +1,488 @@
+package ratelimit
+
+import (
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/auth/jwt"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// 限流键维度
+const (
+	KeyByIP     = "ip"
+	KeyByUser   = "user"
+	KeyByAPIKey = "apikey"
+	KeyByRoute  = "route"
+	KeyByMethod = "method"
+)
+
+// DefaultAPIKeyHeader 默认的API Key请求头
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// RulesConfig 限流规则配置
+type RulesConfig struct {
+	// KeyPrefix 限流键前缀
+	KeyPrefix string `json:"keyPrefix" yaml:"keyPrefix"`
+	// APIKeyHeader 读取API Key的请求头
+	APIKeyHeader string `json:"apiKeyHeader" yaml:"apiKeyHeader"`
+	// Whitelist 白名单，命中时不限流
+	Whitelist ListConfig `json:"whitelist" yaml:"whitelist"`
+	// Blacklist 黑名单，命中时直接拒绝
+	Blacklist ListConfig `json:"blacklist" yaml:"blacklist"`
+	// Rules 限流规则，按顺序匹配
+	Rules []RuleConfig `json:"rules" yaml:"rules"`
+	// Shadow 所有规则都以影子模式运行，用于整体试运行
+	Shadow bool `json:"shadow" yaml:"shadow"`
+}
+
+// ListConfig 黑白名单配置
+type ListConfig struct {
+	// IPs IP或CIDR
+	IPs []string `json:"ips" yaml:"ips"`
+	// Users JWT中的用户ID
+	Users []string `json:"users" yaml:"users"`
+	// APIKeys API Key
+	APIKeys []string `json:"apiKeys" yaml:"apiKeys"`
+}
+
+// RuleConfig 单条限流规则
+// 所有非空条件都满足时规则匹配，同一条件内的多个值满足其一即可
+type RuleConfig struct {
+	// Name 规则名称，用于生成限流键，必须唯一
+	Name string `json:"name" yaml:"name"`
+	// Routes 路由模式，支持gin路由模板(/users/:id)、path.Match通配符以及以*结尾的前缀匹配
+	Routes []string `json:"routes" yaml:"routes"`
+	// Methods HTTP方法
+	Methods []string `json:"methods" yaml:"methods"`
+	// Users JWT中的用户ID
+	Users []string `json:"users" yaml:"users"`
+	// Roles JWT中的角色
+	Roles []string `json:"roles" yaml:"roles"`
+	// APIKeys API Key
+	APIKeys []string `json:"apiKeys" yaml:"apiKeys"`
+	// IPs IP或CIDR
+	IPs []string `json:"ips" yaml:"ips"`
+	// KeyBy 计数维度，可组合 ip/user/apikey/route/method，为空时所有匹配的请求共享计数
+	KeyBy []string `json:"keyBy" yaml:"keyBy"`
+	// Limits 叠加的限流阈值，任一超限即拒绝，窗口不能重复
+	Limits []LimitConfig `json:"limits" yaml:"limits"`
+	// Final 匹配后不再检查后续规则
+	Final bool `json:"final" yaml:"final"`
+	// Shadow 影子模式，照常计数并记录"将被拒绝"的请求，但不拒绝，用于上线前用真实流量验证新规则
+	Shadow bool `json:"shadow" yaml:"shadow"`
+}
+
+// LimitConfig 限流阈值
+type LimitConfig struct {
+	Limit  int64         `json:"limit" yaml:"limit"`
+	Window time.Duration `json:"window" yaml:"window"`
+}
+
+// RuleEngine 限流规则引擎，规则可在运行时原子替换
+type RuleEngine struct {
+	rules atomic.Value // *ruleSet
+}
+
+// ruleSet 编译后的规则集合
+type ruleSet struct {
+	keyPrefix    string
+	apiKeyHeader string
+	whitelist    *matchList
+	blacklist    *matchList
+	rules        []*rule
+}
+
+// rule 编译后的规则
+type rule struct {
+	name    string
+	routes  []string
+	methods map[string]bool
+	users   map[string]bool
+	roles   map[string]bool
+	apiKeys map[string]bool
+	ips     *ipMatcher
+	keyBy   []string
+	limits  []LimitConfig
+	final   bool
+	shadow  bool
+}
+
+// matchList 编译后的黑白名单
+type matchList struct {
+	ips     *ipMatcher
+	users   map[string]bool
+	apiKeys map[string]bool
+}
+
+// ipMatcher IP和CIDR匹配器
+type ipMatcher struct {
+	nets []*net.IPNet
+}
+
+// Request 规则匹配使用的请求信息
+type Request struct {
+	Route  string
+	Path   string
+	Method string
+	IP     string
+	UserID string
+	Roles  []string
+	APIKey string
+}
+
+// Decision 规则匹配结果
+type Decision struct {
+	// Whitelisted 命中白名单
+	Whitelisted bool
+	// Blacklisted 命中黑名单
+	Blacklisted bool
+	// Checks 需要检查的限流项
+	Checks []Check
+}
+
+// Check 单个限流检查项
+type Check struct {
+	Rule   string
+	Key    string
+	Limit  int64
+	Window time.Duration
+	// Shadow 影子模式，超限时只记录不拒绝
+	Shadow bool
+}
+
+// NewRuleEngine 创建规则引擎
+func NewRuleEngine(cfg *RulesConfig) (*RuleEngine, error) {
+	e := &RuleEngine{}
+	if err := e.Update(cfg); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update 校验并替换规则，校验失败时保留原有规则
+func (e *RuleEngine) Update(cfg *RulesConfig) error {
+	set, err := compileRules(cfg)
+	if err != nil {
+		return err
+	}
+	e.rules.Store(set)
+	return nil
+}
+
+// RequestFromGin 从gin请求中提取规则匹配需要的信息
+func (e *RuleEngine) RequestFromGin(c *gin.Context) *Request {
+	set := e.load()
+	req := &Request{
+		Route:  c.FullPath(),
+		Path:   c.Request.URL.Path,
+		Method: c.Request.Method,
+		IP:     c.ClientIP(),
+		APIKey: c.GetHeader(set.apiKeyHeader),
+	}
+	if claims, ok := jwt.FromContext(c); ok {
+		req.UserID = claims.GetUserID()
+		req.Roles = claims.GetRoles()
+	}
+	return req
+}
+
+// Match 返回请求需要检查的限流项
+func (e *RuleEngine) Match(req *Request) *Decision {
+	set := e.load()
+
+	if set.blacklist.match(req) {
+		return &Decision{Blacklisted: true}
+	}
+	if set.whitelist.match(req) {
+		return &Decision{Whitelisted: true}
+	}
+
+	decision := &Decision{}
+	for _, r := range set.rules {
+		if !r.match(req) {
+			continue
+		}
+
+		key := set.keyPrefix + r.name + r.dimension(req)
+		for _, l := range r.limits {
+			decision.Checks = append(decision.Checks, Check{
+				Rule:   r.name,
+				Key:    key + ":" + strconv.FormatInt(l.Window.Milliseconds(), 10),
+				Limit:  l.Limit,
+				Window: l.Window,
+				Shadow: r.shadow,
+			})
+		}
+
+		if r.final {
+			break
+		}
+	}
+	return decision
+}
+
+func (e *RuleEngine) load() *ruleSet {
+	return e.rules.Load().(*ruleSet)
+}
+
+// compileRules 校验并编译规则配置
+func compileRules(cfg *RulesConfig) (*ruleSet, error) {
+	if cfg == nil {
+		cfg = &RulesConfig{}
+	}
+
+	set := &ruleSet{
+		keyPrefix:    cfg.KeyPrefix,
+		apiKeyHeader: cfg.APIKeyHeader,
+	}
+	if set.keyPrefix == "" {
+		set.keyPrefix = "ratelimit:"
+	}
+	if set.apiKeyHeader == "" {
+		set.apiKeyHeader = DefaultAPIKeyHeader
+	}
+
+	var err error
+	if set.whitelist, err = compileList(&cfg.Whitelist); err != nil {
+		return nil, err
+	}
+	if set.blacklist, err = compileList(&cfg.Blacklist); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(cfg.Rules))
+	for i := range cfg.Rules {
+		rc := &cfg.Rules[i]
+		if rc.Name == "" {
+			return nil, errors.NewError(codes.RateLimitConfigError, "rule name is required", nil)
+		}
+		if names[rc.Name] {
+			return nil, errors.NewError(codes.RateLimitConfigError, "duplicate rule name: "+rc.Name, nil)
+		}
+		names[rc.Name] = true
+
+		r, err := compileRule(rc)
+		if err != nil {
+			return nil, err
+		}
+		r.shadow = r.shadow || cfg.Shadow
+		set.rules = append(set.rules, r)
+	}
+
+	return set, nil
+}
+
+func compileRule(rc *RuleConfig) (*rule, error) {
+	if len(rc.Limits) == 0 {
+		return nil, errors.NewError(codes.RateLimitConfigError, "rule "+rc.Name+" has no limits", nil)
+	}
+	// 同一规则的阈值按窗口区分计数键，相同窗口会共用计数
+	windows := make(map[time.Duration]bool, len(rc.Limits))
+	for _, l := range rc.Limits {
+		if l.Limit <= 0 || l.Window <= 0 {
+			return nil, errors.NewError(codes.RateLimitConfigError, "rule "+rc.Name+" has invalid limit", nil)
+		}
+		if windows[l.Window] {
+			return nil, errors.NewError(codes.RateLimitConfigError, "rule "+rc.Name+" has duplicate limit window", nil)
+		}
+		windows[l.Window] = true
+	}
+	for _, k := range rc.KeyBy {
+		switch k {
+		case KeyByIP, KeyByUser, KeyByAPIKey, KeyByRoute, KeyByMethod:
+		default:
+			return nil, errors.NewError(codes.RateLimitConfigError, "rule "+rc.Name+" has unknown keyBy: "+k, nil)
+		}
+	}
+
+	ips, err := compileIPs(rc.IPs)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]string, len(rc.Methods))
+	for i, m := range rc.Methods {
+		methods[i] = strings.ToUpper(m)
+	}
+
+	// 窗口小的阈值先检查，尽量避免被拒绝的请求占用大窗口的配额
+	limits := append([]LimitConfig(nil), rc.Limits...)
+	sort.SliceStable(limits, func(i, j int) bool { return limits[i].Window < limits[j].Window })
+
+	return &rule{
+		name:    rc.Name,
+		routes:  rc.Routes,
+		methods: toSet(methods),
+		users:   toSet(rc.Users),
+		roles:   toSet(rc.Roles),
+		apiKeys: toSet(rc.APIKeys),
+		ips:     ips,
+		keyBy:   rc.KeyBy,
+		limits:  limits,
+		final:   rc.Final,
+		shadow:  rc.Shadow,
+	}, nil
+}
+
+func compileList(lc *ListConfig) (*matchList, error) {
+	ips, err := compileIPs(lc.IPs)
+	if err != nil {
+		return nil, err
+	}
+	return &matchList{
+		ips:     ips,
+		users:   toSet(lc.Users),
+		apiKeys: toSet(lc.APIKeys),
+	}, nil
+}
+
+// compileIPs 解析IP和CIDR，单个IP按/32或/128处理
+func compileIPs(values []string) (*ipMatcher, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	m := &ipMatcher{}
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, errors.NewError(codes.RateLimitConfigError, "invalid ip: "+v, nil)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			m.nets = append(m.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, errors.NewError(codes.RateLimitConfigError, "invalid cidr: "+v, err)
+		}
+		m.nets = append(m.nets, ipNet)
+	}
+	return m, nil
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func (m *ipMatcher) contains(value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, n := range m.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *matchList) match(req *Request) bool {
+	if l.ips != nil && l.ips.contains(req.IP) {
+		return true
+	}
+	if req.UserID != "" && l.users[req.UserID] {
+		return true
+	}
+	if req.APIKey != "" && l.apiKeys[req.APIKey] {
+		return true
+	}
+	return false
+}
+
+func (r *rule) match(req *Request) bool {
+	if len(r.routes) > 0 && !matchRoutes(r.routes, req) {
+		return false
+	}
+	if r.methods != nil && !r.methods[req.Method] {
+		return false
+	}
+	if r.users != nil && !r.users[req.UserID] {
+		return false
+	}
+	if r.roles != nil && !matchAny(r.roles, req.Roles) {
+		return false
+	}
+	if r.apiKeys != nil && !r.apiKeys[req.APIKey] {
+		return false
+	}
+	if r.ips != nil && !r.ips.contains(req.IP) {
+		return false
+	}
+	return true
+}
+
+// dimension 根据 KeyBy 生成计数维度
+func (r *rule) dimension(req *Request) string {
+	var b strings.Builder
+	for _, k := range r.keyBy {
+		b.WriteString(":")
+		switch k {
+		case KeyByIP:
+			b.WriteString(req.IP)
+		case KeyByUser:
+			b.WriteString(req.UserID)
+		case KeyByAPIKey:
+			b.WriteString(req.APIKey)
+		case KeyByRoute:
+			if req.Route != "" {
+				b.WriteString(req.Route)
+			} else {
+				b.WriteString(req.Path)
+			}
+		case KeyByMethod:
+			b.WriteString(req.Method)
+		}
+	}
+	return b.String()
+}
+
+func matchRoutes(patterns []string, req *Request) bool {
+	for _, p := range patterns {
+		switch {
+		case strings.HasSuffix(p, "*") && !strings.ContainsAny(strings.TrimSuffix(p, "*"), "*?["):
+			if strings.HasPrefix(req.Path, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		case p == req.Route || p == req.Path:
+			return true
+		default:
+			if ok, _ := path.Match(p, req.Path); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAny(set map[string]bool, values []string) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
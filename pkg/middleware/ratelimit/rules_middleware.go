@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// RateLimitWithRules 创建基于规则的限流中间件
+// 使用 cfg 中的 Limiter、Formatter、Messages、Status 和 HeaderMode，忽略 KeyFunc、Limit、Window、WaitMode 和 Retry
+// 每个请求依次检查所有匹配规则的全部阈值，任一超限即拒绝
+// 拒绝时退还之前的检查已扣减的配额，需要限流器实现 core.SettleLimiter，否则不退还
+// 影子模式规则的超限和检查失败只记录日志和 shadow_requests_total 指标，不拒绝请求，也不影响响应头
+func RateLimitWithRules(cfg *Config, engine *RuleEngine) gin.HandlerFunc {
+	if cfg == nil || cfg.Limiter == nil {
+		panic("ratelimit middleware requires a limiter instance")
+	}
+	if engine == nil {
+		panic("ratelimit middleware requires a rule engine")
+	}
+
+	defaults := DefaultConfig()
+	if cfg.Formatter == nil {
+		cfg.Formatter = defaults.Formatter
+	}
+	if cfg.Messages == nil {
+		cfg.Messages = defaults.Messages
+	}
+	if cfg.Status == nil {
+		cfg.Status = defaults.Status
+	}
+
+	blacklistedMessage := cfg.Messages.BlacklistedMessage
+	if blacklistedMessage == "" {
+		blacklistedMessage = defaults.Messages.BlacklistedMessage
+	}
+	blacklistedStatus := cfg.Status.Blacklisted
+	if blacklistedStatus == 0 {
+		blacklistedStatus = defaults.Status.Blacklisted
+	}
+
+	resultLimiter, hasResult := cfg.Limiter.(core.ResultLimiter)
+	settleLimiter, canRefund := cfg.Limiter.(core.SettleLimiter)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		log := logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "middleware"},
+			types.Field{Key: "component", Value: "ratelimit"},
+		)
+
+		decision := engine.Match(engine.RequestFromGin(c))
+
+		if decision.Blacklisted {
+			log.Debug(c, "request blacklisted", types.Field{Key: "ip", Value: c.ClientIP()})
+			c.Error(errors.NewError(codes.Forbidden, blacklistedMessage, nil))
+			c.AbortWithStatusJSON(blacklistedStatus,
+				cfg.Formatter.FormatError(codes.Forbidden, blacklistedMessage))
+			return
+		}
+		if decision.Whitelisted || len(decision.Checks) == 0 {
+			c.Next()
+			return
+		}
+
+		// tightest 剩余配额最少的结果，用于输出响应头
+		var tightest *core.Result
+		// taken 已扣减配额的检查项，请求被拒绝时退还
+		var taken []Check
+		refund := func() {
+			if !canRefund {
+				return
+			}
+			for _, check := range taken {
+				var ctx context.Context = c
+				if check.Shadow {
+					ctx = metrics.WithShadow(c)
+				}
+				if err := settleLimiter.Refund(ctx, check.Key, 1, check.Limit, check.Window); err != nil {
+					log.Warn(c, "failed to refund rate limit rule quota",
+						types.Field{Key: "rule", Value: check.Rule},
+						types.Field{Key: "key", Value: check.Key},
+						types.Error(err),
+					)
+				}
+			}
+		}
+
+		for _, check := range decision.Checks {
+			var ctx context.Context = c
+			if check.Shadow {
+				ctx = metrics.WithShadow(c)
+			}
+
+			var result *core.Result
+			var err error
+			if hasResult {
+				result, err = resultLimiter.Take(ctx, check.Key, 1, check.Limit, check.Window)
+			} else {
+				var allowed bool
+				allowed, err = cfg.Limiter.AllowN(ctx, check.Key, 1, check.Limit, check.Window)
+				result = &core.Result{Allowed: allowed, Limit: check.Limit}
+			}
+
+			if check.Shadow {
+				if err != nil {
+					log.Warn(c, "shadow rate limit rule check failed",
+						types.Field{Key: "rule", Value: check.Rule},
+						types.Field{Key: "key", Value: check.Key},
+						types.Error(err),
+					)
+				} else if !result.Allowed {
+					log.Info(c, "shadow rate limit rule would reject request",
+						types.Field{Key: "rule", Value: check.Rule},
+						types.Field{Key: "key", Value: check.Key},
+						types.Field{Key: "limit", Value: check.Limit},
+						types.Field{Key: "window", Value: check.Window},
+					)
+				} else {
+					taken = append(taken, check)
+				}
+				continue
+			}
+
+			if err != nil {
+				log.Error(c, "rate limit rule check failed",
+					types.Field{Key: "rule", Value: check.Rule},
+					types.Field{Key: "key", Value: check.Key},
+					types.Error(err),
+				)
+				metrics.Collector.ObserveCheckFailed(check.Key)
+				refund()
+				c.Error(errors.NewError(codes.RateLimitError, cfg.Messages.CheckFailedMessage, err))
+				c.AbortWithStatusJSON(cfg.Status.CheckFailed,
+					cfg.Formatter.FormatError(codes.RateLimitError, cfg.Messages.CheckFailedMessage))
+				return
+			}
+
+			if !result.Allowed {
+				refund()
+				metrics.Collector.ObserveLatency(check.Rule, "total", time.Since(start).Seconds())
+
+				if hasResult {
+					writeHeaders(c, cfg.HeaderMode, result)
+				}
+				c.Error(errors.NewError(codes.TooManyRequests, cfg.Messages.LimitExceededMessage, nil))
+				c.AbortWithStatusJSON(cfg.Status.LimitExceeded,
+					cfg.Formatter.FormatError(codes.TooManyRequests, cfg.Messages.LimitExceededMessage))
+				return
+			}
+
+			taken = append(taken, check)
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = result
+			}
+		}
+
+		if hasResult {
+			writeHeaders(c, cfg.HeaderMode, tightest)
+		}
+		metrics.Collector.ObserveLatency(decision.Checks[0].Rule, "total", time.Since(start).Seconds())
+		c.Next()
+	}
+}
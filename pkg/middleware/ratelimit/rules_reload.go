@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+
+	configTypes "gobase/pkg/config/types"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+)
+
+// LoadRules 从配置提供者加载 key 下的规则配置
+func LoadRules(provider configTypes.Provider, key string) (*RulesConfig, error) {
+	value, err := provider.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeRules(value)
+}
+
+// DecodeRules 将配置值解析为规则配置，窗口支持 "1s"、"1h" 等时长字符串
+func DecodeRules(value interface{}) (*RulesConfig, error) {
+	cfg := &RulesConfig{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           cfg,
+		WeaklyTypedInput: true,
+		TagName:          "yaml",
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return nil, errors.NewError(codes.RateLimitConfigError, "failed to create rules decoder", err)
+	}
+	if err := decoder.Decode(value); err != nil {
+		return nil, errors.NewError(codes.RateLimitConfigError, "failed to decode rules", err)
+	}
+	return cfg, nil
+}
+
+// Watch 从配置提供者加载 key 下的规则，并在配置变更时热更新
+// 新规则校验失败时保留原有规则。注意 Provider 只保存一个变更回调，调用后会替换已注册的回调
+func (e *RuleEngine) Watch(ctx context.Context, provider configTypes.Provider, key string) error {
+	cfg, err := LoadRules(provider, key)
+	if err != nil {
+		return err
+	}
+	if err := e.Update(cfg); err != nil {
+		return err
+	}
+
+	log := logger.GetLogger().WithFields(
+		types.Field{Key: "module", Value: "middleware"},
+		types.Field{Key: "component", Value: "ratelimit"},
+		types.Field{Key: "config_key", Value: key},
+	)
+
+	provider.OnConfigChange(func(event configTypes.Event) {
+		if event.Error != nil {
+			log.Warn(ctx, "ignoring failed config change event", types.Error(event.Error))
+			return
+		}
+
+		var cfg *RulesConfig
+		var err error
+		if value, ok := lookupEventValue(event, key); ok {
+			cfg, err = DecodeRules(value)
+		} else {
+			cfg, err = LoadRules(provider, key)
+		}
+		if err == nil {
+			err = e.Update(cfg)
+		}
+		if err != nil {
+			log.Error(ctx, "failed to reload rate limit rules, keeping previous rules", types.Error(err))
+			return
+		}
+
+		log.Info(ctx, "rate limit rules reloaded",
+			types.Field{Key: "rules", Value: len(cfg.Rules)},
+		)
+	})
+
+	return provider.WatchConfig(ctx)
+}
+
+// lookupEventValue 从变更事件中取出 key 对应的值
+// 事件的 Key 与 key 相同时直接使用 Value，否则在 Value 中按点分路径查找(忽略大小写)
+func lookupEventValue(event configTypes.Event, key string) (interface{}, bool) {
+	if event.Key == key {
+		return event.Value, true
+	}
+
+	current := event.Value
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		found := false
+		for k, v := range m {
+			if strings.EqualFold(k, part) {
+				current, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return current, true
+}
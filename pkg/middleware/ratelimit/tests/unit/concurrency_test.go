@@ -0,0 +1,183 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/client/redis"
+	middleware "gobase/pkg/middleware/ratelimit"
+	"gobase/pkg/ratelimit/adaptive"
+	"gobase/pkg/ratelimit/core"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+func newTestSemaphore(t *testing.T) core.ConcurrencyLimiter {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	sem, err := redislimiter.NewSemaphore(ratelimit.NewStore(client), core.WithAcquireRetryInterval(5*time.Millisecond))
+	require.NoError(t, err)
+	return sem
+}
+
+// newConcurrencyRouter 创建处理函数阻塞直到 release 关闭的测试路由
+func newConcurrencyRouter(cfg *middleware.ConcurrencyConfig, entered chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ConcurrencyLimit(cfg))
+	router.GET("/export", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func serveAsync(router *gin.Engine, ip string, wg *sync.WaitGroup, codes chan<- int) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/export", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		codes <- w.Code
+	}()
+}
+
+func TestConcurrencyLimit_PerKey(t *testing.T) {
+	sem := newTestSemaphore(t)
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	router := newConcurrencyRouter(&middleware.ConcurrencyConfig{
+		Limiter: sem,
+		Name:    "export",
+		Limit:   1,
+	}, entered, release)
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	serveAsync(router, "10.0.0.1", &wg, results)
+	<-entered
+
+	// 同一键超过上限被拒绝，其他键不受影响
+	serveAsync(router, "10.0.0.1", &wg, results)
+	assert.Equal(t, http.StatusTooManyRequests, <-results)
+
+	serveAsync(router, "10.0.0.2", &wg, results)
+	<-entered
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, <-results)
+	assert.Equal(t, http.StatusOK, <-results)
+
+	count, err := sem.InFlight(context.Background(), "concurrency:export:10.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestConcurrencyLimit_Global(t *testing.T) {
+	sem := newTestSemaphore(t)
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	router := newConcurrencyRouter(&middleware.ConcurrencyConfig{
+		Limiter:     sem,
+		Name:        "upload",
+		Limit:       5,
+		GlobalLimit: 2,
+	}, entered, release)
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	serveAsync(router, "10.0.0.1", &wg, results)
+	serveAsync(router, "10.0.0.2", &wg, results)
+	<-entered
+	<-entered
+
+	serveAsync(router, "10.0.0.3", &wg, results)
+	assert.Equal(t, http.StatusTooManyRequests, <-results)
+
+	// 全局拒绝时已获取的按键许可被释放
+	count, err := sem.InFlight(context.Background(), "concurrency:upload:10.0.0.3")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimit_Wait(t *testing.T) {
+	sem := newTestSemaphore(t)
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	router := newConcurrencyRouter(&middleware.ConcurrencyConfig{
+		Limiter:     sem,
+		Name:        "report",
+		GlobalLimit: 1,
+		WaitTimeout: time.Second,
+	}, entered, release)
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	serveAsync(router, "10.0.0.1", &wg, results)
+	<-entered
+
+	// 排队的请求在前一个请求完成后进入
+	serveAsync(router, "10.0.0.2", &wg, results)
+	time.Sleep(20 * time.Millisecond)
+	release <- struct{}{}
+	<-entered
+	close(release)
+
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, <-results)
+	assert.Equal(t, http.StatusOK, <-results)
+}
+
+func TestConcurrencyLimit_InvalidConfig(t *testing.T) {
+	assert.Panics(t, func() { middleware.ConcurrencyLimit(nil) })
+	assert.Panics(t, func() {
+		middleware.ConcurrencyLimit(&middleware.ConcurrencyConfig{Limiter: newTestSemaphore(t)})
+	})
+}
+
+func TestConcurrencyLimit_AdaptiveFeedback(t *testing.T) {
+	limiter, err := adaptive.New(
+		adaptive.WithAlgorithm(adaptive.AlgorithmAIMD),
+		adaptive.WithLimits(10, 1, 100),
+	)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ConcurrencyLimit(&middleware.ConcurrencyConfig{
+		Limiter:     limiter,
+		Name:        "adaptive",
+		GlobalLimit: 100,
+	}))
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+
+	// 5xx 响应作为过载信号反馈给自适应限流器
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+	assert.Less(t, limiter.Limit("concurrency:adaptive:global"), int64(10))
+}
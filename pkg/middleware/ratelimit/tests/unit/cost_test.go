@@ -0,0 +1,97 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"gobase/pkg/middleware/ratelimit"
+	mocklimiter "gobase/pkg/middleware/ratelimit/tests/mock"
+	"gobase/pkg/ratelimit/memory"
+)
+
+// newCostRouter 创建每小时5个单位的测试路由
+func newCostRouter(cfg *ratelimit.Config) *gin.Engine {
+	cfg.Limiter = memory.NewTokenBucketLimiter()
+	cfg.KeyFunc = func(c *gin.Context) string { return "client" }
+	cfg.Limit = 5
+	cfg.Window = time.Hour
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ratelimit.RateLimit(cfg))
+	router.GET("/search", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/export", func(c *gin.Context) {
+		ratelimit.SetCost(c, 1)
+		c.String(http.StatusOK, strings.Repeat("x", 25))
+	})
+	return router
+}
+
+func get(router *gin.Engine, path string) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code
+}
+
+func TestRateLimit_CostTable(t *testing.T) {
+	router := newCostRouter(&ratelimit.Config{
+		CostFunc: ratelimit.CostTable{
+			"GET /search": 3,
+			"/health":     0,
+			"/items/:id":  2,
+		}.CostFunc(1),
+	})
+
+	assert.Equal(t, http.StatusOK, get(router, "/search"))
+	// 剩余2个单位不足以支付一次搜索
+	assert.Equal(t, http.StatusTooManyRequests, get(router, "/search"))
+	// 成本为0的请求不受限流影响
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, get(router, "/health"))
+	}
+	assert.Equal(t, http.StatusOK, get(router, "/items/1"))
+	assert.Equal(t, http.StatusTooManyRequests, get(router, "/items/2"))
+}
+
+func TestRateLimit_SettleReportedCost(t *testing.T) {
+	router := newCostRouter(&ratelimit.Config{
+		CostFunc:   func(c *gin.Context) int64 { return 3 },
+		SettleFunc: ratelimit.ReportedCost,
+	})
+
+	// 预扣3个单位，处理函数上报实际只用了1个，其余退还
+	assert.Equal(t, http.StatusOK, get(router, "/export"))
+	// 未上报成本的请求不结算
+	assert.Equal(t, http.StatusOK, get(router, "/search"))
+	assert.Equal(t, http.StatusTooManyRequests, get(router, "/search"))
+}
+
+func TestRateLimit_SettleResponseSize(t *testing.T) {
+	router := newCostRouter(&ratelimit.Config{
+		SettleFunc: ratelimit.ResponseSizeCost(10),
+	})
+
+	// 25字节的响应按3个单位结算
+	assert.Equal(t, http.StatusOK, get(router, "/export"))
+	assert.Equal(t, http.StatusOK, get(router, "/export"))
+	assert.Equal(t, http.StatusTooManyRequests, get(router, "/export"))
+}
+
+func TestRateLimit_SettleRequiresSettleLimiter(t *testing.T) {
+	assert.Panics(t, func() {
+		ratelimit.RateLimit(&ratelimit.Config{
+			Limiter:    new(mocklimiter.MockLimiter),
+			Limit:      1,
+			Window:     time.Second,
+			SettleFunc: ratelimit.ReportedCost,
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/middleware/ratelimit"
+	mocklimiter "gobase/pkg/middleware/ratelimit/tests/mock"
+	"gobase/pkg/ratelimit/memory"
+)
+
+// newHeaderRouter 创建每分钟允许2个请求的测试路由
+func newHeaderRouter(mode ratelimit.HeaderMode) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ratelimit.RateLimit(&ratelimit.Config{
+		Limiter:    memory.NewTokenBucketLimiter(),
+		KeyFunc:    func(c *gin.Context) string { return "client" },
+		Limit:      2,
+		Window:     time.Minute,
+		HeaderMode: mode,
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func serve(router *gin.Engine) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_StandardHeaders(t *testing.T) {
+	router := newHeaderRouter(ratelimit.HeaderModeStandard)
+
+	w := serve(router)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(ratelimit.HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(ratelimit.HeaderRateLimitRemaining))
+	assert.Equal(t, "30", w.Header().Get(ratelimit.HeaderRateLimitReset))
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderRetryAfter))
+
+	serve(router)
+	w = serve(router)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get(ratelimit.HeaderRateLimitRemaining))
+	assert.Equal(t, "30", w.Header().Get(ratelimit.HeaderRetryAfter))
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderXRateLimitLimit))
+}
+
+func TestRateLimit_LegacyHeaders(t *testing.T) {
+	router := newHeaderRouter(ratelimit.HeaderModeLegacy)
+
+	w := serve(router)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(ratelimit.HeaderXRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(ratelimit.HeaderXRateLimitRemaining))
+
+	reset, err := strconv.ParseInt(w.Header().Get(ratelimit.HeaderXRateLimitReset), 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(30*time.Second).Unix(), reset, 2)
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderRateLimitLimit))
+
+	serve(router)
+	w = serve(router)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get(ratelimit.HeaderRetryAfter))
+}
+
+func TestRateLimit_NoHeaders(t *testing.T) {
+	w := serve(newHeaderRouter(ratelimit.HeaderModeNone))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderRateLimitLimit))
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderXRateLimitLimit))
+
+	// 限流器不支持详细结果时不输出响应头
+	mockLimiter := new(mocklimiter.MockLimiter)
+	mockLimiter.On("AllowN", testifymock.Anything, testifymock.Anything, int64(1), int64(100), time.Minute).
+		Return(true, nil)
+
+	router := gin.New()
+	router.Use(ratelimit.RateLimit(&ratelimit.Config{
+		Limiter: mockLimiter,
+		Limit:   100,
+		Window:  time.Minute,
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w = serve(router)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderRateLimitLimit))
+	mockLimiter.AssertExpectations(t)
+}
@@ -0,0 +1,354 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/auth/jwt"
+	configTypes "gobase/pkg/config/types"
+	"gobase/pkg/middleware/ratelimit"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/memory"
+)
+
+// fakeProvider 只实现规则加载和热更新用到的方法
+type fakeProvider struct {
+	configTypes.Provider
+	values   map[string]interface{}
+	callback func(configTypes.Event)
+}
+
+func (p *fakeProvider) Get(key string) (interface{}, error) {
+	return p.values[key], nil
+}
+
+func (p *fakeProvider) OnConfigChange(fn func(configTypes.Event)) {
+	p.callback = fn
+}
+
+func (p *fakeProvider) WatchConfig(ctx context.Context) error {
+	return nil
+}
+
+func newRulesRouter(t *testing.T, cfg *ratelimit.RulesConfig, roles []string) (*gin.Engine, *ratelimit.RuleEngine) {
+	engine, err := ratelimit.NewRuleEngine(cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if roles != nil {
+			jwt.ToContext(c, jwt.NewStandardClaims(jwt.WithUserID("u1"), jwt.WithRoles(roles)))
+		}
+	})
+	router.Use(ratelimit.RateLimitWithRules(&ratelimit.Config{Limiter: memory.NewTokenBucketLimiter()}, engine))
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/users/:id", handler)
+	router.POST("/api/users/:id", handler)
+	router.GET("/health", handler)
+	return router, engine
+}
+
+func serveRule(router *gin.Engine, method, target string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if setup != nil {
+		setup(req)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRuleEngine_Match(t *testing.T) {
+	engine, err := ratelimit.NewRuleEngine(&ratelimit.RulesConfig{
+		Rules: []ratelimit.RuleConfig{
+			{
+				Name:    "admin",
+				Roles:   []string{"admin"},
+				KeyBy:   []string{ratelimit.KeyByUser},
+				Limits:  []ratelimit.LimitConfig{{Limit: 1000, Window: time.Minute}},
+				Final:   true,
+				Methods: []string{"get", "post"},
+			},
+			{
+				Name:   "users",
+				Routes: []string{"/api/users/:id"},
+				KeyBy:  []string{ratelimit.KeyByIP, ratelimit.KeyByRoute},
+				Limits: []ratelimit.LimitConfig{
+					{Limit: 1000, Window: time.Hour},
+					{Limit: 10, Window: time.Second},
+				},
+			},
+			{
+				Name:    "partner",
+				APIKeys: []string{"k1"},
+				Limits:  []ratelimit.LimitConfig{{Limit: 5, Window: time.Second}},
+			},
+			{
+				Name:   "intranet",
+				Routes: []string{"/api/*"},
+				IPs:    []string{"192.168.0.0/16"},
+				Limits: []ratelimit.LimitConfig{{Limit: 50, Window: time.Second}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	d := engine.Match(&ratelimit.Request{Route: "/api/users/:id", Path: "/api/users/1", Method: "GET", IP: "10.0.0.1"})
+	require.Len(t, d.Checks, 2)
+	assert.Equal(t, "ratelimit:users:10.0.0.1:/api/users/:id:1000", d.Checks[0].Key)
+	assert.Equal(t, time.Second, d.Checks[0].Window)
+	assert.Equal(t, time.Hour, d.Checks[1].Window)
+
+	d = engine.Match(&ratelimit.Request{Route: "/api/users/:id", Path: "/api/users/1", Method: "GET", IP: "10.0.0.1", UserID: "u1", Roles: []string{"admin"}})
+	require.Len(t, d.Checks, 1)
+	assert.Equal(t, "admin", d.Checks[0].Rule)
+	assert.Equal(t, "ratelimit:admin:u1:60000", d.Checks[0].Key)
+
+	d = engine.Match(&ratelimit.Request{Path: "/other", Method: "GET", IP: "10.0.0.1", APIKey: "k1"})
+	require.Len(t, d.Checks, 1)
+	assert.Equal(t, "partner", d.Checks[0].Rule)
+
+	d = engine.Match(&ratelimit.Request{Path: "/api/orders", Method: "GET", IP: "192.168.1.20"})
+	require.Len(t, d.Checks, 1)
+	assert.Equal(t, "intranet", d.Checks[0].Rule)
+
+	d = engine.Match(&ratelimit.Request{Path: "/api/orders", Method: "GET", IP: "10.0.0.1"})
+	assert.Empty(t, d.Checks)
+}
+
+func TestRuleEngine_InvalidConfig(t *testing.T) {
+	limits := []ratelimit.LimitConfig{{Limit: 1, Window: time.Second}}
+	tests := []struct {
+		name string
+		cfg  *ratelimit.RulesConfig
+	}{
+		{"missing name", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Limits: limits}}}},
+		{"duplicate name", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Name: "a", Limits: limits}, {Name: "a", Limits: limits}}}},
+		{"no limits", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Name: "a"}}}},
+		{"zero window", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Name: "a", Limits: []ratelimit.LimitConfig{{Limit: 1}}}}}},
+		{"duplicate window", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Name: "a", Limits: []ratelimit.LimitConfig{{Limit: 1, Window: time.Second}, {Limit: 5, Window: time.Second}}}}}},
+		{"unknown keyBy", &ratelimit.RulesConfig{Rules: []ratelimit.RuleConfig{{Name: "a", KeyBy: []string{"cookie"}, Limits: limits}}}},
+		{"invalid cidr", &ratelimit.RulesConfig{Blacklist: ratelimit.ListConfig{IPs: []string{"10.0.0.0/33"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ratelimit.NewRuleEngine(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRateLimitWithRules_StackedLimits(t *testing.T) {
+	router, _ := newRulesRouter(t, &ratelimit.RulesConfig{
+		Rules: []ratelimit.RuleConfig{{
+			Name:   "users",
+			Routes: []string{"/api/users/:id"},
+			KeyBy:  []string{ratelimit.KeyByIP},
+			Limits: []ratelimit.LimitConfig{
+				{Limit: 3, Window: time.Hour},
+				{Limit: 2, Window: time.Minute},
+			},
+		}},
+	}, nil)
+
+	w := serveRule(router, http.MethodGet, "/api/users/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(ratelimit.HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(ratelimit.HeaderRateLimitRemaining))
+
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/api/users/2", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRule(router, http.MethodGet, "/api/users/3", nil).Code)
+
+	// 未匹配规则的路由不限流
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/health", nil).Code)
+	}
+}
+
+func TestRateLimitWithRules_RefundOnReject(t *testing.T) {
+	engine, err := ratelimit.NewRuleEngine(&ratelimit.RulesConfig{
+		Rules: []ratelimit.RuleConfig{
+			{Name: "wide", Limits: []ratelimit.LimitConfig{{Limit: 5, Window: time.Minute}}},
+			{Name: "narrow", Limits: []ratelimit.LimitConfig{{Limit: 1, Window: time.Minute}}},
+		},
+	})
+	require.NoError(t, err)
+
+	limiter := memory.NewTokenBucketLimiter()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ratelimit.RateLimitWithRules(&ratelimit.Config{Limiter: limiter}, engine))
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/health", nil).Code)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusTooManyRequests, serveRule(router, http.MethodGet, "/health", nil).Code)
+	}
+
+	// 被 narrow 拒绝的请求不占用 wide 的配额
+	result, err := limiter.(core.ResultLimiter).Take(context.Background(), "ratelimit:wide:60000", 1, 5, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(3), result.Remaining)
+}
+
+func TestRateLimitWithRules_Shadow(t *testing.T) {
+	router, engine := newRulesRouter(t, &ratelimit.RulesConfig{
+		Rules: []ratelimit.RuleConfig{
+			{
+				Name:   "shadow",
+				Routes: []string{"/api/users/:id"},
+				Limits: []ratelimit.LimitConfig{{Limit: 1, Window: time.Minute}},
+				Shadow: true,
+			},
+			{
+				Name:   "enforced",
+				Routes: []string{"/api/users/:id"},
+				Limits: []ratelimit.LimitConfig{{Limit: 3, Window: time.Minute}},
+			},
+		},
+	}, nil)
+
+	// 影子规则超限后请求仍然通过，响应头来自生效的规则
+	for i := 0; i < 3; i++ {
+		w := serveRule(router, http.MethodGet, "/api/users/1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get(ratelimit.HeaderRateLimitLimit))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serveRule(router, http.MethodGet, "/api/users/1", nil).Code)
+
+	// 全局影子模式下所有规则都不拒绝
+	require.NoError(t, engine.Update(&ratelimit.RulesConfig{
+		Shadow: true,
+		Rules: []ratelimit.RuleConfig{{
+			Name:   "enforced",
+			Routes: []string{"/api/users/:id"},
+			Limits: []ratelimit.LimitConfig{{Limit: 3, Window: time.Minute}},
+		}},
+	}))
+	w := serveRule(router, http.MethodGet, "/api/users/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(ratelimit.HeaderRateLimitLimit))
+}
+
+func TestRateLimitWithRules_RoleAndMethod(t *testing.T) {
+	cfg := &ratelimit.RulesConfig{
+		Rules: []ratelimit.RuleConfig{
+			{
+				Name:   "admin",
+				Roles:  []string{"admin"},
+				Limits: []ratelimit.LimitConfig{{Limit: 100, Window: time.Minute}},
+				Final:  true,
+			},
+			{
+				Name:    "writes",
+				Methods: []string{http.MethodPost},
+				Limits:  []ratelimit.LimitConfig{{Limit: 1, Window: time.Minute}},
+			},
+		},
+	}
+
+	router, _ := newRulesRouter(t, cfg, []string{"admin"})
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serveRule(router, http.MethodPost, "/api/users/1", nil).Code)
+	}
+
+	router, _ = newRulesRouter(t, cfg, []string{"user"})
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/api/users/1", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/api/users/1", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodPost, "/api/users/1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRule(router, http.MethodPost, "/api/users/1", nil).Code)
+}
+
+func TestRateLimitWithRules_Lists(t *testing.T) {
+	router, _ := newRulesRouter(t, &ratelimit.RulesConfig{
+		Whitelist: ratelimit.ListConfig{APIKeys: []string{"internal"}},
+		Blacklist: ratelimit.ListConfig{IPs: []string{"10.1.0.0/16"}},
+		Rules: []ratelimit.RuleConfig{{
+			Name:   "all",
+			Limits: []ratelimit.LimitConfig{{Limit: 1, Window: time.Minute}},
+		}},
+	}, nil)
+
+	withKey := func(r *http.Request) { r.Header.Set(ratelimit.DefaultAPIKeyHeader, "internal") }
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/health", withKey).Code)
+	}
+
+	blocked := func(r *http.Request) { r.RemoteAddr = "10.1.2.3:1234" }
+	assert.Equal(t, http.StatusForbidden, serveRule(router, http.MethodGet, "/health", blocked).Code)
+
+	assert.Equal(t, http.StatusOK, serveRule(router, http.MethodGet, "/health", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveRule(router, http.MethodGet, "/health", nil).Code)
+}
+
+func TestDecodeRules(t *testing.T) {
+	cfg, err := ratelimit.DecodeRules(map[string]interface{}{
+		"keyPrefix": "rl:",
+		"rules": []interface{}{
+			map[string]interface{}{
+				"name":   "login",
+				"routes": []interface{}{"/login"},
+				"shadow": true,
+				"limits": []interface{}{
+					map[string]interface{}{"limit": "5", "window": "1m"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rl:", cfg.KeyPrefix)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, int64(5), cfg.Rules[0].Limits[0].Limit)
+	assert.Equal(t, time.Minute, cfg.Rules[0].Limits[0].Window)
+	assert.True(t, cfg.Rules[0].Shadow)
+}
+
+func TestRuleEngine_Watch(t *testing.T) {
+	rules := func(limit int) map[string]interface{} {
+		return map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"name":   "all",
+					"limits": []interface{}{map[string]interface{}{"limit": limit, "window": "1m"}},
+				},
+			},
+		}
+	}
+	provider := &fakeProvider{values: map[string]interface{}{"ratelimit": rules(1)}}
+
+	engine, err := ratelimit.NewRuleEngine(nil)
+	require.NoError(t, err)
+	require.NoError(t, engine.Watch(context.Background(), provider, "ratelimit"))
+	require.NotNil(t, provider.callback)
+
+	req := &ratelimit.Request{Path: "/", Method: http.MethodGet}
+	require.Len(t, engine.Match(req).Checks, 1)
+	assert.Equal(t, int64(1), engine.Match(req).Checks[0].Limit)
+
+	// 整个配置文件变更，按路径查找规则
+	provider.callback(configTypes.Event{
+		Key:   "config.yaml",
+		Value: map[string]interface{}{"ratelimit": rules(10)},
+		Type:  configTypes.EventUpdate,
+	})
+	assert.Equal(t, int64(10), engine.Match(req).Checks[0].Limit)
+
+	// 非法配置保留原有规则
+	provider.callback(configTypes.Event{
+		Key:   "ratelimit",
+		Value: rules(0),
+		Type:  configTypes.EventUpdate,
+	})
+	assert.Equal(t, int64(10), engine.Match(req).Checks[0].Limit)
+}
@@ -3,11 +3,13 @@ package collector
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"gobase/pkg/errors"
@@ -45,6 +47,9 @@ type SystemCollector struct {
 	// 修改内存指标名称以匹配测试用例
 	memoryUsage *metric.Gauge // 系统内存使用量
 
+	// 最近一次采集的CPU使用率，math.Float64bits 编码
+	lastCPU atomic.Uint64
+
 	// 添加停止通道
 	stopCh chan struct{}
 }
@@ -183,6 +188,17 @@ func (c *SystemCollector) run() {
 	}
 }
 
+// CPUUsage 返回最近一次采集的CPU使用率百分比，尚未采集时返回0
+func (c *SystemCollector) CPUUsage() float64 {
+	return math.Float64frombits(c.lastCPU.Load())
+}
+
+// setCPUUsage 更新CPU使用率指标和缓存值
+func (c *SystemCollector) setCPUUsage(percent float64) {
+	c.cpuUsage.Set(percent)
+	c.lastCPU.Store(math.Float64bits(percent))
+}
+
 // Stop 停止收集
 func (c *SystemCollector) Stop() {
 	close(c.stopCh)
@@ -192,7 +208,7 @@ func (c *SystemCollector) Stop() {
 func (c *SystemCollector) collect() error {
 	// 收集 CPU 使用率
 	if cpuPercent, err := cpu.Percent(0, false); err == nil && len(cpuPercent) > 0 {
-		c.cpuUsage.Set(cpuPercent[0])
+		c.setCPUUsage(cpuPercent[0])
 	}
 
 	// 收集 goroutine 数量
@@ -267,7 +283,7 @@ func (c *SystemCollector) Describe(ch chan<- *prometheus.Desc) {
 func (c *SystemCollector) Collect(ch chan<- prometheus.Metric) {
 	// 收集 CPU 使用率
 	if cpuPercent, err := cpu.Percent(0, false); err == nil && len(cpuPercent) > 0 {
-		c.cpuUsage.Set(cpuPercent[0])
+		c.setCPUUsage(cpuPercent[0])
 	}
 
 	// 收集内存指标
@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"context"
+)
+
+// Queue 作业队列接口
+type Queue interface {
+	// Enqueue 将作业加入队列，可通过选项设置延迟、计划时间、优先级等
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (*Job, error)
+
+	// Dequeue 按给定顺序依次从队列中取出一个作业，所有队列为空时返回 nil, nil
+	// 取出的作业在可见性超时之前对其他消费者不可见
+	Dequeue(ctx context.Context, queues ...string) (*Job, error)
+
+	// Extend 将执行中作业的可见性截止时间延长一个可见性超时，租约已失效时返回错误
+	// 执行时间可能超过可见性超时的作业需要定期调用，避免在执行期间被重新投递
+	Extend(ctx context.Context, job *Job) error
+
+	// Ack 确认作业执行成功并删除
+	Ack(ctx context.Context, job *Job) error
+
+	// Fail 标记作业执行失败，可重试时按退避策略延迟重试，否则移入死信队列
+	Fail(ctx context.Context, job *Job, cause error) error
+
+	// Kill 直接将作业移入死信队列
+	Kill(ctx context.Context, job *Job, cause error) error
+
+	// Promote 将到期的延迟作业和可见性超时的作业移回就绪队列，返回移动的作业数
+	Promote(ctx context.Context, queue string) (int64, error)
+
+	// Stats 获取队列统计信息
+	Stats(ctx context.Context, queue string) (*Stats, error)
+
+	// DeadJobs 获取最近进入死信队列的作业
+	DeadJobs(ctx context.Context, queue string, limit int64) ([]*Job, error)
+
+	// RetryDead 将死信作业重新放回就绪队列
+	RetryDead(ctx context.Context, queue, id string) error
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"encoding/json"
+	"time"
+
+	"gobase/pkg/errors"
+)
+
+const (
+	// DefaultQueue 默认队列名称
+	DefaultQueue = "default"
+
+	// MaxPriority 最大优先级，数值越大越先被处理
+	MaxPriority = 9
+)
+
+// Job 作业
+type Job struct {
+	// ID 作业唯一标识
+	ID string `json:"id"`
+
+	// Type 作业类型，用于匹配处理器
+	Type string `json:"type"`
+
+	// Queue 所属队列
+	Queue string `json:"queue"`
+
+	// Payload 作业负载(JSON)
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// Priority 优先级 (0-9)
+	Priority int `json:"priority"`
+
+	// MaxRetries 最大重试次数，总执行次数为 MaxRetries+1
+	MaxRetries int `json:"max_retries"`
+
+	// EnqueuedAt 入队时间
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// ProcessAt 计划执行时间，为零值表示立即执行
+	ProcessAt time.Time `json:"process_at,omitempty"`
+
+	// LastError 最近一次失败原因
+	LastError string `json:"last_error,omitempty"`
+
+	// FailedAt 最近一次失败时间
+	FailedAt time.Time `json:"failed_at,omitempty"`
+
+	// Attempts 已投递次数，由队列在出队时维护，同时作为租约标识
+	Attempts int64 `json:"-"`
+}
+
+// Unmarshal 将负载解析到 v
+func (j *Job) Unmarshal(v interface{}) error {
+	if len(j.Payload) == 0 {
+		return errors.NewJobError("job payload is empty", nil)
+	}
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return errors.NewJobError("failed to unmarshal job payload", err)
+	}
+	return nil
+}
+
+// Retryable 判断作业是否还可以重试
+func (j *Job) Retryable() bool {
+	return j.Attempts <= int64(j.MaxRetries)
+}
+
+// Stats 队列统计信息
+type Stats struct {
+	Queue     string `json:"queue"`
+	Ready     int64  `json:"ready"`
+	Scheduled int64  `json:"scheduled"`
+	InFlight  int64  `json:"in_flight"`
+	Dead      int64  `json:"dead"`
+}
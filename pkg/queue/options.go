@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"math/rand"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// BackoffFunc 根据已执行次数计算下次重试的延迟
+type BackoffFunc func(attempts int64) time.Duration
+
+// ExponentialBackoff 返回带抖动的指数退避函数
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempts int64) time.Duration {
+		if attempts < 1 {
+			attempts = 1
+		}
+		delay := max
+		if shift := attempts - 1; shift < 32 {
+			if d := base << uint(shift); d > 0 && d < max {
+				delay = d
+			}
+		}
+		// 增加最多 20% 的抖动，避免大量作业同时重试
+		return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+	}
+}
+
+// Option 队列选项函数
+type Option func(*Options)
+
+// Options 队列配置
+type Options struct {
+	// KeyPrefix 键前缀
+	KeyPrefix string
+
+	// VisibilityTimeout 作业出队后对其他消费者不可见的时间
+	VisibilityTimeout time.Duration
+
+	// MaxRetries 默认最大重试次数
+	MaxRetries int
+
+	// Backoff 重试退避策略
+	Backoff BackoffFunc
+
+	// PromoteBatch 每次 Promote 最多移动的作业数
+	PromoteBatch int64
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		KeyPrefix:         "queue:",
+		VisibilityTimeout: 30 * time.Second,
+		MaxRetries:        3,
+		Backoff:           ExponentialBackoff(time.Second, 10*time.Minute),
+		PromoteBatch:      100,
+		Logger:            &types.NoopLogger{},
+	}
+}
+
+// WithKeyPrefix 设置键前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = prefix
+	}
+}
+
+// WithVisibilityTimeout 设置可见性超时
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.VisibilityTimeout = timeout
+	}
+}
+
+// WithMaxRetries 设置默认最大重试次数
+func WithMaxRetries(retries int) Option {
+	return func(o *Options) {
+		o.MaxRetries = retries
+	}
+}
+
+// WithBackoff 设置重试退避策略
+func WithBackoff(backoff BackoffFunc) Option {
+	return func(o *Options) {
+		o.Backoff = backoff
+	}
+}
+
+// WithPromoteBatch 设置每次 Promote 最多移动的作业数
+func WithPromoteBatch(batch int64) Option {
+	return func(o *Options) {
+		o.PromoteBatch = batch
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 验证配置
+func (o *Options) Validate() error {
+	if o.VisibilityTimeout <= 0 {
+		return errors.NewInvalidParamsError("visibility timeout must be positive", nil)
+	}
+	if o.MaxRetries < 0 {
+		return errors.NewInvalidParamsError("max retries cannot be negative", nil)
+	}
+	if o.PromoteBatch <= 0 {
+		return errors.NewInvalidParamsError("promote batch must be positive", nil)
+	}
+	if o.Backoff == nil {
+		o.Backoff = ExponentialBackoff(time.Second, 10*time.Minute)
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
+
+// EnqueueOption 入队选项函数
+type EnqueueOption func(*enqueueOptions)
+
+// enqueueOptions 入队配置
+type enqueueOptions struct {
+	id         string
+	queue      string
+	priority   int
+	maxRetries int
+	processAt  time.Time
+}
+
+// WithJobID 指定作业ID，相同ID的作业只会入队一次
+func WithJobID(id string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.id = id
+	}
+}
+
+// WithQueue 指定目标队列
+func WithQueue(name string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.queue = name
+	}
+}
+
+// WithPriority 设置优先级 (0-9)
+func WithPriority(priority int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.priority = priority
+	}
+}
+
+// WithJobMaxRetries 设置作业的最大重试次数
+func WithJobMaxRetries(retries int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxRetries = retries
+	}
+}
+
+// WithDelay 延迟指定时间后执行
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.processAt = time.Now().Add(delay)
+	}
+}
+
+// WithProcessAt 在指定时间执行
+func WithProcessAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.processAt = t
+	}
+}
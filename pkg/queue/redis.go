@@ -0,0 +1,369 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// redisQueue 基于Redis的作业队列
+type redisQueue struct {
+	client redis.Client
+	opts   *Options
+}
+
+// NewQueue 创建基于Redis的作业队列
+func NewQueue(client redis.Client, opts ...Option) (Queue, error) {
+	if client == nil {
+		return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &redisQueue{
+		client: client,
+		opts:   options,
+	}, nil
+}
+
+// Enqueue 将作业加入队列
+func (q *redisQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (*Job, error) {
+	if jobType == "" {
+		return nil, errors.NewInvalidParamsError("job type is required", nil)
+	}
+
+	eo := &enqueueOptions{queue: DefaultQueue, maxRetries: -1}
+	for _, opt := range opts {
+		opt(eo)
+	}
+	if eo.queue == "" {
+		return nil, errors.NewInvalidParamsError("queue name is required", nil)
+	}
+	if eo.priority < 0 || eo.priority > MaxPriority {
+		return nil, errors.NewInvalidParamsError("job priority must be between 0 and 9", nil)
+	}
+	if eo.id == "" {
+		eo.id = uuid.New().String()
+	}
+	if eo.maxRetries < 0 {
+		eo.maxRetries = q.opts.MaxRetries
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:         eo.id,
+		Type:       jobType,
+		Queue:      eo.queue,
+		Priority:   eo.priority,
+		MaxRetries: eo.maxRetries,
+		EnqueuedAt: now,
+	}
+
+	if payload != nil {
+		raw, ok := payload.(json.RawMessage)
+		if !ok {
+			var err error
+			if raw, err = json.Marshal(payload); err != nil {
+				return nil, errors.NewJobError("failed to marshal job payload", err)
+			}
+		}
+		job.Payload = raw
+	}
+
+	var processAt int64
+	if eo.processAt.After(now) {
+		job.ProcessAt = eo.processAt
+		processAt = eo.processAt.UnixMilli()
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, errors.NewJobError("failed to marshal job", err)
+	}
+
+	k := q.keys(job.Queue)
+	result, err := q.evalInt(ctx, enqueueScript, []string{k.jobs, k.ready, k.scheduled},
+		job.ID, data, readyScore(job.Priority, now), processAt)
+	if err != nil {
+		return nil, errors.NewJobError("failed to enqueue job", err)
+	}
+	if result == 0 {
+		return nil, errors.NewJobError("job with the same id already exists", nil)
+	}
+
+	q.opts.Logger.Debug(ctx, "job enqueued",
+		types.Field{Key: "job_id", Value: job.ID},
+		types.Field{Key: "job_type", Value: job.Type},
+		types.Field{Key: "queue", Value: job.Queue},
+	)
+	return job, nil
+}
+
+// Dequeue 从队列中取出一个作业
+func (q *redisQueue) Dequeue(ctx context.Context, queues ...string) (*Job, error) {
+	if len(queues) == 0 {
+		queues = []string{DefaultQueue}
+	}
+
+	deadline := time.Now().Add(q.opts.VisibilityTimeout).UnixMilli()
+	for _, name := range queues {
+		k := q.keys(name)
+		result, err := q.client.Eval(ctx, dequeueScript, []string{k.jobs, k.attempts, k.ready, k.inflight}, deadline)
+		if err != nil {
+			return nil, errors.NewJobError("failed to dequeue job", err)
+		}
+
+		values, ok := result.([]interface{})
+		if !ok || len(values) != 2 {
+			continue
+		}
+
+		data, _ := values[0].(string)
+		attempts, _ := values[1].(int64)
+
+		job := &Job{}
+		if err := json.Unmarshal([]byte(data), job); err != nil {
+			return nil, errors.NewJobError("failed to unmarshal job", err)
+		}
+		job.Attempts = attempts
+		return job, nil
+	}
+
+	return nil, nil
+}
+
+// Extend 延长执行中作业的可见性截止时间
+func (q *redisQueue) Extend(ctx context.Context, job *Job) error {
+	if job == nil {
+		return errors.NewInvalidParamsError("job cannot be nil", nil)
+	}
+
+	deadline := time.Now().Add(q.opts.VisibilityTimeout).UnixMilli()
+	k := q.keys(job.Queue)
+	result, err := q.evalInt(ctx, extendScript, []string{k.attempts, k.inflight}, job.ID, job.Attempts, deadline)
+	if err != nil {
+		return errors.NewJobError("failed to extend job lease", err)
+	}
+	if result == 0 {
+		return errors.NewJobError("job lease has expired", nil)
+	}
+	return nil
+}
+
+// Ack 确认作业执行成功
+func (q *redisQueue) Ack(ctx context.Context, job *Job) error {
+	if job == nil {
+		return errors.NewInvalidParamsError("job cannot be nil", nil)
+	}
+
+	k := q.keys(job.Queue)
+	result, err := q.evalInt(ctx, ackScript, []string{k.jobs, k.attempts, k.ready, k.inflight}, job.ID, job.Attempts)
+	if err != nil {
+		return errors.NewJobError("failed to ack job", err)
+	}
+	if result == 0 {
+		return errors.NewJobError("job lease has expired", nil)
+	}
+	return nil
+}
+
+// Fail 标记作业执行失败
+func (q *redisQueue) Fail(ctx context.Context, job *Job, cause error) error {
+	if job == nil {
+		return errors.NewInvalidParamsError("job cannot be nil", nil)
+	}
+	if !job.Retryable() {
+		return q.Kill(ctx, job, cause)
+	}
+
+	retryAt := time.Now().Add(q.opts.Backoff(job.Attempts))
+	k := q.keys(job.Queue)
+	if err := q.moveFailed(ctx, job, cause, k.scheduled, retryAt.UnixMilli()); err != nil {
+		return err
+	}
+
+	q.opts.Logger.Warn(ctx, "job failed, scheduled for retry",
+		types.Field{Key: "job_id", Value: job.ID},
+		types.Field{Key: "job_type", Value: job.Type},
+		types.Field{Key: "attempts", Value: job.Attempts},
+		types.Field{Key: "retry_at", Value: retryAt},
+		types.Error(cause),
+	)
+	return nil
+}
+
+// Kill 将作业移入死信队列
+func (q *redisQueue) Kill(ctx context.Context, job *Job, cause error) error {
+	if job == nil {
+		return errors.NewInvalidParamsError("job cannot be nil", nil)
+	}
+
+	k := q.keys(job.Queue)
+	if err := q.moveFailed(ctx, job, cause, k.dead, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+
+	q.opts.Logger.Error(ctx, "job moved to dead letter queue",
+		types.Field{Key: "job_id", Value: job.ID},
+		types.Field{Key: "job_type", Value: job.Type},
+		types.Field{Key: "attempts", Value: job.Attempts},
+		types.Error(cause),
+	)
+	return nil
+}
+
+// moveFailed 记录失败原因并将作业移入目标集合
+func (q *redisQueue) moveFailed(ctx context.Context, job *Job, cause error, target string, score int64) error {
+	job.FailedAt = time.Now()
+	if cause != nil {
+		job.LastError = cause.Error()
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.NewJobError("failed to marshal job", err)
+	}
+
+	k := q.keys(job.Queue)
+	result, err := q.evalInt(ctx, failScript, []string{k.jobs, k.attempts, k.ready, k.inflight, target},
+		job.ID, job.Attempts, data, score)
+	if err != nil {
+		return errors.NewJobError("failed to move failed job", err)
+	}
+	if result == 0 {
+		return errors.NewJobError("job lease has expired", nil)
+	}
+	return nil
+}
+
+// Promote 将到期作业移回就绪队列
+func (q *redisQueue) Promote(ctx context.Context, queue string) (int64, error) {
+	k := q.keys(queue)
+	moved, err := q.evalInt(ctx, promoteScript, []string{k.jobs, k.ready, k.scheduled, k.inflight},
+		time.Now().UnixMilli(), q.opts.PromoteBatch)
+	if err != nil {
+		return 0, errors.NewJobError("failed to promote jobs", err)
+	}
+	return moved, nil
+}
+
+// Stats 获取队列统计信息
+func (q *redisQueue) Stats(ctx context.Context, queue string) (*Stats, error) {
+	k := q.keys(queue)
+	result, err := q.client.Eval(ctx, statsScript, []string{k.ready, k.scheduled, k.inflight, k.dead})
+	if err != nil {
+		return nil, errors.NewJobError("failed to get queue stats", err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 4 {
+		return nil, errors.NewJobError("unexpected queue stats result", nil)
+	}
+
+	counts := make([]int64, 4)
+	for i, v := range values {
+		counts[i], _ = v.(int64)
+	}
+	return &Stats{
+		Queue:     queue,
+		Ready:     counts[0],
+		Scheduled: counts[1],
+		InFlight:  counts[2],
+		Dead:      counts[3],
+	}, nil
+}
+
+// DeadJobs 获取最近的死信作业
+func (q *redisQueue) DeadJobs(ctx context.Context, queue string, limit int64) ([]*Job, error) {
+	if limit <= 0 {
+		return nil, errors.NewInvalidParamsError("limit must be positive", nil)
+	}
+
+	k := q.keys(queue)
+	result, err := q.client.Eval(ctx, deadJobsScript, []string{k.jobs, k.attempts, k.dead}, limit)
+	if err != nil {
+		return nil, errors.NewJobError("failed to list dead jobs", err)
+	}
+
+	values, _ := result.([]interface{})
+	jobs := make([]*Job, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		data, _ := values[i].(string)
+		job := &Job{}
+		if err := json.Unmarshal([]byte(data), job); err != nil {
+			return nil, errors.NewJobError("failed to unmarshal job", err)
+		}
+		attempts, _ := values[i+1].(string)
+		job.Attempts, _ = strconv.ParseInt(attempts, 10, 64)
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// RetryDead 将死信作业重新放回就绪队列
+func (q *redisQueue) RetryDead(ctx context.Context, queue, id string) error {
+	k := q.keys(queue)
+	result, err := q.evalInt(ctx, retryDeadScript, []string{k.jobs, k.attempts, k.ready, k.dead},
+		id, time.Now().UnixMilli())
+	if err != nil {
+		return errors.NewJobError("failed to retry dead job", err)
+	}
+	if result == 0 {
+		return errors.NewJobError("job is not in dead letter queue", nil)
+	}
+	return nil
+}
+
+// evalInt 执行返回整数的脚本
+func (q *redisQueue) evalInt(ctx context.Context, script string, keys []string, args ...interface{}) (int64, error) {
+	result, err := q.client.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := result.(int64)
+	if !ok {
+		return 0, errors.NewRedisScriptError("unexpected queue script result", nil)
+	}
+	return value, nil
+}
+
+// queueKeys 单个队列使用的键
+type queueKeys struct {
+	jobs      string
+	attempts  string
+	ready     string
+	scheduled string
+	inflight  string
+	dead      string
+}
+
+// keys 返回队列使用的键，使用 hash tag 保证落在同一个槽
+func (q *redisQueue) keys(queue string) queueKeys {
+	base := q.opts.KeyPrefix + "{" + queue + "}:"
+	return queueKeys{
+		jobs:      base + "jobs",
+		attempts:  base + "attempts",
+		ready:     base + "ready",
+		scheduled: base + "scheduled",
+		inflight:  base + "inflight",
+		dead:      base + "dead",
+	}
+}
+
+// readyScore 计算就绪分值，优先级越高、入队越早的作业分值越小
+func readyScore(priority int, t time.Time) int64 {
+	return t.UnixMilli() - int64(priority)*1e13
+}
@@ -0,0 +1,170 @@
+package queue
+
+// 每个队列的键都使用同一个 hash tag，脚本可在集群模式下执行
+// jobs:      hash  作业ID -> 作业JSON
+// attempts:  hash  作业ID -> 投递次数(租约标识)
+// ready:     zset  就绪作业，分值越小越先出队
+// scheduled: zset  延迟/重试作业，分值为计划执行时间(毫秒)
+// inflight:  zset  执行中作业，分值为可见性截止时间(毫秒)
+// dead:      zset  死信作业，分值为进入时间(毫秒)
+
+// readyScoreLua 根据优先级计算就绪分值，与 readyScore 保持一致
+const readyScoreLua = `
+local function ready_score(jobs, id, now)
+	local data = redis.call('HGET', jobs, id)
+	if not data then
+		return nil
+	end
+	local job = cjson.decode(data)
+	return now - (tonumber(job.priority) or 0) * 1e13
+end
+`
+
+// enqueueScript 入队
+// KEYS: jobs, ready, scheduled
+// ARGV: id, data, ready score, process at(毫秒，0表示立即执行)
+// 返回: 1 成功，0 作业ID已存在
+const enqueueScript = `
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if tonumber(ARGV[4]) > 0 then
+	redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+else
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+end
+return 1
+`
+
+// dequeueScript 出队并进入执行中状态
+// KEYS: jobs, attempts, ready, inflight
+// ARGV: visibility deadline(毫秒)
+// 返回: {data, attempts}，队列为空时返回空数组
+const dequeueScript = `
+while true do
+	local ids = redis.call('ZRANGE', KEYS[3], 0, 0)
+	if #ids == 0 then
+		return {}
+	end
+	local id = ids[1]
+	redis.call('ZREM', KEYS[3], id)
+	local data = redis.call('HGET', KEYS[1], id)
+	if data then
+		local attempts = redis.call('HINCRBY', KEYS[2], id, 1)
+		redis.call('ZADD', KEYS[4], ARGV[1], id)
+		return {data, attempts}
+	end
+end
+`
+
+// extendScript 延长执行中作业的可见性截止时间
+// KEYS: attempts, inflight
+// ARGV: id, attempts, visibility deadline(毫秒)
+// 返回: 1 成功，0 租约已失效
+const extendScript = `
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+if not redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`
+
+// ackScript 确认作业完成
+// KEYS: jobs, attempts, ready, inflight
+// ARGV: id, attempts
+// 返回: 1 成功，0 租约已失效
+const ackScript = `
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[4], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`
+
+// failScript 将失败作业移入重试队列或死信队列
+// KEYS: jobs, attempts, ready, inflight, target
+// ARGV: id, attempts, data, score
+// 返回: 1 成功，0 租约已失效
+const failScript = `
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[4], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[5], ARGV[4], ARGV[1])
+return 1
+`
+
+// promoteScript 将到期的延迟作业和可见性超时的作业移回就绪队列
+// KEYS: jobs, ready, scheduled, inflight
+// ARGV: now(毫秒), batch
+// 返回: 移动的作业数
+const promoteScript = readyScoreLua + `
+local now = tonumber(ARGV[1])
+local moved = 0
+for _, source in ipairs({KEYS[3], KEYS[4]}) do
+	local ids = redis.call('ZRANGEBYSCORE', source, '-inf', now, 'LIMIT', 0, ARGV[2])
+	for _, id in ipairs(ids) do
+		redis.call('ZREM', source, id)
+		local score = ready_score(KEYS[1], id, now)
+		if score then
+			redis.call('ZADD', KEYS[2], score, id)
+			moved = moved + 1
+		end
+	end
+end
+return moved
+`
+
+// retryDeadScript 将死信作业放回就绪队列
+// KEYS: jobs, attempts, ready, dead
+// ARGV: id, now(毫秒)
+// 返回: 1 成功，0 作业不在死信队列中
+const retryDeadScript = readyScoreLua + `
+if redis.call('ZREM', KEYS[4], ARGV[1]) == 0 then
+	return 0
+end
+local score = ready_score(KEYS[1], ARGV[1], tonumber(ARGV[2]))
+if not score then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[3], score, ARGV[1])
+return 1
+`
+
+// deadJobsScript 获取最近的死信作业
+// KEYS: jobs, attempts, dead
+// ARGV: limit
+// 返回: {data1, attempts1, data2, attempts2, ...}
+const deadJobsScript = `
+local ids = redis.call('ZREVRANGE', KEYS[3], 0, tonumber(ARGV[1]) - 1)
+local result = {}
+for _, id in ipairs(ids) do
+	local data = redis.call('HGET', KEYS[1], id)
+	if data then
+		table.insert(result, data)
+		table.insert(result, redis.call('HGET', KEYS[2], id) or '0')
+	end
+end
+return result
+`
+
+// statsScript 获取队列统计信息
+// KEYS: ready, scheduled, inflight, dead
+const statsScript = `
+return {
+	redis.call('ZCARD', KEYS[1]),
+	redis.call('ZCARD', KEYS[2]),
+	redis.call('ZCARD', KEYS[3]),
+	redis.call('ZCARD', KEYS[4])
+}
+`
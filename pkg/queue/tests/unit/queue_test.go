@@ -0,0 +1,234 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/queue"
+)
+
+// newTestQueue 创建连接到 miniredis 的队列
+func newTestQueue(t *testing.T, opts ...queue.Option) (*miniredis.Miniredis, queue.Queue) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	q, err := queue.NewQueue(client, opts...)
+	require.NoError(t, err)
+	return mr, q
+}
+
+type emailPayload struct {
+	To string `json:"to"`
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("enqueue dequeue ack", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		job, err := q.Enqueue(ctx, "email:send", emailPayload{To: "a@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, queue.DefaultQueue, job.Queue)
+
+		got, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, job.ID, got.ID)
+		assert.Equal(t, int64(1), got.Attempts)
+
+		var payload emailPayload
+		require.NoError(t, got.Unmarshal(&payload))
+		assert.Equal(t, "a@example.com", payload.To)
+
+		empty, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, empty)
+
+		require.NoError(t, q.Ack(ctx, got))
+		stats, err := q.Stats(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, &queue.Stats{Queue: queue.DefaultQueue}, stats)
+	})
+
+	t.Run("duplicate job id", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		_, err := q.Enqueue(ctx, "report", nil, queue.WithJobID("daily"))
+		require.NoError(t, err)
+		_, err = q.Enqueue(ctx, "report", nil, queue.WithJobID("daily"))
+		assert.Error(t, err)
+	})
+
+	t.Run("priority order", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		low, err := q.Enqueue(ctx, "task", nil)
+		require.NoError(t, err)
+		high, err := q.Enqueue(ctx, "task", nil, queue.WithPriority(queue.MaxPriority))
+		require.NoError(t, err)
+
+		first, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, high.ID, first.ID)
+
+		second, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, low.ID, second.ID)
+
+		_, err = q.Enqueue(ctx, "task", nil, queue.WithPriority(queue.MaxPriority+1))
+		assert.Error(t, err)
+	})
+
+	t.Run("queue order", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		_, err := q.Enqueue(ctx, "task", nil)
+		require.NoError(t, err)
+		critical, err := q.Enqueue(ctx, "task", nil, queue.WithQueue("critical"))
+		require.NoError(t, err)
+
+		job, err := q.Dequeue(ctx, "critical", queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, critical.ID, job.ID)
+	})
+
+	t.Run("delayed job", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		_, err := q.Enqueue(ctx, "task", nil, queue.WithDelay(50*time.Millisecond))
+		require.NoError(t, err)
+
+		job, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, job)
+
+		moved, err := q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), moved)
+
+		time.Sleep(60 * time.Millisecond)
+		moved, err = q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), moved)
+
+		job, err = q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, job)
+	})
+
+	t.Run("visibility timeout redelivers job", func(t *testing.T) {
+		_, q := newTestQueue(t, queue.WithVisibilityTimeout(20*time.Millisecond))
+
+		_, err := q.Enqueue(ctx, "task", nil)
+		require.NoError(t, err)
+
+		first, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+
+		time.Sleep(30 * time.Millisecond)
+		moved, err := q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), moved)
+
+		second, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+		assert.Equal(t, int64(2), second.Attempts)
+
+		// 旧租约不能再确认作业
+		assert.Error(t, q.Ack(ctx, first))
+		assert.NoError(t, q.Ack(ctx, second))
+	})
+
+	t.Run("extend postpones redelivery", func(t *testing.T) {
+		_, q := newTestQueue(t, queue.WithVisibilityTimeout(40*time.Millisecond))
+
+		_, err := q.Enqueue(ctx, "task", nil)
+		require.NoError(t, err)
+		job, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+
+		time.Sleep(25 * time.Millisecond)
+		require.NoError(t, q.Extend(ctx, job))
+		time.Sleep(25 * time.Millisecond)
+
+		moved, err := q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Zero(t, moved)
+
+		// 重新投递后旧租约不能再续期
+		time.Sleep(50 * time.Millisecond)
+		_, err = q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		second, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Error(t, q.Extend(ctx, job))
+		assert.NoError(t, q.Extend(ctx, second))
+		assert.NoError(t, q.Ack(ctx, second))
+		assert.Error(t, q.Extend(ctx, second))
+	})
+
+	t.Run("retry then dead letter", func(t *testing.T) {
+		_, q := newTestQueue(t, queue.WithBackoff(func(int64) time.Duration { return 0 }))
+
+		_, err := q.Enqueue(ctx, "task", nil, queue.WithJobMaxRetries(1))
+		require.NoError(t, err)
+
+		job, err := q.Dequeue(ctx)
+		require.NoError(t, err)
+		require.NoError(t, q.Fail(ctx, job, errors.NewExecutionError("boom", nil)))
+
+		stats, err := q.Stats(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stats.Scheduled)
+
+		time.Sleep(5 * time.Millisecond)
+		_, err = q.Promote(ctx, queue.DefaultQueue)
+		require.NoError(t, err)
+
+		job, err = q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), job.Attempts)
+		assert.Contains(t, job.LastError, "boom")
+		require.NoError(t, q.Fail(ctx, job, errors.NewExecutionError("boom again", nil)))
+
+		dead, err := q.DeadJobs(ctx, queue.DefaultQueue, 10)
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+		assert.Equal(t, job.ID, dead[0].ID)
+		assert.Equal(t, int64(2), dead[0].Attempts)
+
+		require.NoError(t, q.RetryDead(ctx, queue.DefaultQueue, job.ID))
+		job, err = q.Dequeue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), job.Attempts)
+
+		assert.Error(t, q.RetryDead(ctx, queue.DefaultQueue, "missing"))
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := queue.ExponentialBackoff(time.Second, 10*time.Second)
+
+	assert.GreaterOrEqual(t, backoff(1), time.Second)
+	assert.Less(t, backoff(1), 2*time.Second)
+	assert.GreaterOrEqual(t, backoff(3), 4*time.Second)
+	assert.GreaterOrEqual(t, backoff(100), 10*time.Second)
+	assert.LessOrEqual(t, backoff(100), 12*time.Second)
+}
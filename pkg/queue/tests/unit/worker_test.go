@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/queue"
+	"gobase/pkg/queue/worker"
+)
+
+func TestWorker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("processes and retries jobs", func(t *testing.T) {
+		_, q := newTestQueue(t, queue.WithBackoff(func(int64) time.Duration { return 0 }))
+
+		var calls int32
+		w, err := worker.New(q,
+			worker.WithConcurrency(2),
+			worker.WithPollInterval(5*time.Millisecond),
+			worker.WithPromoteInterval(5*time.Millisecond),
+		)
+		require.NoError(t, err)
+		w.HandleFunc("flaky", func(ctx context.Context, job *queue.Job) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return errors.NewExecutionError("first attempt fails", nil)
+			}
+			return nil
+		})
+
+		_, err = q.Enqueue(ctx, "flaky", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, w.Start(ctx))
+		assert.Eventually(t, func() bool {
+			stats, err := q.Stats(ctx, queue.DefaultQueue)
+			return err == nil && atomic.LoadInt32(&calls) == 2 &&
+				stats.Ready+stats.Scheduled+stats.InFlight+stats.Dead == 0
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, w.Shutdown(ctx))
+	})
+
+	t.Run("unknown type and panic go to dead letter", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		w, err := worker.New(q, worker.WithPollInterval(5*time.Millisecond))
+		require.NoError(t, err)
+		w.HandleFunc("panic", func(ctx context.Context, job *queue.Job) error {
+			panic("unexpected")
+		})
+
+		_, err = q.Enqueue(ctx, "panic", nil, queue.WithJobMaxRetries(0))
+		require.NoError(t, err)
+		_, err = q.Enqueue(ctx, "unknown", nil, queue.WithJobMaxRetries(0))
+		require.NoError(t, err)
+
+		require.NoError(t, w.Start(ctx))
+		assert.Eventually(t, func() bool {
+			stats, err := q.Stats(ctx, queue.DefaultQueue)
+			return err == nil && stats.Dead == 2
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, w.Shutdown(ctx))
+
+		dead, err := q.DeadJobs(ctx, queue.DefaultQueue, 10)
+		require.NoError(t, err)
+		for _, job := range dead {
+			assert.NotEmpty(t, job.LastError)
+		}
+	})
+
+	t.Run("graceful shutdown waits for running job", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		started := make(chan struct{})
+		var finished int32
+		w, err := worker.New(q, worker.WithConcurrency(1), worker.WithPollInterval(5*time.Millisecond))
+		require.NoError(t, err)
+		w.HandleFunc("slow", func(ctx context.Context, job *queue.Job) error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&finished, 1)
+			return nil
+		})
+
+		_, err = q.Enqueue(ctx, "slow", nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Start(ctx))
+		<-started
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, w.Shutdown(shutdownCtx))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+	})
+
+	t.Run("shutdown timeout cancels running job", func(t *testing.T) {
+		_, q := newTestQueue(t)
+
+		started := make(chan struct{})
+		w, err := worker.New(q, worker.WithConcurrency(1), worker.WithPollInterval(5*time.Millisecond))
+		require.NoError(t, err)
+		w.HandleFunc("stuck", func(ctx context.Context, job *queue.Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		_, err = q.Enqueue(ctx, "stuck", nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Start(ctx))
+		<-started
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		assert.Error(t, w.Shutdown(shutdownCtx))
+	})
+
+	t.Run("heartbeat keeps long job invisible", func(t *testing.T) {
+		_, q := newTestQueue(t, queue.WithVisibilityTimeout(40*time.Millisecond))
+
+		var calls int32
+		w, err := worker.New(q,
+			worker.WithConcurrency(2),
+			worker.WithPollInterval(5*time.Millisecond),
+			worker.WithPromoteInterval(5*time.Millisecond),
+			worker.WithHeartbeatInterval(10*time.Millisecond),
+		)
+		require.NoError(t, err)
+		w.HandleFunc("long", func(ctx context.Context, job *queue.Job) error {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(150 * time.Millisecond)
+			return nil
+		})
+
+		_, err = q.Enqueue(ctx, "long", nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Start(ctx))
+
+		assert.Eventually(t, func() bool {
+			stats, err := q.Stats(ctx, queue.DefaultQueue)
+			return err == nil && stats.Ready+stats.Scheduled+stats.InFlight+stats.Dead == 0
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, w.Shutdown(ctx))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, q := newTestQueue(t)
+		_, err := worker.New(q, worker.WithConcurrency(0))
+		assert.Error(t, err)
+		_, err = worker.New(q, worker.WithHeartbeatInterval(0))
+		assert.Error(t, err)
+		_, err = worker.New(nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/queue"
+)
+
+// Option 工作器选项函数
+type Option func(*Options)
+
+// Options 工作器配置
+type Options struct {
+	// Queues 消费的队列，按严格优先级从前往后获取
+	Queues []string
+
+	// Concurrency 并发处理的作业数
+	Concurrency int
+
+	// PollInterval 队列为空时的轮询间隔
+	PollInterval time.Duration
+
+	// PromoteInterval 移动到期作业的间隔
+	PromoteInterval time.Duration
+
+	// JobTimeout 单个作业的执行超时
+	JobTimeout time.Duration
+
+	// HeartbeatInterval 作业执行期间延长可见性超时的间隔，应小于队列的可见性超时
+	HeartbeatInterval time.Duration
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Queues:            []string{queue.DefaultQueue},
+		Concurrency:       10,
+		PollInterval:      time.Second,
+		PromoteInterval:   time.Second,
+		JobTimeout:        25 * time.Second,
+		HeartbeatInterval: 10 * time.Second,
+		Logger:            &types.NoopLogger{},
+	}
+}
+
+// WithQueues 设置消费的队列
+func WithQueues(queues ...string) Option {
+	return func(o *Options) {
+		o.Queues = queues
+	}
+}
+
+// WithConcurrency 设置并发数
+func WithConcurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// WithPollInterval 设置轮询间隔
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = interval
+	}
+}
+
+// WithPromoteInterval 设置移动到期作业的间隔
+func WithPromoteInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.PromoteInterval = interval
+	}
+}
+
+// WithJobTimeout 设置作业执行超时
+func WithJobTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.JobTimeout = timeout
+	}
+}
+
+// WithHeartbeatInterval 设置延长可见性超时的间隔
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.HeartbeatInterval = interval
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 验证配置
+func (o *Options) Validate() error {
+	if len(o.Queues) == 0 {
+		return errors.NewInvalidParamsError("at least one queue is required", nil)
+	}
+	if o.Concurrency <= 0 {
+		return errors.NewInvalidParamsError("concurrency must be positive", nil)
+	}
+	if o.PollInterval <= 0 {
+		return errors.NewInvalidParamsError("poll interval must be positive", nil)
+	}
+	if o.PromoteInterval <= 0 {
+		return errors.NewInvalidParamsError("promote interval must be positive", nil)
+	}
+	if o.JobTimeout <= 0 {
+		return errors.NewInvalidParamsError("job timeout must be positive", nil)
+	}
+	if o.HeartbeatInterval <= 0 {
+		return errors.NewInvalidParamsError("heartbeat interval must be positive", nil)
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
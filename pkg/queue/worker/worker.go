@@ -0,0 +1,280 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/queue"
+)
+
+// Handler 作业处理器
+type Handler interface {
+	ProcessJob(ctx context.Context, job *queue.Job) error
+}
+
+// HandlerFunc 函数形式的作业处理器
+type HandlerFunc func(ctx context.Context, job *queue.Job) error
+
+// ProcessJob 实现 Handler 接口
+func (f HandlerFunc) ProcessJob(ctx context.Context, job *queue.Job) error {
+	return f(ctx, job)
+}
+
+// Worker 作业工作器
+type Worker struct {
+	queue    queue.Queue
+	opts     *Options
+	handlers map[string]Handler
+	mu       sync.RWMutex
+
+	started bool
+	stop    chan struct{}
+	// jobCtx 作业执行使用的上下文，仅在优雅关闭超时后取消
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// New 创建工作器
+func New(q queue.Queue, opts ...Option) (*Worker, error) {
+	if q == nil {
+		return nil, errors.NewInvalidParamsError("queue cannot be nil", nil)
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Worker{
+		queue:    q,
+		opts:     options,
+		handlers: make(map[string]Handler),
+	}, nil
+}
+
+// Handle 注册作业处理器
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// HandleFunc 注册函数形式的作业处理器
+func (w *Worker) HandleFunc(jobType string, fn func(ctx context.Context, job *queue.Job) error) {
+	w.Handle(jobType, HandlerFunc(fn))
+}
+
+// Start 启动工作器，立即返回
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started {
+		return errors.NewTaskError("worker already started", nil)
+	}
+	w.started = true
+	w.stop = make(chan struct{})
+	w.jobCtx, w.jobCancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	w.wg.Add(1)
+	go w.promote()
+
+	for i := 0; i < w.opts.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.consume()
+	}
+
+	w.opts.Logger.Info(ctx, "worker started",
+		types.Field{Key: "queues", Value: w.opts.Queues},
+		types.Field{Key: "concurrency", Value: w.opts.Concurrency},
+	)
+	return nil
+}
+
+// Shutdown 优雅关闭工作器
+// 停止获取新作业并等待执行中的作业完成，ctx 结束时取消仍在执行的作业，
+// 这些作业会在可见性超时后被重新投递
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.started {
+		w.mu.Unlock()
+		return nil
+	}
+	w.started = false
+	close(w.stop)
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.jobCancel()
+		w.opts.Logger.Info(ctx, "worker stopped gracefully")
+		return nil
+	case <-ctx.Done():
+		w.jobCancel()
+		<-done
+		w.opts.Logger.Warn(ctx, "worker shutdown timed out, running jobs cancelled")
+		return errors.NewTaskError("worker shutdown timed out", ctx.Err())
+	}
+}
+
+// consume 消费循环
+func (w *Worker) consume() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		job, err := w.queue.Dequeue(w.jobCtx, w.opts.Queues...)
+		if err != nil {
+			w.opts.Logger.Error(w.jobCtx, "failed to dequeue job", types.Error(err))
+		}
+		if job == nil {
+			if !w.sleep(w.opts.PollInterval) {
+				return
+			}
+			continue
+		}
+
+		w.process(job)
+	}
+}
+
+// process 执行单个作业并根据结果确认或标记失败
+func (w *Worker) process(job *queue.Job) {
+	ctx := w.jobCtx
+
+	// 可见性超时后重复投递导致超过最大执行次数，直接进入死信队列
+	if job.Attempts > int64(job.MaxRetries)+1 {
+		if err := w.queue.Kill(ctx, job, errors.NewExecutionError("job exceeded max attempts", nil)); err != nil {
+			w.opts.Logger.Error(ctx, "failed to kill job", types.Field{Key: "job_id", Value: job.ID}, types.Error(err))
+		}
+		return
+	}
+
+	w.mu.RLock()
+	handler, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = errors.NewJobError(fmt.Sprintf("no handler registered for job type %q", job.Type), nil)
+	} else {
+		stopHeartbeat := w.heartbeat(ctx, job)
+		err = w.run(ctx, handler, job)
+		stopHeartbeat()
+	}
+
+	if err == nil {
+		if ackErr := w.queue.Ack(ctx, job); ackErr != nil {
+			w.opts.Logger.Error(ctx, "failed to ack job", types.Field{Key: "job_id", Value: job.ID}, types.Error(ackErr))
+		}
+		return
+	}
+
+	if failErr := w.queue.Fail(ctx, job, err); failErr != nil {
+		w.opts.Logger.Error(ctx, "failed to mark job as failed", types.Field{Key: "job_id", Value: job.ID}, types.Error(failErr))
+	}
+}
+
+// run 在超时控制下执行处理器，并将 panic 转换为错误
+func (w *Worker) run(ctx context.Context, handler Handler, job *queue.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.JobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.NewExecutionError(fmt.Sprintf("job panicked: %v", r), nil)
+		}
+	}()
+
+	if err := handler.ProcessJob(ctx, job); err != nil {
+		return errors.NewExecutionError("job execution failed", err)
+	}
+	return nil
+}
+
+// heartbeat 在作业执行期间定期延长可见性超时，返回停止函数
+func (w *Worker) heartbeat(ctx context.Context, job *queue.Job) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.opts.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// 失败时在下一个间隔重试，租约已失效的作业会在确认时失败
+				if err := w.queue.Extend(ctx, job); err != nil {
+					w.opts.Logger.Warn(ctx, "failed to extend job lease",
+						types.Field{Key: "job_id", Value: job.ID},
+						types.Error(err),
+					)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// promote 定期将到期作业移回就绪队列
+func (w *Worker) promote() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.PromoteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			for _, name := range w.opts.Queues {
+				if _, err := w.queue.Promote(w.jobCtx, name); err != nil {
+					w.opts.Logger.Error(w.jobCtx, "failed to promote jobs",
+						types.Field{Key: "queue", Value: name},
+						types.Error(err),
+					)
+				}
+			}
+		}
+	}
+}
+
+// sleep 等待指定时间，收到停止信号时返回 false
+func (w *Worker) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-w.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
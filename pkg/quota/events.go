@@ -0,0 +1,85 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	// SoftLimitReached 用量达到软限制，每个周期只发布一次
+	SoftLimitReached EventType = "quota_soft_limit_reached"
+	// QuotaExhausted 配额耗尽导致扣减被拒绝，每个周期只发布一次，追加配额后可再次发布
+	QuotaExhausted EventType = "quota_exhausted"
+	// QuotaToppedUp 管理员追加配额
+	QuotaToppedUp EventType = "quota_topped_up"
+	// QuotaReset 管理员重置用量
+	QuotaReset EventType = "quota_reset"
+)
+
+// Event 配额事件
+type Event struct {
+	// 事件ID
+	ID string `json:"id"`
+	// 事件类型
+	Type EventType `json:"type"`
+	// 事件时间
+	Timestamp time.Time `json:"timestamp"`
+	// 事件发生时的用量
+	Usage Usage `json:"usage"`
+}
+
+// Publisher 配额事件发布接口
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// DefaultChannel 默认的事件发布频道
+const DefaultChannel = "quota:events"
+
+// redisPublisher 基于Redis发布订阅的事件发布器
+type redisPublisher struct {
+	client  redis.Client
+	channel string
+}
+
+// NewRedisPublisher 创建基于Redis发布订阅的事件发布器，channel 为空时使用 DefaultChannel
+func NewRedisPublisher(client redis.Client, channel string) (Publisher, error) {
+	if client == nil {
+		return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+	}
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	return &redisPublisher{client: client, channel: channel}, nil
+}
+
+// Publish 发布事件
+func (p *redisPublisher) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.NewSerializationError("failed to marshal quota event", err)
+	}
+	if err := p.client.Publish(ctx, p.channel, string(payload)); err != nil {
+		return errors.NewThirdPartyError("failed to publish quota event to redis", err)
+	}
+	return nil
+}
+
+// newEvent 创建事件
+func newEvent(eventType EventType, usage Usage, now time.Time) *Event {
+	return &Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		Timestamp: now,
+		Usage:     usage,
+	}
+}
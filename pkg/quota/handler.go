@@ -0,0 +1,111 @@
+package quota
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+)
+
+// Handler 配额管理 HTTP 接口
+// 路由不包含鉴权，应注册到已挂载管理员鉴权中间件的路由组上
+type Handler struct {
+	manager Manager
+}
+
+// NewHandler 创建配额管理 HTTP 接口
+func NewHandler(manager Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// Register 注册路由
+//
+//	GET  /:tenant                 查询当前用量
+//	PUT  /:tenant/:period/limit   设置配额 {"limit": 1000}
+//	POST /:tenant/:period/topup   追加配额 {"amount": 100}
+//	POST /:tenant/:period/reset   重置当前周期用量
+func (h *Handler) Register(r gin.IRouter) {
+	r.GET("/:tenant", h.usage)
+	r.PUT("/:tenant/:period/limit", h.setLimit)
+	r.POST("/:tenant/:period/topup", h.topUp)
+	r.POST("/:tenant/:period/reset", h.reset)
+}
+
+// limitRequest 设置配额请求
+type limitRequest struct {
+	Limit *int64 `json:"limit" binding:"required"`
+}
+
+// topUpRequest 追加配额请求
+type topUpRequest struct {
+	Amount int64 `json:"amount" binding:"required"`
+}
+
+func (h *Handler) usage(c *gin.Context) {
+	usages, err := h.manager.Usage(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"usages": usages})
+}
+
+func (h *Handler) setLimit(c *gin.Context) {
+	var req limitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errors.NewInvalidParamsError("invalid request body", err))
+		return
+	}
+
+	tenant, period := c.Param("tenant"), Period(c.Param("period"))
+	if err := h.manager.SetLimit(c.Request.Context(), tenant, period, *req.Limit); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) topUp(c *gin.Context) {
+	var req topUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errors.NewInvalidParamsError("invalid request body", err))
+		return
+	}
+
+	usage, err := h.manager.TopUp(c.Request.Context(), c.Param("tenant"), Period(c.Param("period")), req.Amount)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+func (h *Handler) reset(c *gin.Context) {
+	if err := h.manager.Reset(c.Request.Context(), c.Param("tenant"), Period(c.Param("period"))); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writeError 按错误码写入错误响应
+func writeError(c *gin.Context, err error) {
+	code := errors.GetErrorCode(err)
+
+	status := http.StatusInternalServerError
+	switch code {
+	case codes.InvalidParams:
+		status = http.StatusBadRequest
+	case codes.QuotaNotConfigured:
+		status = http.StatusNotFound
+	case codes.QuotaExceeded:
+		status = http.StatusTooManyRequests
+	}
+
+	c.JSON(status, gin.H{
+		"code":    code,
+		"message": errors.GetErrorMessage(err),
+	})
+}
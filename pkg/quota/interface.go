@@ -0,0 +1,45 @@
+package quota
+
+import (
+	"context"
+	"time"
+)
+
+// Manager 租户配额管理接口
+type Manager interface {
+	// Consume 从租户所有受限周期中扣减 n 个单位
+	// 任一周期剩余不足时全部不扣减，返回 QuotaExceeded 错误以及当前用量
+	Consume(ctx context.Context, tenant string, n int64) ([]Usage, error)
+
+	// Usage 查询租户当前各受限周期的用量
+	Usage(ctx context.Context, tenant string) ([]Usage, error)
+
+	// TopUp 为租户当前周期追加配额，仅对本周期有效
+	TopUp(ctx context.Context, tenant string, period Period, amount int64) (*Usage, error)
+
+	// Reset 清空租户当前周期的用量和追加配额
+	Reset(ctx context.Context, tenant string, period Period) error
+
+	// SetLimit 设置租户在周期内的配额，覆盖默认配额
+	SetLimit(ctx context.Context, tenant string, period Period, limit int64) error
+}
+
+// Usage 租户在一个周期内的用量
+type Usage struct {
+	// Tenant 租户标识
+	Tenant string `json:"tenant"`
+	// Period 周期
+	Period Period `json:"period"`
+	// Limit 周期配额
+	Limit int64 `json:"limit"`
+	// Bonus 本周期追加的配额
+	Bonus int64 `json:"bonus"`
+	// Used 已使用量
+	Used int64 `json:"used"`
+	// Remaining 剩余可用量
+	Remaining int64 `json:"remaining"`
+	// PeriodStart 周期开始时间
+	PeriodStart time.Time `json:"period_start"`
+	// ResetAt 周期结束(重置)时间
+	ResetAt time.Time `json:"reset_at"`
+}
@@ -0,0 +1,176 @@
+package quota
+
+import (
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// Option 定义选项函数类型
+type Option func(*Options)
+
+// Options 配额管理器配置
+type Options struct {
+	// KeyPrefix 存储键前缀
+	KeyPrefix string
+
+	// Location 周期对齐使用的时区，默认 UTC
+	Location *time.Location
+
+	// Timezone 时区名称，如 "Asia/Shanghai"，设置后覆盖 Location
+	Timezone string
+
+	// Periods 参与计量的周期
+	Periods []Period
+
+	// DefaultLimits 租户未单独配置时使用的默认配额，未出现的周期不限制
+	DefaultLimits map[Period]int64
+
+	// SoftLimitRatio 软限制比例，用量达到 (配额+充值) * 比例时发布预警事件，0 表示不预警
+	SoftLimitRatio float64
+
+	// Retention 周期结束后用量数据的保留时间
+	Retention time.Duration
+
+	// Publisher 事件发布器，为空时不发布事件
+	Publisher Publisher
+
+	// Clock 当前时间函数
+	Clock func() time.Time
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		KeyPrefix:      "quota:",
+		Location:       time.UTC,
+		Periods:        []Period{PeriodDaily, PeriodMonthly},
+		DefaultLimits:  map[Period]int64{},
+		SoftLimitRatio: 0.8,
+		Retention:      24 * time.Hour,
+		Clock:          time.Now,
+		Logger:         &types.NoopLogger{},
+	}
+}
+
+// WithKeyPrefix 设置存储键前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = prefix
+	}
+}
+
+// WithLocation 设置周期对齐使用的时区
+func WithLocation(loc *time.Location) Option {
+	return func(o *Options) {
+		o.Location = loc
+	}
+}
+
+// WithTimezone 按名称设置周期对齐使用的时区
+func WithTimezone(name string) Option {
+	return func(o *Options) {
+		o.Timezone = name
+	}
+}
+
+// WithPeriods 设置参与计量的周期
+func WithPeriods(periods ...Period) Option {
+	return func(o *Options) {
+		o.Periods = periods
+	}
+}
+
+// WithDefaultLimit 设置周期的默认配额
+func WithDefaultLimit(period Period, limit int64) Option {
+	return func(o *Options) {
+		o.DefaultLimits[period] = limit
+	}
+}
+
+// WithSoftLimitRatio 设置软限制比例
+func WithSoftLimitRatio(ratio float64) Option {
+	return func(o *Options) {
+		o.SoftLimitRatio = ratio
+	}
+}
+
+// WithRetention 设置周期结束后用量数据的保留时间
+func WithRetention(retention time.Duration) Option {
+	return func(o *Options) {
+		o.Retention = retention
+	}
+}
+
+// WithPublisher 设置事件发布器
+func WithPublisher(publisher Publisher) Option {
+	return func(o *Options) {
+		o.Publisher = publisher
+	}
+}
+
+// WithClock 设置当前时间函数
+func WithClock(clock func() time.Time) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 验证配置
+func (o *Options) Validate() error {
+	if o.Timezone != "" {
+		loc, err := time.LoadLocation(o.Timezone)
+		if err != nil {
+			return errors.NewQuotaConfigError("invalid timezone: "+o.Timezone, err)
+		}
+		o.Location = loc
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	if len(o.Periods) == 0 {
+		return errors.NewQuotaConfigError("at least one period is required", nil)
+	}
+	seen := make(map[Period]bool, len(o.Periods))
+	for _, p := range o.Periods {
+		if !p.Valid() {
+			return errors.NewQuotaConfigError("unsupported period: "+string(p), nil)
+		}
+		if seen[p] {
+			return errors.NewQuotaConfigError("duplicate period: "+string(p), nil)
+		}
+		seen[p] = true
+	}
+	for p, limit := range o.DefaultLimits {
+		if !p.Valid() {
+			return errors.NewQuotaConfigError("unsupported period: "+string(p), nil)
+		}
+		if limit < 0 {
+			return errors.NewQuotaConfigError("default limit cannot be negative", nil)
+		}
+	}
+	if o.SoftLimitRatio < 0 || o.SoftLimitRatio > 1 {
+		return errors.NewQuotaConfigError("soft limit ratio must be in [0, 1]", nil)
+	}
+	if o.Retention < 0 {
+		return errors.NewQuotaConfigError("retention cannot be negative", nil)
+	}
+	if o.Clock == nil {
+		o.Clock = time.Now
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package quota
+
+import (
+	"time"
+)
+
+// Period 配额周期
+type Period string
+
+const (
+	// PeriodDaily 自然日，在配置时区的零点重置
+	PeriodDaily Period = "daily"
+	// PeriodMonthly 自然月，在配置时区每月一日零点重置
+	PeriodMonthly Period = "monthly"
+)
+
+// Valid 判断周期是否受支持
+func (p Period) Valid() bool {
+	return p == PeriodDaily || p == PeriodMonthly
+}
+
+// Bounds 返回 t 在时区 loc 中所属周期的起止时间，区间为 [start, end)
+func (p Period) Bounds(t time.Time, loc *time.Location) (start, end time.Time) {
+	t = t.In(loc)
+	switch p {
+	case PeriodMonthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	default:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// id 返回周期起始时间对应的标识，用于拼接存储键
+func (p Period) id(start time.Time) string {
+	if p == PeriodMonthly {
+		return start.Format("200601")
+	}
+	return start.Format("20060102")
+}
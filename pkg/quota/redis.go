@@ -0,0 +1,300 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+)
+
+// redisManager 基于Redis的配额管理器
+// 同一租户的所有键使用相同的 hash tag，兼容 Redis 集群
+type redisManager struct {
+	client redis.Client
+	opts   *Options
+}
+
+// NewManager 创建基于Redis的配额管理器
+func NewManager(client redis.Client, opts ...Option) (Manager, error) {
+	if client == nil {
+		return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &redisManager{
+		client: client,
+		opts:   options,
+	}, nil
+}
+
+// window 一个周期在当前时间的区间
+type window struct {
+	period Period
+	start  time.Time
+	end    time.Time
+}
+
+// Consume 从租户所有受限周期中扣减 n 个单位
+func (m *redisManager) Consume(ctx context.Context, tenant string, n int64) ([]Usage, error) {
+	if tenant == "" {
+		return nil, errors.NewInvalidParamsError("tenant is required", nil)
+	}
+	if n <= 0 {
+		return nil, errors.NewInvalidParamsError("consume amount must be positive", nil)
+	}
+
+	now := m.opts.Clock()
+	windows := m.windows(now, m.opts.Periods...)
+	keys, args := m.scriptArgs(tenant, windows)
+	args = append([]interface{}{n, m.ratio()}, args...)
+
+	result, err := m.client.Eval(ctx, consumeScript, keys, args...)
+	if err != nil {
+		m.opts.Logger.Error(ctx, "failed to evaluate quota consume script",
+			types.Field{Key: "tenant", Value: tenant},
+			types.Error(err),
+		)
+		return nil, errors.NewQuotaStoreError("failed to consume quota", err)
+	}
+	values, err := int64Values(result, 1+len(windows)*4)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]Usage, 0, len(windows))
+	for i, w := range windows {
+		v := values[1+i*4 : 5+i*4]
+		if v[0] < 0 {
+			continue
+		}
+		usage := newUsage(tenant, w, v[0], v[1], v[2])
+		usages = append(usages, usage)
+
+		switch v[3] {
+		case 1:
+			m.publish(ctx, SoftLimitReached, usage, now)
+		case 2:
+			m.publish(ctx, QuotaExhausted, usage, now)
+		}
+	}
+	if len(usages) == 0 {
+		return nil, errors.NewQuotaNotConfiguredError("no quota configured for tenant "+tenant, nil)
+	}
+
+	if values[0] != 1 {
+		return usages, errors.NewQuotaExceededError("quota exceeded", nil)
+	}
+	return usages, nil
+}
+
+// Usage 查询租户当前各受限周期的用量
+func (m *redisManager) Usage(ctx context.Context, tenant string) ([]Usage, error) {
+	if tenant == "" {
+		return nil, errors.NewInvalidParamsError("tenant is required", nil)
+	}
+
+	windows := m.windows(m.opts.Clock(), m.opts.Periods...)
+	keys, args := m.scriptArgs(tenant, windows)
+	args = append([]interface{}{0, 0}, args...)
+
+	result, err := m.client.Eval(ctx, usageScript, keys, args...)
+	if err != nil {
+		return nil, errors.NewQuotaStoreError("failed to query quota usage", err)
+	}
+	values, err := int64Values(result, len(windows)*3)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]Usage, 0, len(windows))
+	for i, w := range windows {
+		v := values[i*3 : 3+i*3]
+		if v[0] < 0 {
+			continue
+		}
+		usages = append(usages, newUsage(tenant, w, v[0], v[1], v[2]))
+	}
+	if len(usages) == 0 {
+		return nil, errors.NewQuotaNotConfiguredError("no quota configured for tenant "+tenant, nil)
+	}
+	return usages, nil
+}
+
+// TopUp 为租户当前周期追加配额
+func (m *redisManager) TopUp(ctx context.Context, tenant string, period Period, amount int64) (*Usage, error) {
+	if err := m.checkPeriod(tenant, period); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, errors.NewInvalidParamsError("top up amount must be positive", nil)
+	}
+
+	now := m.opts.Clock()
+	windows := m.windows(now, period)
+	keys, args := m.scriptArgs(tenant, windows)
+	args = append(args, amount, m.ratio())
+
+	result, err := m.client.Eval(ctx, topUpScript, keys, args...)
+	if err != nil {
+		return nil, errors.NewQuotaStoreError("failed to top up quota", err)
+	}
+	values, err := int64Values(result, 3)
+	if err != nil {
+		return nil, err
+	}
+	if values[0] < 0 {
+		return nil, errors.NewQuotaNotConfiguredError("no "+string(period)+" quota configured for tenant "+tenant, nil)
+	}
+
+	usage := newUsage(tenant, windows[0], values[0], values[1], values[2])
+	m.publish(ctx, QuotaToppedUp, usage, now)
+	return &usage, nil
+}
+
+// Reset 清空租户当前周期的用量和追加配额
+func (m *redisManager) Reset(ctx context.Context, tenant string, period Period) error {
+	if err := m.checkPeriod(tenant, period); err != nil {
+		return err
+	}
+
+	now := m.opts.Clock()
+	w := m.windows(now, period)[0]
+	if _, err := m.client.Del(ctx, m.usageKey(tenant, w)); err != nil {
+		return errors.NewQuotaStoreError("failed to reset quota", err)
+	}
+
+	m.publish(ctx, QuotaReset, Usage{
+		Tenant:      tenant,
+		Period:      period,
+		PeriodStart: w.start,
+		ResetAt:     w.end,
+	}, now)
+	return nil
+}
+
+// SetLimit 设置租户在周期内的配额
+func (m *redisManager) SetLimit(ctx context.Context, tenant string, period Period, limit int64) error {
+	if err := m.checkPeriod(tenant, period); err != nil {
+		return err
+	}
+	if limit < 0 {
+		return errors.NewInvalidParamsError("quota limit cannot be negative", nil)
+	}
+
+	if _, err := m.client.HSet(ctx, m.limitsKey(tenant), string(period), limit); err != nil {
+		return errors.NewQuotaStoreError("failed to set quota limit", err)
+	}
+	return nil
+}
+
+// checkPeriod 校验租户和周期
+func (m *redisManager) checkPeriod(tenant string, period Period) error {
+	if tenant == "" {
+		return errors.NewInvalidParamsError("tenant is required", nil)
+	}
+	for _, p := range m.opts.Periods {
+		if p == period {
+			return nil
+		}
+	}
+	return errors.NewInvalidParamsError("unsupported period: "+string(period), nil)
+}
+
+// windows 计算各周期在 now 时的区间
+func (m *redisManager) windows(now time.Time, periods ...Period) []window {
+	windows := make([]window, 0, len(periods))
+	for _, p := range periods {
+		start, end := p.Bounds(now, m.opts.Location)
+		windows = append(windows, window{period: p, start: start, end: end})
+	}
+	return windows
+}
+
+// scriptArgs 生成脚本的键和每个周期的参数 {配置字段, 默认配额, 过期时间}
+func (m *redisManager) scriptArgs(tenant string, windows []window) ([]string, []interface{}) {
+	keys := make([]string, 0, len(windows)+1)
+	args := make([]interface{}, 0, len(windows)*3)
+	keys = append(keys, m.limitsKey(tenant))
+	for _, w := range windows {
+		defaultLimit, ok := m.opts.DefaultLimits[w.period]
+		if !ok {
+			defaultLimit = -1
+		}
+		keys = append(keys, m.usageKey(tenant, w))
+		args = append(args, string(w.period), defaultLimit, w.end.Add(m.opts.Retention).UnixMilli())
+	}
+	return keys, args
+}
+
+// limitsKey 租户配额配置键
+func (m *redisManager) limitsKey(tenant string) string {
+	return m.opts.KeyPrefix + "{" + tenant + "}:limits"
+}
+
+// usageKey 租户周期用量键
+func (m *redisManager) usageKey(tenant string, w window) string {
+	return m.opts.KeyPrefix + "{" + tenant + "}:" + string(w.period) + ":" + w.period.id(w.start)
+}
+
+// ratio 软限制比例参数
+func (m *redisManager) ratio() string {
+	return strconv.FormatFloat(m.opts.SoftLimitRatio, 'f', -1, 64)
+}
+
+// publish 发布事件，发布失败只记录日志
+func (m *redisManager) publish(ctx context.Context, eventType EventType, usage Usage, now time.Time) {
+	if m.opts.Publisher == nil {
+		return
+	}
+	if err := m.opts.Publisher.Publish(ctx, newEvent(eventType, usage, now)); err != nil {
+		m.opts.Logger.Warn(ctx, "failed to publish quota event",
+			types.Field{Key: "tenant", Value: usage.Tenant},
+			types.Field{Key: "event_type", Value: string(eventType)},
+			types.Error(err),
+		)
+	}
+}
+
+// newUsage 创建周期用量
+func newUsage(tenant string, w window, limit, used, bonus int64) Usage {
+	remaining := limit + bonus - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Usage{
+		Tenant:      tenant,
+		Period:      w.period,
+		Limit:       limit,
+		Bonus:       bonus,
+		Used:        used,
+		Remaining:   remaining,
+		PeriodStart: w.start,
+		ResetAt:     w.end,
+	}
+}
+
+// int64Values 解析脚本返回的整数数组
+func int64Values(result interface{}, n int) ([]int64, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != n {
+		return nil, errors.NewQuotaStoreError("unexpected quota script result", nil)
+	}
+	out := make([]int64, n)
+	for i, v := range values {
+		out[i], ok = v.(int64)
+		if !ok {
+			return nil, errors.NewQuotaStoreError("unexpected quota script result", nil)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package quota
+
+// consumeScript 原子地从多个周期扣减用量
+// KEYS[1] 为租户配额配置，KEYS[2..] 为各周期用量
+// ARGV[1] 扣减量，ARGV[2] 软限制比例，之后每个周期依次为 {配置字段, 默认配额, 过期时间(毫秒)}
+// 默认配额为 -1 且未单独配置的周期不限制
+// 返回 {allowed, 每个周期的 limit, used, bonus, flag}，flag 为 1 表示首次达到软限制，2 表示首次耗尽
+const consumeScript = `
+local n = tonumber(ARGV[1])
+local ratio = tonumber(ARGV[2])
+local count = #KEYS - 1
+
+local limits, used, bonus = {}, {}, {}
+local allowed = 1
+for i = 1, count do
+    local base = 2 + (i - 1) * 3
+    limits[i] = tonumber(redis.call('HGET', KEYS[1], ARGV[base + 1]) or ARGV[base + 2])
+    used[i] = tonumber(redis.call('HGET', KEYS[i + 1], 'used') or '0')
+    bonus[i] = tonumber(redis.call('HGET', KEYS[i + 1], 'bonus') or '0')
+    if limits[i] >= 0 and used[i] + n > limits[i] + bonus[i] then
+        allowed = 0
+    end
+end
+
+local result = {allowed}
+for i = 1, count do
+    local base = 2 + (i - 1) * 3
+    local flag = 0
+    if limits[i] >= 0 then
+        local total = limits[i] + bonus[i]
+        if allowed == 1 then
+            used[i] = redis.call('HINCRBY', KEYS[i + 1], 'used', n)
+            redis.call('PEXPIREAT', KEYS[i + 1], ARGV[base + 3])
+            if ratio > 0 and used[i] >= total * ratio and redis.call('HSETNX', KEYS[i + 1], 'warned', 1) == 1 then
+                flag = 1
+            end
+        elseif used[i] + n > total then
+            if redis.call('HSETNX', KEYS[i + 1], 'exhausted', 1) == 1 then
+                redis.call('PEXPIREAT', KEYS[i + 1], ARGV[base + 3])
+                flag = 2
+            end
+        end
+    end
+    table.insert(result, limits[i])
+    table.insert(result, used[i])
+    table.insert(result, bonus[i])
+    table.insert(result, flag)
+end
+return result
+`
+
+// usageScript 查询多个周期的用量
+// KEYS 与 ARGV 的布局与 consumeScript 相同，返回每个周期的 {limit, used, bonus}
+const usageScript = `
+local result = {}
+for i = 1, #KEYS - 1 do
+    local base = 2 + (i - 1) * 3
+    table.insert(result, tonumber(redis.call('HGET', KEYS[1], ARGV[base + 1]) or ARGV[base + 2]))
+    table.insert(result, tonumber(redis.call('HGET', KEYS[i + 1], 'used') or '0'))
+    table.insert(result, tonumber(redis.call('HGET', KEYS[i + 1], 'bonus') or '0'))
+end
+return result
+`
+
+// topUpScript 为当前周期追加配额
+// KEYS[1] 为租户配额配置，KEYS[2] 为周期用量
+// ARGV 依次为 {配置字段, 默认配额, 过期时间(毫秒), 追加量, 软限制比例}
+// 追加后清除耗尽标记，用量回落到软限制以下时清除预警标记，周期不限制时返回 {-1, 0, 0}
+const topUpScript = `
+local limit = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or ARGV[2])
+if limit < 0 then
+    return {-1, 0, 0}
+end
+
+local bonus = redis.call('HINCRBY', KEYS[2], 'bonus', ARGV[4])
+redis.call('PEXPIREAT', KEYS[2], ARGV[3])
+local used = tonumber(redis.call('HGET', KEYS[2], 'used') or '0')
+
+redis.call('HDEL', KEYS[2], 'exhausted')
+local ratio = tonumber(ARGV[5])
+if used < (limit + bonus) * ratio then
+    redis.call('HDEL', KEYS[2], 'warned')
+end
+return {limit, used, bonus}
+`
@@ -0,0 +1,296 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/quota"
+)
+
+// recordPublisher 记录发布的事件
+type recordPublisher struct {
+	mu     sync.Mutex
+	events []*quota.Event
+}
+
+func (p *recordPublisher) Publish(ctx context.Context, event *quota.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordPublisher) types() []quota.EventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	types := make([]quota.EventType, 0, len(p.events))
+	for _, e := range p.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// fakeClock 可手动推进的时钟，同时推进 miniredis 的时间使过期时间一致
+type fakeClock struct {
+	mu  sync.Mutex
+	mr  *miniredis.Miniredis
+	now time.Time
+}
+
+func newFakeClock(mr *miniredis.Miniredis, now time.Time) *fakeClock {
+	mr.SetTime(now)
+	return &fakeClock{mr: mr, now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.mr.SetTime(t)
+}
+
+// newTestClient 创建连接到 miniredis 的客户端
+func newTestClient(t *testing.T) (*miniredis.Miniredis, redis.Client) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return mr, client
+}
+
+func TestPeriod_Bounds(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// UTC 1月31日 18:00 在上海已是2月1日
+	now := time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC)
+
+	start, end := quota.PeriodDaily.Bounds(now, shanghai)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, shanghai), start)
+	assert.Equal(t, time.Date(2024, 2, 2, 0, 0, 0, 0, shanghai), end)
+
+	start, end = quota.PeriodMonthly.Bounds(now, shanghai)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, shanghai), start)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, shanghai), end)
+
+	start, end = quota.PeriodMonthly.Bounds(now, time.UTC)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestManager_Consume(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestClient(t)
+	clock := newFakeClock(mr, time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC))
+	pub := &recordPublisher{}
+
+	m, err := quota.NewManager(client,
+		quota.WithDefaultLimit(quota.PeriodDaily, 10),
+		quota.WithDefaultLimit(quota.PeriodMonthly, 15),
+		quota.WithSoftLimitRatio(0.8),
+		quota.WithPublisher(pub),
+		quota.WithClock(clock.Now),
+	)
+	require.NoError(t, err)
+
+	usages, err := m.Consume(ctx, "acme", 7)
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+	assert.Equal(t, quota.PeriodDaily, usages[0].Period)
+	assert.Equal(t, int64(3), usages[0].Remaining)
+	assert.Equal(t, int64(8), usages[1].Remaining)
+	assert.Empty(t, pub.types())
+
+	// 达到日配额的80%时发布一次软限制预警
+	_, err = m.Consume(ctx, "acme", 1)
+	require.NoError(t, err)
+	_, err = m.Consume(ctx, "acme", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []quota.EventType{quota.SoftLimitReached}, pub.types())
+
+	// 日配额不足时不扣减任何周期，耗尽事件只发布一次
+	usages, err = m.Consume(ctx, "acme", 2)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaExceeded))
+	assert.Equal(t, int64(9), usages[0].Used)
+	assert.Equal(t, int64(9), usages[1].Used)
+	_, err = m.Consume(ctx, "acme", 2)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaExceeded))
+	assert.Equal(t, []quota.EventType{quota.SoftLimitReached, quota.QuotaExhausted}, pub.types())
+
+	// 次日日配额重置，月配额继续累计
+	clock.Set(clock.Now().Add(24 * time.Hour))
+	usages, err = m.Consume(ctx, "acme", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), usages[0].Used)
+	assert.Equal(t, int64(14), usages[1].Used)
+
+	_, err = m.Consume(ctx, "acme", 2)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaExceeded))
+
+	// 下个月月配额重置
+	clock.Set(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	usages, err = m.Consume(ctx, "acme", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usages[1].Used)
+}
+
+func TestManager_Timezone(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestClient(t)
+	clock := newFakeClock(mr, time.Date(2024, 5, 10, 15, 59, 0, 0, time.UTC))
+
+	m, err := quota.NewManager(client,
+		quota.WithTimezone("Asia/Shanghai"),
+		quota.WithPeriods(quota.PeriodDaily),
+		quota.WithDefaultLimit(quota.PeriodDaily, 1),
+		quota.WithClock(clock.Now),
+	)
+	require.NoError(t, err)
+
+	_, err = m.Consume(ctx, "acme", 1)
+	require.NoError(t, err)
+
+	// UTC 16:00 是上海的零点
+	clock.Set(time.Date(2024, 5, 10, 16, 0, 0, 0, time.UTC))
+	usages, err := m.Consume(ctx, "acme", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usages[0].Used)
+
+	_, err = quota.NewManager(client, quota.WithTimezone("Mars/Olympus"))
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaConfigError))
+}
+
+func TestManager_Admin(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+	pub := &recordPublisher{}
+
+	m, err := quota.NewManager(client,
+		quota.WithDefaultLimit(quota.PeriodMonthly, 100),
+		quota.WithPublisher(pub),
+	)
+	require.NoError(t, err)
+
+	// 单独配置的日配额覆盖默认值(默认不限制)
+	require.NoError(t, m.SetLimit(ctx, "acme", quota.PeriodDaily, 5))
+	_, err = m.Consume(ctx, "acme", 5)
+	require.NoError(t, err)
+	_, err = m.Consume(ctx, "acme", 1)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaExceeded))
+
+	// 追加配额仅对当前周期有效
+	usage, err := m.TopUp(ctx, "acme", quota.PeriodDaily, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), usage.Bonus)
+	assert.Equal(t, int64(3), usage.Remaining)
+	_, err = m.Consume(ctx, "acme", 3)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Reset(ctx, "acme", quota.PeriodDaily))
+	usages, err := m.Usage(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+	assert.Equal(t, int64(0), usages[0].Used)
+	assert.Equal(t, int64(0), usages[0].Bonus)
+	assert.Equal(t, int64(8), usages[1].Used)
+
+	assert.Contains(t, pub.types(), quota.QuotaToppedUp)
+	assert.Contains(t, pub.types(), quota.QuotaReset)
+
+	err = m.SetLimit(ctx, "acme", "weekly", 1)
+	assert.True(t, errors.HasErrorCode(err, codes.InvalidParams))
+}
+
+func TestManager_NotConfigured(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	m, err := quota.NewManager(client)
+	require.NoError(t, err)
+
+	_, err = m.Consume(ctx, "acme", 1)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaNotConfigured))
+	_, err = m.TopUp(ctx, "acme", quota.PeriodDaily, 1)
+	assert.True(t, errors.HasErrorCode(err, codes.QuotaNotConfigured))
+}
+
+func TestRedisPublisher(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, client := newTestClient(t)
+	pub, err := quota.NewRedisPublisher(client, "")
+	require.NoError(t, err)
+
+	sub := client.Subscribe(ctx, quota.DefaultChannel)
+	defer sub.Close()
+
+	require.NoError(t, pub.Publish(ctx, &quota.Event{ID: "1", Type: quota.SoftLimitReached}))
+	msg, err := sub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Payload, string(quota.SoftLimitReached))
+}
+
+func TestHandler(t *testing.T) {
+	_, client := newTestClient(t)
+	m, err := quota.NewManager(client, quota.WithDefaultLimit(quota.PeriodDaily, 10))
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	quota.NewHandler(m).Register(router.Group("/admin/quotas"))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/admin/quotas/acme/monthly/limit", `{"limit": 100}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	_, err = m.Consume(context.Background(), "acme", 4)
+	require.NoError(t, err)
+
+	w = do(http.MethodGet, "/admin/quotas/acme", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"used":4`)
+
+	w = do(http.MethodPost, "/admin/quotas/acme/daily/topup", `{"amount": 5}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"remaining":11`)
+
+	w = do(http.MethodPost, "/admin/quotas/acme/daily/reset", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(http.MethodPost, "/admin/quotas/acme/weekly/topup", `{"amount": 5}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPost, "/admin/quotas/acme/daily/topup", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,272 @@
+package adaptive
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// limiterType 自适应限流器类型
+const limiterType = "adaptive"
+
+// sweepInterval 清理空闲键的最小间隔
+const sweepInterval = time.Minute
+
+// state 单个键的自适应状态
+type state struct {
+	limit    float64
+	inflight int64
+	// longRTT 长期延迟的指数移动平均(纳秒)
+	longRTT  float64
+	lastUsed time.Time
+}
+
+// Limiter 自适应并发限流器
+// 根据请求延迟、失败率和可选的CPU使用率动态调整每个键的并发上限，进程内生效
+type Limiter struct {
+	opts   *Options
+	nextID atomic.Uint64
+
+	mu        sync.Mutex
+	states    map[string]*state
+	lastSweep time.Time
+}
+
+var _ core.ConcurrencyLimiter = (*Limiter)(nil)
+
+// New 创建自适应并发限流器
+func New(opts ...Option) (*Limiter, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	metrics.Collector.SetActiveLimiters(limiterType, 1)
+	return &Limiter{
+		opts:      options,
+		states:    make(map[string]*state),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// TryAcquire 尝试获取许可
+// 实际上限为自适应上限与 limit 中的较小值，limit 作为静态上限使用
+func (l *Limiter) TryAcquire(ctx context.Context, key string, limit int64) (core.Lease, bool, error) {
+	if limit <= 0 {
+		return nil, false, errors.NewError(codes.RateLimitConfigError, "limit must be positive", nil)
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	st := l.state(key, now)
+	allowed := st.inflight < int64(math.Min(st.limit, float64(limit)))
+	if allowed {
+		st.inflight++
+	}
+	inflight := st.inflight
+	l.mu.Unlock()
+
+	// 并发许可不计入限流请求指标，由并发中间件记录在途数和拒绝数
+	if !allowed {
+		return nil, false, nil
+	}
+
+	return &lease{
+		limiter:  l,
+		key:      key,
+		id:       strconv.FormatUint(l.nextID.Add(1), 10),
+		start:    now,
+		inflight: inflight,
+	}, true, nil
+}
+
+// Acquire 等待直到获取许可或 ctx 结束
+func (l *Limiter) Acquire(ctx context.Context, key string, limit int64) (core.Lease, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
+	}()
+
+	for {
+		lease, ok, err := l.TryAcquire(ctx, key, limit)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return lease, nil
+		}
+
+		timer := time.NewTimer(l.opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.NewError(codes.TooManyRequests, "wait timeout exceeded", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// InFlight 返回当前持有的许可数
+func (l *Limiter) InFlight(ctx context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if st, ok := l.states[key]; ok {
+		return st.inflight, nil
+	}
+	return 0, nil
+}
+
+// Limit 返回键当前的自适应并发上限
+func (l *Limiter) Limit(key string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if st, ok := l.states[key]; ok {
+		return int64(st.limit)
+	}
+	return l.opts.InitialLimit
+}
+
+// state 获取或创建键的状态，调用方需持有锁
+func (l *Limiter) state(key string, now time.Time) *state {
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		for k, st := range l.states {
+			if st.inflight == 0 && now.Sub(st.lastUsed) >= sweepInterval {
+				delete(l.states, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	st, ok := l.states[key]
+	if !ok {
+		st = &state{limit: float64(l.opts.InitialLimit)}
+		l.states[key] = st
+	}
+	st.lastUsed = now
+	return st
+}
+
+// release 释放许可并根据处理结果调整上限
+func (l *Limiter) release(key string, rtt time.Duration, inflight int64, outcome core.Outcome) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st, ok := l.states[key]
+	if !ok {
+		return
+	}
+	st.inflight--
+	if outcome == core.OutcomeIgnored {
+		return
+	}
+
+	old := st.limit
+	overloaded := outcome == core.OutcomeDropped ||
+		(l.opts.CPU != nil && l.opts.CPU.CPUUsage() >= l.opts.CPUThreshold)
+
+	switch l.opts.Algorithm {
+	case AlgorithmAIMD:
+		l.aimd(st, rtt, inflight, overloaded)
+	default:
+		l.gradient(st, rtt, inflight, overloaded)
+	}
+	st.limit = math.Max(float64(l.opts.MinLimit), math.Min(float64(l.opts.MaxLimit), st.limit))
+
+	if int64(st.limit) != int64(old) {
+		l.opts.Logger.Debug(context.Background(), "adaptive limit changed",
+			types.Field{Key: "key", Value: key},
+			types.Field{Key: "limit", Value: int64(st.limit)},
+			types.Field{Key: "rtt", Value: rtt},
+		)
+	}
+}
+
+// aimd 过载或延迟超过阈值时按比例缩减，否则在并发达到上限一半以上时加一
+func (l *Limiter) aimd(st *state, rtt time.Duration, inflight int64, overloaded bool) {
+	if overloaded || rtt > l.opts.LatencyThreshold {
+		st.limit *= l.opts.BackoffRatio
+		return
+	}
+	// 并发远低于上限时说明负载不足，不增加上限，避免上限无限增长
+	if float64(inflight)*2 >= st.limit {
+		st.limit++
+	}
+}
+
+// gradient 按长期延迟与本次延迟的比值调整上限，并预留 sqrt(limit) 的排队余量
+func (l *Limiter) gradient(st *state, rtt time.Duration, inflight int64, overloaded bool) {
+	if overloaded {
+		st.limit *= l.opts.BackoffRatio
+		return
+	}
+
+	sample := float64(rtt)
+	if sample <= 0 {
+		sample = 1
+	}
+	if st.longRTT == 0 {
+		st.longRTT = sample
+	} else {
+		st.longRTT += (sample - st.longRTT) * 2 / float64(l.opts.LongWindow+1)
+	}
+
+	if float64(inflight)*2 < st.limit {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.opts.Tolerance*st.longRTT/sample))
+	next := st.limit*gradient + math.Sqrt(st.limit)
+	st.limit = st.limit*(1-l.opts.Smoothing) + next*l.opts.Smoothing
+}
+
+// lease 自适应限流器的许可
+type lease struct {
+	limiter  *Limiter
+	key      string
+	id       string
+	start    time.Time
+	inflight int64
+	once     sync.Once
+}
+
+// Key 返回许可所属的限流键
+func (s *lease) Key() string {
+	return s.key
+}
+
+// ID 返回许可标识
+func (s *lease) ID() string {
+	return s.id
+}
+
+// Lost 进程内许可不会丢失，返回的通道永不关闭
+func (s *lease) Lost() <-chan struct{} {
+	return nil
+}
+
+// Release 释放许可，不参与上限调整
+func (s *lease) Release(ctx context.Context) error {
+	return s.Done(ctx, core.OutcomeIgnored)
+}
+
+// Done 按请求处理结果释放许可
+func (s *lease) Done(ctx context.Context, outcome core.Outcome) error {
+	s.once.Do(func() {
+		s.limiter.release(s.key, time.Since(s.start), s.inflight, outcome)
+	})
+	return nil
+}
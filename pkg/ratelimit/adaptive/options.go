@@ -0,0 +1,195 @@
+package adaptive
+
+import (
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+)
+
+// 自适应算法类型
+const (
+	// AlgorithmAIMD 加性增、乘性减
+	AlgorithmAIMD = "aimd"
+	// AlgorithmGradient 根据短期与长期延迟的比值调整
+	AlgorithmGradient = "gradient"
+)
+
+// CPUReader 提供CPU使用率百分比，collector.SystemCollector 实现了该接口
+type CPUReader interface {
+	CPUUsage() float64
+}
+
+// Option 定义选项函数类型
+type Option func(*Options)
+
+// Options 自适应限流器配置
+type Options struct {
+	// Algorithm 自适应算法
+	Algorithm string
+
+	// InitialLimit 初始并发上限
+	InitialLimit int64
+
+	// MinLimit 最小并发上限
+	MinLimit int64
+
+	// MaxLimit 最大并发上限
+	MaxLimit int64
+
+	// BackoffRatio 出现过载信号时并发上限的缩减比例
+	BackoffRatio float64
+
+	// LatencyThreshold AIMD 算法下延迟超过该值视为过载
+	LatencyThreshold time.Duration
+
+	// Tolerance Gradient 算法允许短期延迟超过长期延迟的倍数
+	Tolerance float64
+
+	// Smoothing Gradient 算法新上限的平滑系数，取值 (0, 1]
+	Smoothing float64
+
+	// LongWindow Gradient 算法长期延迟的平均样本数
+	LongWindow int
+
+	// CPU CPU使用率来源，为nil时不参考CPU
+	CPU CPUReader
+
+	// CPUThreshold CPU使用率超过该百分比时视为过载
+	CPUThreshold float64
+
+	// RetryInterval 阻塞获取时的重试间隔
+	RetryInterval time.Duration
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Algorithm:        AlgorithmGradient,
+		InitialLimit:     20,
+		MinLimit:         1,
+		MaxLimit:         1000,
+		BackoffRatio:     0.9,
+		LatencyThreshold: time.Second,
+		Tolerance:        1.5,
+		Smoothing:        0.2,
+		LongWindow:       600,
+		CPUThreshold:     80,
+		RetryInterval:    10 * time.Millisecond,
+		Logger:           &types.NoopLogger{},
+	}
+}
+
+// WithAlgorithm 设置自适应算法
+func WithAlgorithm(algorithm string) Option {
+	return func(o *Options) {
+		o.Algorithm = algorithm
+	}
+}
+
+// WithLimits 设置初始、最小和最大并发上限
+func WithLimits(initial, min, max int64) Option {
+	return func(o *Options) {
+		o.InitialLimit = initial
+		o.MinLimit = min
+		o.MaxLimit = max
+	}
+}
+
+// WithBackoffRatio 设置过载时并发上限的缩减比例
+func WithBackoffRatio(ratio float64) Option {
+	return func(o *Options) {
+		o.BackoffRatio = ratio
+	}
+}
+
+// WithLatencyThreshold 设置 AIMD 算法的延迟阈值
+func WithLatencyThreshold(threshold time.Duration) Option {
+	return func(o *Options) {
+		o.LatencyThreshold = threshold
+	}
+}
+
+// WithTolerance 设置 Gradient 算法的延迟容忍倍数
+func WithTolerance(tolerance float64) Option {
+	return func(o *Options) {
+		o.Tolerance = tolerance
+	}
+}
+
+// WithSmoothing 设置 Gradient 算法的平滑系数
+func WithSmoothing(smoothing float64) Option {
+	return func(o *Options) {
+		o.Smoothing = smoothing
+	}
+}
+
+// WithLongWindow 设置 Gradient 算法长期延迟的平均样本数
+func WithLongWindow(samples int) Option {
+	return func(o *Options) {
+		o.LongWindow = samples
+	}
+}
+
+// WithCPU 设置CPU使用率来源和过载阈值(百分比)
+func WithCPU(reader CPUReader, threshold float64) Option {
+	return func(o *Options) {
+		o.CPU = reader
+		o.CPUThreshold = threshold
+	}
+}
+
+// WithRetryInterval 设置阻塞获取时的重试间隔
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 校验配置
+func (o *Options) Validate() error {
+	switch o.Algorithm {
+	case AlgorithmAIMD, AlgorithmGradient:
+	default:
+		return errors.NewError(codes.RateLimitConfigError, "unsupported adaptive algorithm: "+o.Algorithm, nil)
+	}
+	if o.MinLimit <= 0 || o.MaxLimit < o.MinLimit {
+		return errors.NewError(codes.RateLimitConfigError, "invalid min or max limit", nil)
+	}
+	if o.InitialLimit < o.MinLimit || o.InitialLimit > o.MaxLimit {
+		return errors.NewError(codes.RateLimitConfigError, "initial limit must be between min and max limit", nil)
+	}
+	if o.BackoffRatio <= 0 || o.BackoffRatio >= 1 {
+		return errors.NewError(codes.RateLimitConfigError, "backoff ratio must be in (0, 1)", nil)
+	}
+	if o.LatencyThreshold <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "latency threshold must be positive", nil)
+	}
+	if o.Tolerance < 1 {
+		return errors.NewError(codes.RateLimitConfigError, "tolerance must be at least 1", nil)
+	}
+	if o.Smoothing <= 0 || o.Smoothing > 1 {
+		return errors.NewError(codes.RateLimitConfigError, "smoothing must be in (0, 1]", nil)
+	}
+	if o.LongWindow <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "long window must be positive", nil)
+	}
+	if o.RetryInterval <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "retry interval must be positive", nil)
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Lease 并发许可，持有期间占用一个并发名额
+type Lease interface {
+	// Key 返回许可所属的限流键
+	Key() string
+
+	// ID 返回许可标识
+	ID() string
+
+	// Lost 返回许可丢失通知通道，续期失败导致许可过期时关闭
+	Lost() <-chan struct{}
+
+	// Release 释放许可，重复释放或许可已过期时不返回错误
+	Release(ctx context.Context) error
+}
+
+// ConcurrencyLimiter 并发限流器，限制同一键同时在途的请求数
+type ConcurrencyLimiter interface {
+	// TryAcquire 尝试获取许可，超过并发上限时立即返回 false
+	TryAcquire(ctx context.Context, key string, limit int64) (Lease, bool, error)
+
+	// Acquire 等待直到获取许可或 ctx 结束
+	Acquire(ctx context.Context, key string, limit int64) (Lease, error)
+
+	// InFlight 返回当前持有的许可数
+	InFlight(ctx context.Context, key string) (int64, error)
+}
+
+// ConcurrencyOption 并发限流器配置选项
+type ConcurrencyOption func(*ConcurrencyOptions)
+
+// ConcurrencyOptions 并发限流器配置
+type ConcurrencyOptions struct {
+	// LeaseTTL 许可有效期，持有者崩溃后许可在有效期结束时自动释放
+	LeaseTTL time.Duration
+
+	// RenewInterval 自动续期间隔，为0时为 LeaseTTL 的三分之一，小于0时不自动续期
+	RenewInterval time.Duration
+
+	// RetryInterval 阻塞获取时的重试间隔
+	RetryInterval time.Duration
+}
+
+// DefaultConcurrencyOptions 返回默认并发限流器配置
+func DefaultConcurrencyOptions() *ConcurrencyOptions {
+	return &ConcurrencyOptions{
+		LeaseTTL:      30 * time.Second,
+		RetryInterval: 50 * time.Millisecond,
+	}
+}
+
+// WithLeaseTTL 设置许可有效期
+func WithLeaseTTL(ttl time.Duration) ConcurrencyOption {
+	return func(opts *ConcurrencyOptions) {
+		opts.LeaseTTL = ttl
+	}
+}
+
+// WithRenewInterval 设置自动续期间隔，小于0时不自动续期
+func WithRenewInterval(interval time.Duration) ConcurrencyOption {
+	return func(opts *ConcurrencyOptions) {
+		opts.RenewInterval = interval
+	}
+}
+
+// WithAcquireRetryInterval 设置阻塞获取时的重试间隔
+func WithAcquireRetryInterval(interval time.Duration) ConcurrencyOption {
+	return func(opts *ConcurrencyOptions) {
+		opts.RetryInterval = interval
+	}
+}
+
+// Outcome 请求处理结果，自适应并发限流器据此调整并发上限
+type Outcome int
+
+const (
+	// OutcomeSuccess 请求成功，记录处理延迟
+	OutcomeSuccess Outcome = iota
+	// OutcomeDropped 请求失败或超时，视为过载信号
+	OutcomeDropped
+	// OutcomeIgnored 与负载无关的结果(如客户端错误)，不参与调整
+	OutcomeIgnored
+)
+
+// FeedbackLease 能接收请求处理结果的许可
+type FeedbackLease interface {
+	Lease
+
+	// Done 按请求处理结果释放许可
+	Done(ctx context.Context, outcome Outcome) error
+}
@@ -41,6 +41,44 @@ type Limiter interface {
 	Reset(ctx context.Context, key string) error
 }
 
+// Result 限流判断结果
+type Result struct {
+	// Allowed 是否允许通过
+	Allowed bool
+
+	// Limit 限流阈值
+	Limit int64
+
+	// Remaining 当前剩余可用请求数
+	Remaining int64
+
+	// ResetAt 配额完全恢复的时间
+	ResetAt time.Time
+
+	// RetryAfter 被拒绝时距下次可能通过的时间，小于0表示请求数超过容量永远无法通过
+	RetryAfter time.Duration
+}
+
+// ResultLimiter 能返回详细限流结果的限流器，内置限流器均实现该接口
+type ResultLimiter interface {
+	Limiter
+
+	// Take 判断是否允许N个请求通过，并返回剩余配额、重置时间和重试时间
+	Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*Result, error)
+}
+
+// SettleLimiter 支持事后结算成本的限流器
+// 请求先按预估成本扣减配额，处理完成后按实际成本与预估成本的差额退还或追加
+type SettleLimiter interface {
+	Limiter
+
+	// Refund 退还 n 个已扣减的配额，退还后的配额不超过容量
+	Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error
+
+	// Charge 不做检查地追加扣减 n 个配额，配额可以透支，透支的部分由后续恢复的配额偿还
+	Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error
+}
+
 // LimiterOption 限流器配置选项
 type LimiterOption func(*LimiterOptions)
 
@@ -52,6 +90,12 @@ type LimiterOptions struct {
 	// 限流算法类型
 	Algorithm string
 
+	// 突发容量，仅令牌桶和GCRA使用，为0时等于limit
+	Burst int64
+
+	// 等待队列深度，大于0时 Wait 按 FIFO 顺序排队，仅 Redis 限流器使用
+	WaitQueueDepth int64
+
 	// Redis配置(如果使用Redis限流器)
 	RedisConfig *RedisConfig
 
@@ -62,3 +106,13 @@ type LimiterOptions struct {
 	// 链路追踪配置
 	EnableTracing bool
 }
+
+// 限流算法类型
+const (
+	// AlgorithmSlidingWindow 滑动窗口
+	AlgorithmSlidingWindow = "sliding_window"
+	// AlgorithmTokenBucket 令牌桶
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmGCRA 通用信元速率算法
+	AlgorithmGCRA = "gcra"
+)
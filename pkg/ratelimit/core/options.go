@@ -14,6 +14,21 @@ func WithAlgorithm(algorithm string) LimiterOption {
 	}
 }
 
+// WithBurst 设置突发容量
+func WithBurst(burst int64) LimiterOption {
+	return func(opts *LimiterOptions) {
+		opts.Burst = burst
+	}
+}
+
+// WithWaitQueue 启用公平排队的等待模式，maxDepth 为每个键的最大排队数
+// 等待者按到达顺序领取排队号，只有队首尝试获取配额，队列已满时立即返回
+func WithWaitQueue(maxDepth int64) LimiterOption {
+	return func(opts *LimiterOptions) {
+		opts.WaitQueueDepth = maxDepth
+	}
+}
+
 // WithRedisConfig 设置Redis配置
 func WithRedisConfig(config *RedisConfig) LimiterOption {
 	return func(opts *LimiterOptions) {
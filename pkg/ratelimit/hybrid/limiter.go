@@ -0,0 +1,368 @@
+package hybrid
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/memory"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// lease 从 Redis 租借到本地的配额
+type lease struct {
+	remaining int64
+	expires   time.Time
+	limit     int64
+	window    time.Duration
+	// remoteRemaining 和 resetAt 为最近一次租借时 remote 返回的结果
+	remoteRemaining int64
+	resetAt         time.Time
+}
+
+// result 根据租约构造限流结果，剩余配额包含本地未用完的部分
+func (l *lease) result() *core.Result {
+	return &core.Result{
+		Allowed:   true,
+		Limit:     l.limit,
+		Remaining: l.remaining + l.remoteRemaining,
+		ResetAt:   l.resetAt,
+	}
+}
+
+// valid 租约在 now 时对指定规则是否可用
+func (l *lease) valid(now time.Time, limit int64, window time.Duration) bool {
+	return l != nil && now.Before(l.expires) && l.limit == limit && l.window == window
+}
+
+// 混合限流器实现
+type hybridLimiter struct {
+	remote core.Limiter
+	local  core.ResultLimiter
+	opts   *Options
+
+	mu     sync.Mutex
+	leases map[string]*lease
+	// downUntil 在此之前不访问 Redis，直接使用本地降级限流
+	downUntil time.Time
+	degraded  bool
+	lastSweep time.Time
+}
+
+// New 创建混合限流器
+// remote 通常是 Redis 限流器，本地按批次从 remote 租借配额，remote 不可用时按实例份额在本地限流
+func New(remote core.Limiter, opts ...Option) (core.Limiter, error) {
+	if remote == nil {
+		return nil, errors.NewError(codes.RateLimitInitError, "remote limiter is required", nil)
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &hybridLimiter{
+		remote:    remote,
+		local:     memory.NewTokenBucketLimiter(core.WithName("hybrid_fallback")).(core.ResultLimiter),
+		opts:      options,
+		leases:    make(map[string]*lease),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// Allow 实现限流判断
+func (l *hybridLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	return l.AllowN(ctx, key, 1, limit, window)
+}
+
+// AllowN 判断是否允许N个请求通过
+func (l *hybridLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Take 优先消耗本地租约，租约不足时从 remote 租借新批次
+// 每个请求只记录一次判断结果，租借批次和降级时的内部调用不计入指标
+func (l *hybridLimiter) Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
+	if n <= 0 || limit <= 0 || window <= 0 {
+		return nil, errors.NewError(codes.RateLimitConfigError, "n, limit and window must be positive", nil)
+	}
+
+	result, err := l.take(metrics.WithoutDecision(ctx), key, n, limit, window)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Collector.ObserveDecision(ctx, key, result.Allowed)
+	return result, nil
+}
+
+// take 执行限流判断，不记录判断结果
+func (l *hybridLimiter) take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	if ls := l.leases[key]; ls.valid(now, limit, window) && ls.remaining >= n {
+		ls.remaining -= n
+		result := ls.result()
+		l.mu.Unlock()
+		return result, nil
+	}
+	down := now.Before(l.downUntil)
+	l.mu.Unlock()
+
+	if down {
+		return l.fallback(ctx, key, n, limit, window)
+	}
+
+	batch := l.opts.BatchSize
+	if batch < n {
+		batch = n
+	}
+	if batch > limit {
+		batch = limit
+	}
+
+	result, err := l.takeRemote(ctx, key, batch, limit, window)
+	if err == nil && !result.Allowed && batch > n {
+		// 剩余配额不足一个批次时只申请本次需要的数量
+		batch = n
+		result, err = l.takeRemote(ctx, key, batch, limit, window)
+	}
+	if err != nil {
+		l.markDown(ctx, err)
+		return l.fallback(ctx, key, n, limit, window)
+	}
+	l.markUp(ctx)
+
+	if !result.Allowed {
+		return result, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	ls := l.leases[key]
+	if !ls.valid(now, limit, window) {
+		ls = &lease{limit: limit, window: window}
+		l.leases[key] = ls
+	}
+	ls.remaining += batch - n
+	ls.expires = now.Add(l.leaseTTL(window))
+	ls.remoteRemaining = result.Remaining
+	ls.resetAt = result.ResetAt
+	return ls.result(), nil
+}
+
+// takeRemote 从 remote 申请配额，remote 不支持详细结果时只能得到是否通过
+func (l *hybridLimiter) takeRemote(ctx context.Context, key string, n, limit int64, window time.Duration) (*core.Result, error) {
+	if rl, ok := l.remote.(core.ResultLimiter); ok {
+		return rl.Take(ctx, key, n, limit, window)
+	}
+
+	allowed, err := l.remote.AllowN(ctx, key, n, limit, window)
+	if err != nil {
+		return nil, err
+	}
+	return &core.Result{Allowed: allowed, Limit: limit}, nil
+}
+
+// Wait 等待直到允许通过或超时
+func (l *hybridLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	deadline := time.Now().Add(window)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// remote 未给出重试时间时按平均请求间隔轮询
+	interval := window / time.Duration(limit)
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	for {
+		result, err := l.Take(ctx, key, 1, limit, window)
+		if err != nil {
+			return err
+		}
+		if result.Allowed {
+			return nil
+		}
+
+		sleep := interval
+		if result.RetryAfter > 0 {
+			sleep = result.RetryAfter
+		}
+		if time.Now().Add(sleep).After(deadline) {
+			return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reset 重置本地租约、本地降级限流状态和 remote 中的状态
+func (l *hybridLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	delete(l.leases, key)
+	l.mu.Unlock()
+
+	if err := l.local.Reset(ctx, key); err != nil {
+		return err
+	}
+	return l.remote.Reset(ctx, key)
+}
+
+// Refund 优先退还到本地租约，没有可用租约时退还给 remote
+func (l *hybridLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if n <= 0 || limit <= 0 || window <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "n, limit and window must be positive", nil)
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	if ls := l.leases[key]; ls.valid(now, limit, window) {
+		ls.remaining += n
+		l.mu.Unlock()
+		return nil
+	}
+	l.mu.Unlock()
+
+	return l.settle(ctx, key, -n, limit, window)
+}
+
+// Charge 优先从本地租约扣减，不足的部分在 remote 追加扣减
+func (l *hybridLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if n <= 0 || limit <= 0 || window <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "n, limit and window must be positive", nil)
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	if ls := l.leases[key]; ls.valid(now, limit, window) {
+		taken := ls.remaining
+		if taken > n {
+			taken = n
+		}
+		ls.remaining -= taken
+		n -= taken
+	}
+	l.mu.Unlock()
+
+	if n == 0 {
+		return nil
+	}
+	return l.settle(ctx, key, n, limit, window)
+}
+
+// settle 在 remote 结算，delta 为正时追加扣减，为负时退还
+// remote 不可用时在本地降级限流器上结算
+func (l *hybridLimiter) settle(ctx context.Context, key string, delta int64, limit int64, window time.Duration) error {
+	remote, ok := l.remote.(core.SettleLimiter)
+	if !ok {
+		return errors.NewError(codes.RateLimitConfigError, "remote limiter does not support settlement", nil)
+	}
+
+	l.mu.Lock()
+	down := time.Now().Before(l.downUntil)
+	l.mu.Unlock()
+
+	if !down {
+		var err error
+		if delta > 0 {
+			err = remote.Charge(ctx, key, delta, limit, window)
+		} else {
+			err = remote.Refund(ctx, key, -delta, limit, window)
+		}
+		if err == nil {
+			l.markUp(ctx)
+			return nil
+		}
+		l.markDown(ctx, err)
+	}
+
+	local := l.local.(core.SettleLimiter)
+	if delta > 0 {
+		return local.Charge(ctx, key, delta, l.share(limit), window)
+	}
+	return local.Refund(ctx, key, -delta, l.share(limit), window)
+}
+
+// fallback 使用本实例分得的份额在本地限流
+func (l *hybridLimiter) fallback(ctx context.Context, key string, n, limit int64, window time.Duration) (*core.Result, error) {
+	return l.local.Take(ctx, key, n, l.share(limit), window)
+}
+
+// share 本实例分得的份额
+func (l *hybridLimiter) share(limit int64) int64 {
+	share := limit / l.opts.Instances
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// sweep 定期删除过期租约，需持有锁
+func (l *hybridLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	for key, ls := range l.leases {
+		if !now.Before(ls.expires) {
+			delete(l.leases, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// leaseTTL 租约有效期不超过窗口
+func (l *hybridLimiter) leaseTTL(window time.Duration) time.Duration {
+	if l.opts.LeaseTTL < window {
+		return l.opts.LeaseTTL
+	}
+	return window
+}
+
+// markDown 记录 remote 不可用，在 RetryInterval 内不再访问
+func (l *hybridLimiter) markDown(ctx context.Context, err error) {
+	l.mu.Lock()
+	l.downUntil = time.Now().Add(l.opts.RetryInterval)
+	wasDegraded := l.degraded
+	l.degraded = true
+	l.mu.Unlock()
+
+	if !wasDegraded {
+		l.opts.Logger.Warn(ctx, "remote rate limiter unavailable, falling back to local limits",
+			types.Field{Key: "instances", Value: l.opts.Instances},
+			types.Error(err),
+		)
+	}
+}
+
+// markUp 记录 remote 恢复
+func (l *hybridLimiter) markUp(ctx context.Context) {
+	l.mu.Lock()
+	wasDegraded := l.degraded
+	l.degraded = false
+	l.mu.Unlock()
+
+	if wasDegraded {
+		l.opts.Logger.Info(ctx, "remote rate limiter recovered")
+	}
+}
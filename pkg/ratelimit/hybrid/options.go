@@ -0,0 +1,96 @@
+package hybrid
+
+import (
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+)
+
+// Option 定义选项函数类型
+type Option func(*Options)
+
+// Options 混合限流器配置
+type Options struct {
+	// BatchSize 每次从 remote 租借的配额数
+	BatchSize int64
+
+	// LeaseTTL 本地租约有效期，超过窗口时按窗口计算，过期未用完的配额作废
+	LeaseTTL time.Duration
+
+	// Instances 实例数量，remote 不可用时每个实例按 limit/Instances 限流
+	Instances int64
+
+	// RetryInterval remote 不可用后重新尝试的间隔
+	RetryInterval time.Duration
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		BatchSize:     10,
+		LeaseTTL:      time.Second,
+		Instances:     1,
+		RetryInterval: time.Second,
+		Logger:        &types.NoopLogger{},
+	}
+}
+
+// WithBatchSize 设置每次租借的配额数
+func WithBatchSize(size int64) Option {
+	return func(o *Options) {
+		o.BatchSize = size
+	}
+}
+
+// WithLeaseTTL 设置本地租约有效期
+func WithLeaseTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.LeaseTTL = ttl
+	}
+}
+
+// WithInstances 设置实例数量
+func WithInstances(instances int64) Option {
+	return func(o *Options) {
+		o.Instances = instances
+	}
+}
+
+// WithRetryInterval 设置 remote 不可用后的重试间隔
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 校验配置
+func (o *Options) Validate() error {
+	if o.BatchSize <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "batch size must be positive", nil)
+	}
+	if o.LeaseTTL <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "lease ttl must be positive", nil)
+	}
+	if o.Instances <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "instances must be positive", nil)
+	}
+	if o.RetryInterval <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "retry interval must be positive", nil)
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// RetryAfterHeader 请求被限流时返回的响应头元数据，值为建议的重试秒数
+const RetryAfterHeader = "retry-after"
+
+// KeyFunc 根据请求上下文和完整方法名(如 /pkg.Service/Method)生成限流键
+type KeyFunc func(ctx context.Context, fullMethod string) string
+
+// Config gRPC 限流拦截器配置
+type Config struct {
+	// Limiter 限流器实例
+	Limiter core.Limiter
+	// KeyFunc 限流键生成函数，默认使用对端IP
+	KeyFunc KeyFunc
+	// Limit 限流阈值
+	Limit int64
+	// Window 时间窗口
+	Window time.Duration
+	// WaitTimeout 等待配额的最长时间，为0时超过限制立即拒绝
+	WaitTimeout time.Duration
+	// SkipFunc 返回 true 的方法不限流，如健康检查
+	SkipFunc func(fullMethod string) bool
+	// Message 请求被限流时的错误消息
+	Message string
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		KeyFunc: PeerKey,
+		Limit:   100,
+		Window:  time.Minute,
+		Message: "too many requests",
+		// Limiter 必须由用户提供，因为它需要依赖外部存储
+	}
+}
+
+// PeerKey 使用对端IP作为限流键，无法获取对端地址时返回空字符串
+func PeerKey(ctx context.Context, fullMethod string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// MetadataKey 使用请求元数据中 name 的第一个值作为限流键(如 x-api-key)，元数据缺失时使用对端IP
+func MetadataKey(name string) KeyFunc {
+	return func(ctx context.Context, fullMethod string) string {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(name); len(values) > 0 && values[0] != "" {
+				return values[0]
+			}
+		}
+		return PeerKey(ctx, fullMethod)
+	}
+}
+
+// PerMethod 在 base 生成的键前加上方法名，使每个方法单独计数
+func PerMethod(base KeyFunc) KeyFunc {
+	return func(ctx context.Context, fullMethod string) string {
+		key := base(ctx, fullMethod)
+		if key == "" {
+			return ""
+		}
+		return fullMethod + ":" + key
+	}
+}
+
+// UnaryServerInterceptor 创建一元调用限流拦截器
+func UnaryServerInterceptor(cfg *Config) grpc.UnaryServerInterceptor {
+	l := newLimiter(cfg)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 创建流式调用限流拦截器，每个流在建立时计数一次
+func StreamServerInterceptor(cfg *Config) grpc.StreamServerInterceptor {
+	l := newLimiter(cfg)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// limiter 拦截器共用的限流逻辑
+type limiter struct {
+	cfg *Config
+	log types.Logger
+}
+
+// newLimiter 校验配置并补全默认值
+func newLimiter(cfg *Config) *limiter {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if cfg.Limiter == nil {
+		panic("ratelimit interceptor requires a limiter instance")
+	}
+	if cfg.Limit <= 0 {
+		panic("ratelimit interceptor requires a positive limit")
+	}
+	if cfg.Window <= 0 {
+		panic("ratelimit interceptor requires a positive window")
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = PeerKey
+	}
+	if cfg.Message == "" {
+		cfg.Message = DefaultConfig().Message
+	}
+
+	return &limiter{
+		cfg: cfg,
+		log: logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "ratelimit"},
+			types.Field{Key: "component", Value: "grpc_interceptor"},
+		),
+	}
+}
+
+// check 判断请求是否允许通过，被限流时返回 ResourceExhausted，限流器出错时返回 Internal
+// setHeader 用于在拒绝时返回 retry-after 元数据
+func (l *limiter) check(ctx context.Context, fullMethod string, setHeader func(metadata.MD) error) error {
+	if l.cfg.SkipFunc != nil && l.cfg.SkipFunc(fullMethod) {
+		return nil
+	}
+
+	key := l.cfg.KeyFunc(ctx, fullMethod)
+	if key == "" {
+		l.log.Error(ctx, "empty key generated by KeyFunc",
+			types.Field{Key: "method", Value: fullMethod},
+		)
+		return status.Error(grpccodes.Internal, "invalid rate limit key")
+	}
+
+	start := time.Now()
+	allowed, retryAfter, err := l.allow(ctx, key)
+	metrics.Collector.ObserveLatency(key, "total", time.Since(start).Seconds())
+
+	if err != nil && ctx.Err() != nil {
+		// 客户端取消或调用超时
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		l.log.Error(ctx, "rate limit check failed",
+			types.Field{Key: "key", Value: key},
+			types.Field{Key: "method", Value: fullMethod},
+			types.Error(err),
+		)
+		metrics.Collector.ObserveCheckFailed(key)
+		return status.Error(grpccodes.Internal, "rate limit check failed")
+	}
+	if allowed {
+		return nil
+	}
+
+	l.log.Debug(ctx, "rate limit exceeded",
+		types.Field{Key: "key", Value: key},
+		types.Field{Key: "method", Value: fullMethod},
+	)
+	if retryAfter > 0 {
+		seconds := int64((retryAfter + time.Second - 1) / time.Second)
+		if err := setHeader(metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10))); err != nil {
+			l.log.Debug(ctx, "failed to set retry-after header", types.Error(err))
+		}
+	}
+	return status.Error(grpccodes.ResourceExhausted, l.cfg.Message)
+}
+
+// allow 执行限流判断，返回是否允许和建议的重试时间
+func (l *limiter) allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	if l.cfg.WaitTimeout > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, l.cfg.WaitTimeout)
+		defer cancel()
+
+		start := time.Now()
+		err := l.cfg.Limiter.Wait(waitCtx, key, l.cfg.Limit, l.cfg.Window)
+		metrics.Collector.ObserveWait(key, time.Since(start).Seconds())
+
+		// 等待超时、等待队列已满都按限流处理
+		if errors.HasErrorCode(err, codes.TooManyRequests) || errors.Is(err, context.DeadlineExceeded) {
+			return false, 0, nil
+		}
+		return err == nil, 0, err
+	}
+
+	if rl, ok := l.cfg.Limiter.(core.ResultLimiter); ok {
+		result, err := rl.Take(ctx, key, 1, l.cfg.Limit, l.cfg.Window)
+		if err != nil {
+			return false, 0, err
+		}
+		return result.Allowed, result.RetryAfter, nil
+	}
+
+	allowed, err := l.cfg.Limiter.Allow(ctx, key, l.cfg.Limit, l.cfg.Window)
+	return allowed, 0, err
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// sweepInterval 清理过期键的最小间隔
+const sweepInterval = time.Minute
+
+// entry 键的限流状态
+type entry interface {
+	// expired 状态在 now 时是否已与初始状态相同，可以删除
+	expired(now time.Time) bool
+}
+
+// keyStore 保存各个键的限流状态，访问时顺带清理过期键
+type keyStore struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+func newKeyStore() *keyStore {
+	return &keyStore{
+		entries:   make(map[string]entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// do 在锁内获取或创建键的状态并执行 fn
+func (s *keyStore) do(key string, now time.Time, create func() entry, fn func(e entry) *core.Result) *core.Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, e := range s.entries {
+			if e.expired(now) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = create()
+		s.entries[key] = e
+	}
+	return fn(e)
+}
+
+// delete 删除键的状态
+func (s *keyStore) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// validateArgs 校验限流参数
+func validateArgs(n, limit int64, window time.Duration) error {
+	if n <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "n must be positive", nil)
+	}
+	if limit <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "limit must be positive", nil)
+	}
+	if window <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "window must be positive", nil)
+	}
+	return nil
+}
+
+// takeFunc 执行一次限流判断
+type takeFunc func(ctx context.Context, key string, n, limit int64, window time.Duration) (*core.Result, error)
+
+// waitFor 按计算出的重试时间等待，直到允许通过、超出最大等待时间或 ctx 结束
+// 未设置截止时间时最多等待一个窗口
+func waitFor(ctx context.Context, take takeFunc, key string, limit int64, window time.Duration) error {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
+	}()
+
+	deadline := start.Add(window)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	for {
+		result, err := take(ctx, key, 1, limit, window)
+		if err != nil {
+			return err
+		}
+		if result.Allowed {
+			return nil
+		}
+		if result.RetryAfter < 0 {
+			return errors.NewError(codes.RateLimitConfigError, "request exceeds burst capacity", nil)
+		}
+		if time.Now().Add(result.RetryAfter).After(deadline) {
+			return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+		}
+
+		timer := time.NewTimer(result.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
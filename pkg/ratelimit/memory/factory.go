@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+)
+
+// NewLimiter 根据 LimiterOptions.Algorithm 创建进程内限流器，未设置算法时使用滑动窗口
+func NewLimiter(opts ...core.LimiterOption) (core.Limiter, error) {
+	options := &core.LimiterOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	switch options.Algorithm {
+	case "", core.AlgorithmSlidingWindow:
+		return NewSlidingWindowLimiter(opts...), nil
+	case core.AlgorithmTokenBucket:
+		return NewTokenBucketLimiter(opts...), nil
+	default:
+		return nil, errors.NewError(codes.RateLimitConfigError, "unsupported memory rate limit algorithm: "+options.Algorithm, nil)
+	}
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// window 滑动窗口计数状态，按上一个窗口的计数加权估算当前滑动窗口内的请求数
+type window struct {
+	start time.Time
+	size  time.Duration
+	prev  int64
+	curr  int64
+}
+
+func (w *window) expired(now time.Time) bool {
+	return now.Sub(w.start) >= 2*w.size
+}
+
+// advance 将窗口推进到 now 所在的固定窗口
+func (w *window) advance(now time.Time, size time.Duration) {
+	if w.size != size {
+		// 窗口大小变化时重新计数
+		*w = window{start: now.Truncate(size), size: size}
+		return
+	}
+
+	elapsed := now.Sub(w.start)
+	switch {
+	case elapsed < size:
+	case elapsed < 2*size:
+		w.prev, w.curr = w.curr, 0
+		w.start = w.start.Add(size)
+	default:
+		w.prev, w.curr = 0, 0
+		w.start = now.Truncate(size)
+	}
+}
+
+// count 估算 now 时滑动窗口内的请求数
+func (w *window) count(now time.Time) float64 {
+	weight := 1 - float64(now.Sub(w.start))/float64(w.size)
+	return float64(w.prev)*weight + float64(w.curr)
+}
+
+// 内存滑动窗口限流器实现
+type slidingWindowLimiter struct {
+	opts  *core.LimiterOptions
+	store *keyStore
+}
+
+// NewSlidingWindowLimiter 创建进程内滑动窗口限流器
+// 每个键只保存当前和上一个固定窗口的计数，内存占用与请求量无关
+func NewSlidingWindowLimiter(opts ...core.LimiterOption) core.Limiter {
+	options := &core.LimiterOptions{
+		Name:      core.AlgorithmSlidingWindow,
+		Algorithm: core.AlgorithmSlidingWindow,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	metrics.Collector.SetActiveLimiters("memory_"+core.AlgorithmSlidingWindow, 1)
+
+	return &slidingWindowLimiter{
+		opts:  options,
+		store: newKeyStore(),
+	}
+}
+
+// Allow 实现限流判断
+func (l *slidingWindowLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	return l.AllowN(ctx, key, 1, limit, window)
+}
+
+// AllowN 判断是否允许N个请求通过
+func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Wait 等待直到允许通过或超时
+func (l *slidingWindowLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	return waitFor(ctx, l.Take, key, limit, window)
+}
+
+// Reset 重置限流器
+func (l *slidingWindowLimiter) Reset(ctx context.Context, key string) error {
+	l.store.delete(key)
+	return nil
+}
+
+// Take 判断是否允许N个请求通过并返回详细结果
+func (l *slidingWindowLimiter) Take(ctx context.Context, key string, n int64, limit int64, size time.Duration) (*core.Result, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "allow", time.Since(start).Seconds())
+	}()
+
+	if err := validateArgs(n, limit, size); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := l.store.do(key, now,
+		func() entry { return &window{start: now.Truncate(size), size: size} },
+		func(e entry) *core.Result {
+			w := e.(*window)
+			w.advance(now, size)
+
+			result := &core.Result{Limit: limit}
+			switch {
+			case n > limit:
+				result.RetryAfter = -1
+			case w.count(now)+float64(n) <= float64(limit):
+				w.curr += n
+				result.Allowed = true
+			default:
+				result.RetryAfter = w.retryAfter(now, n, limit)
+			}
+
+			if remaining := limit - int64(math.Ceil(w.count(now))); remaining > 0 {
+				result.Remaining = remaining
+			}
+			// 当前窗口的计数在下一个窗口结束时完全失效
+			result.ResetAt = w.start.Add(2 * size)
+			return result
+		},
+	)
+
+	metrics.Collector.ObserveDecision(ctx, key, result.Allowed)
+	return result, nil
+}
+
+// retryAfter 计算上一个窗口的权重衰减到足以放行 n 个请求所需的时间
+func (w *window) retryAfter(now time.Time, n, limit int64) time.Duration {
+	next := w.start.Add(w.size)
+	free := limit - w.curr - n
+	if free < 0 || w.prev == 0 {
+		// 当前窗口已满，至少要等到下一个窗口
+		return next.Sub(now)
+	}
+	// prev * (1 - elapsed/size) <= free
+	at := w.start.Add(time.Duration(float64(w.size) * (1 - float64(free)/float64(w.prev))))
+	if !at.After(now) {
+		return time.Millisecond
+	}
+	return at.Sub(now)
+}
+
+// Refund 从当前窗口的计数中退还已扣减的请求数
+func (l *slidingWindowLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(key, -n, limit, window)
+}
+
+// Charge 在当前窗口追加计数，计数可以超过阈值
+func (l *slidingWindowLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(key, n, limit, window)
+}
+
+// settle 调整当前窗口的计数，delta 为正时追加扣减，为负时退还，计数不小于0
+func (l *slidingWindowLimiter) settle(key string, delta int64, limit int64, size time.Duration) error {
+	now := time.Now()
+	l.store.do(key, now,
+		func() entry { return &window{start: now.Truncate(size), size: size} },
+		func(e entry) *core.Result {
+			w := e.(*window)
+			w.advance(now, size)
+			w.curr += delta
+			if w.curr < 0 {
+				w.curr = 0
+			}
+			return nil
+		},
+	)
+	return nil
+}
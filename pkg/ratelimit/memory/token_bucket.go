@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// bucket 令牌桶状态
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full 令牌补满的时间
+	full time.Time
+}
+
+func (b *bucket) expired(now time.Time) bool {
+	return !now.Before(b.full)
+}
+
+// refill 按经过的时间补充令牌，时钟回拨时不补充
+func (b *bucket) refill(now time.Time, burst, rate float64) {
+	if now.After(b.last) {
+		b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.last))*rate)
+		b.last = now
+	}
+}
+
+// 内存令牌桶限流器实现
+type tokenBucketLimiter struct {
+	opts  *core.LimiterOptions
+	store *keyStore
+}
+
+// NewTokenBucketLimiter 创建进程内令牌桶限流器
+// 令牌以 limit/window 的速率补充，桶容量为 Burst(未设置时等于 limit)
+func NewTokenBucketLimiter(opts ...core.LimiterOption) core.Limiter {
+	options := &core.LimiterOptions{
+		Name:      core.AlgorithmTokenBucket,
+		Algorithm: core.AlgorithmTokenBucket,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	metrics.Collector.SetActiveLimiters("memory_"+core.AlgorithmTokenBucket, 1)
+
+	return &tokenBucketLimiter{
+		opts:  options,
+		store: newKeyStore(),
+	}
+}
+
+// Allow 实现限流判断
+func (l *tokenBucketLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	return l.AllowN(ctx, key, 1, limit, window)
+}
+
+// AllowN 判断是否允许N个请求通过
+func (l *tokenBucketLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Wait 等待直到允许通过或超时
+func (l *tokenBucketLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	return waitFor(ctx, l.Take, key, limit, window)
+}
+
+// Reset 重置限流器
+func (l *tokenBucketLimiter) Reset(ctx context.Context, key string) error {
+	l.store.delete(key)
+	return nil
+}
+
+// Take 判断是否允许N个请求通过并返回详细结果
+func (l *tokenBucketLimiter) Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "allow", time.Since(start).Seconds())
+	}()
+
+	if err := validateArgs(n, limit, window); err != nil {
+		return nil, err
+	}
+
+	burst, rate := l.params(limit, window)
+	now := time.Now()
+
+	result := l.store.do(key, now,
+		func() entry { return &bucket{tokens: burst, last: now} },
+		func(e entry) *core.Result {
+			b := e.(*bucket)
+			b.refill(now, burst, rate)
+
+			result := &core.Result{Limit: limit}
+			switch {
+			case float64(n) > burst:
+				result.RetryAfter = -1
+			case b.tokens >= float64(n):
+				b.tokens -= float64(n)
+				result.Allowed = true
+			default:
+				result.RetryAfter = time.Duration(math.Ceil((float64(n) - b.tokens) / rate))
+			}
+			b.full = now.Add(time.Duration((burst - b.tokens) / rate))
+
+			if b.tokens > 0 {
+				result.Remaining = int64(b.tokens)
+			}
+			result.ResetAt = b.full
+			return result
+		},
+	)
+
+	metrics.Collector.ObserveDecision(ctx, key, result.Allowed)
+	return result, nil
+}
+
+// Refund 退还已扣减的令牌，不超过桶容量
+func (l *tokenBucketLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(key, -n, limit, window)
+}
+
+// Charge 追加扣减令牌，令牌数可以为负，补充的令牌先偿还透支
+func (l *tokenBucketLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(key, n, limit, window)
+}
+
+// settle 调整令牌数，delta 为正时追加扣减，为负时退还，与 Redis 实现一致
+func (l *tokenBucketLimiter) settle(key string, delta int64, limit int64, window time.Duration) error {
+	burst, rate := l.params(limit, window)
+	now := time.Now()
+	l.store.do(key, now,
+		func() entry { return &bucket{tokens: burst, last: now} },
+		func(e entry) *core.Result {
+			b := e.(*bucket)
+			b.refill(now, burst, rate)
+			b.tokens = math.Min(burst, b.tokens-float64(delta))
+			b.full = now.Add(time.Duration((burst - b.tokens) / rate))
+			return nil
+		},
+	)
+	return nil
+}
+
+// params 返回桶容量和每纳秒补充的令牌数
+func (l *tokenBucketLimiter) params(limit int64, window time.Duration) (burst, rate float64) {
+	burst = float64(limit)
+	if l.opts.Burst > 0 {
+		burst = float64(l.opts.Burst)
+	}
+	return burst, float64(limit) / float64(window)
+}
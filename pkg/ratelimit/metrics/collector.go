@@ -1,26 +1,73 @@
 package metrics
 
 import (
+	"context"
+	"sync"
+
 	"gobase/pkg/monitor/prometheus/metric"
 )
 
+// DefaultMaxKeys 默认的 key 标签最大取值数
+const DefaultMaxKeys = 1000
+
+// OverflowKey key 标签取值数超过上限后，新的 key 统一使用该标签值
+const OverflowKey = "__overflow__"
+
 // RateLimitCollector 限流器指标收集器
+// 限流器和限流中间件统一通过该收集器上报指标，key 标签的取值数受 MaxKeys 限制
 type RateLimitCollector struct {
 	// 请求计数器
 	requestsTotal *metric.Counter
 	// 被拒绝请求计数器
 	rejectedTotal *metric.Counter
+	// 影子模式的请求计数器，不计入 requestsTotal 和 rejectedTotal
+	shadowTotal *metric.Counter
 	// 限流器延迟
 	limiterLatency *metric.Histogram
 	// 当前活跃限流器数量
 	activeLimiters *metric.Gauge
 	// 等待队列长度
 	waitingQueue *metric.Gauge
+	// 因等待队列已满被拒绝的请求
+	queueRejected *metric.Counter
+	// 等待模式下的等待时间
+	waitDuration *metric.Histogram
+	// 中间件重试次数
+	retryCount *metric.Histogram
+	// 错误类型计数器
+	errorsTotal *metric.Counter
+	// 并发限流在途请求数
+	concurrencyInFlight *metric.Gauge
+	// 并发许可排队时间
+	concurrencyWait *metric.Histogram
+	// 因并发上限被拒绝的请求
+	concurrencyRejected *metric.Counter
+
+	// key 标签基数保护
+	mu      sync.Mutex
+	maxKeys int
+	keys    map[string]struct{}
+}
+
+// Option 收集器配置选项
+type Option func(*RateLimitCollector)
+
+// WithMaxKeys 设置 key 标签的最大取值数，小于等于0时不限制
+func WithMaxKeys(n int) Option {
+	return func(c *RateLimitCollector) {
+		c.maxKeys = n
+	}
 }
 
 // NewRateLimitCollector 创建限流器指标收集器
-func NewRateLimitCollector() *RateLimitCollector {
-	c := &RateLimitCollector{}
+func NewRateLimitCollector(opts ...Option) *RateLimitCollector {
+	c := &RateLimitCollector{
+		maxKeys: DefaultMaxKeys,
+		keys:    make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
 
 	// 初始化请求计数器
 	c.requestsTotal = metric.NewCounter(metric.CounterOpts{
@@ -28,7 +75,7 @@ func NewRateLimitCollector() *RateLimitCollector {
 		Subsystem: "ratelimit",
 		Name:      "requests_total",
 		Help:      "Total number of requests handled by rate limiter",
-	}).WithLabels("key", "result") // result: allowed/rejected
+	}).WithLabels("key", "result") // result: allowed/rejected/error
 
 	// 初始化拒绝计数器
 	c.rejectedTotal = metric.NewCounter(metric.CounterOpts{
@@ -38,6 +85,14 @@ func NewRateLimitCollector() *RateLimitCollector {
 		Help:      "Total number of requests rejected by rate limiter",
 	}).WithLabels("key")
 
+	// 初始化影子模式计数器
+	c.shadowTotal = metric.NewCounter(metric.CounterOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "shadow_requests_total",
+		Help:      "Total number of requests evaluated by rate limit rules in shadow mode",
+	}).WithLabels("key", "result") // result: allowed/would_reject
+
 	// 初始化延迟直方图
 	c.limiterLatency = metric.NewHistogram(metric.HistogramOpts{
 		Namespace: "gobase",
@@ -45,7 +100,7 @@ func NewRateLimitCollector() *RateLimitCollector {
 		Name:      "latency_seconds",
 		Help:      "Latency of rate limiter operations",
 		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-	}).WithLabels("key", "operation") // operation: allow/wait
+	}).WithLabels("key", "operation") // operation: allow/wait/acquire/total
 
 	// 初始化活跃限流器计数
 	c.activeLimiters = metric.NewGauge(metric.GaugeOpts{
@@ -63,6 +118,63 @@ func NewRateLimitCollector() *RateLimitCollector {
 		Help:      "Current size of waiting queue",
 	}).WithLabels([]string{"key"})
 
+	// 初始化等待队列拒绝计数器
+	c.queueRejected = metric.NewCounter(metric.CounterOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "wait_queue_rejected_total",
+		Help:      "Total number of waiters rejected because the wait queue is full",
+	}).WithLabels("key")
+
+	// 初始化等待时间直方图
+	c.waitDuration = metric.NewHistogram(metric.HistogramOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "wait_duration_seconds",
+		Help:      "Distribution of waiting time in wait mode",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}).WithLabels("key")
+
+	// 初始化重试次数直方图
+	c.retryCount = metric.NewHistogram(metric.HistogramOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "retry_count",
+		Help:      "Distribution of retry attempts",
+		Buckets:   []float64{0, 1, 2, 3, 4, 5},
+	}).WithLabels("key", "result") // result: success/failure
+
+	// 初始化错误计数器
+	c.errorsTotal = metric.NewCounter(metric.CounterOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "errors_total",
+		Help:      "Total number of errors by type",
+	}).WithLabels("key", "type") // type: timeout/connection/other
+
+	// 初始化并发限流指标
+	c.concurrencyInFlight = metric.NewGauge(metric.GaugeOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "concurrency_in_flight",
+		Help:      "Number of in-flight requests holding a concurrency lease",
+	}).WithLabels([]string{"name"})
+
+	c.concurrencyWait = metric.NewHistogram(metric.HistogramOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "concurrency_wait_seconds",
+		Help:      "Time spent waiting for a concurrency lease",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}).WithLabels("name", "scope")
+
+	c.concurrencyRejected = metric.NewCounter(metric.CounterOpts{
+		Namespace: "gobase",
+		Subsystem: "ratelimit",
+		Name:      "concurrency_rejected_total",
+		Help:      "Total number of requests rejected by concurrency limiter",
+	}).WithLabels("name", "scope") // scope: key/global
+
 	return c
 }
 
@@ -71,8 +183,31 @@ func (c *RateLimitCollector) Register() error {
 	return metric.Register(c)
 }
 
+// SetMaxKeys 设置 key 标签的最大取值数，小于等于0时不限制，已记录的 key 不受影响
+func (c *RateLimitCollector) SetMaxKeys(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxKeys = n
+}
+
+// keyLabel 返回 key 对应的标签值，取值数达到上限后新的 key 归入 OverflowKey
+func (c *RateLimitCollector) keyLabel(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.keys[key]; ok {
+		return key
+	}
+	if c.maxKeys > 0 && len(c.keys) >= c.maxKeys {
+		return OverflowKey
+	}
+	c.keys[key] = struct{}{}
+	return key
+}
+
 // ObserveRequest 观察请求结果
 func (c *RateLimitCollector) ObserveRequest(key string, allowed bool) {
+	key = c.keyLabel(key)
 	result := "allowed"
 	if !allowed {
 		result = "rejected"
@@ -81,9 +216,50 @@ func (c *RateLimitCollector) ObserveRequest(key string, allowed bool) {
 	c.requestsTotal.WithLabelValues(key, result).Inc()
 }
 
+// ObserveDecision 观察限流器的判断结果，ctx 处于影子模式时只记入 shadow_requests_total
+// ctx 由 WithoutDecision 标记时不记录
+func (c *RateLimitCollector) ObserveDecision(ctx context.Context, key string, allowed bool) {
+	if skipDecision(ctx) {
+		return
+	}
+	if !IsShadow(ctx) {
+		c.ObserveRequest(key, allowed)
+		return
+	}
+	result := "allowed"
+	if !allowed {
+		result = "would_reject"
+	}
+	c.shadowTotal.WithLabelValues(c.keyLabel(key), result).Inc()
+}
+
+// ObserveCheckFailed 观察限流检查失败的请求
+func (c *RateLimitCollector) ObserveCheckFailed(key string) {
+	c.requestsTotal.WithLabelValues(c.keyLabel(key), "error").Inc()
+}
+
+// ObserveError 观察限流操作错误，errorType: timeout/connection/other
+func (c *RateLimitCollector) ObserveError(key string, errorType string) {
+	c.errorsTotal.WithLabelValues(c.keyLabel(key), errorType).Inc()
+}
+
 // ObserveLatency 观察操作延迟
 func (c *RateLimitCollector) ObserveLatency(key string, operation string, duration float64) {
-	c.limiterLatency.WithLabelValues(key, operation).Observe(duration)
+	c.limiterLatency.WithLabelValues(c.keyLabel(key), operation).Observe(duration)
+}
+
+// ObserveWait 观察等待模式下的等待时间
+func (c *RateLimitCollector) ObserveWait(key string, duration float64) {
+	c.waitDuration.WithLabelValues(c.keyLabel(key)).Observe(duration)
+}
+
+// ObserveRetry 观察重试次数
+func (c *RateLimitCollector) ObserveRetry(key string, success bool, attempts int) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	c.retryCount.WithLabelValues(c.keyLabel(key), result).Observe(float64(attempts))
 }
 
 // SetActiveLimiters 设置活跃限流器数量
@@ -93,35 +269,58 @@ func (c *RateLimitCollector) SetActiveLimiters(limiterType string, count float64
 
 // SetWaitingQueueSize 设置等待队列长度
 func (c *RateLimitCollector) SetWaitingQueueSize(key string, size float64) {
-	c.waitingQueue.WithLabelValues(key).Set(size)
+	c.waitingQueue.WithLabelValues(c.keyLabel(key)).Set(size)
 }
 
-// Describe 实现 Collector 接口
-func (c *RateLimitCollector) Describe(ch chan<- *metric.Desc) {
-	collectors := []metric.Collector{
+// ObserveQueueRejected 观察因等待队列已满被拒绝的请求
+func (c *RateLimitCollector) ObserveQueueRejected(key string) {
+	c.queueRejected.WithLabelValues(c.keyLabel(key)).Inc()
+}
+
+// AddInFlight 调整并发限流的在途请求数
+func (c *RateLimitCollector) AddInFlight(name string, delta float64) {
+	c.concurrencyInFlight.WithLabelValues(name).Add(delta)
+}
+
+// ObserveConcurrencyWait 观察获取并发许可的排队时间
+func (c *RateLimitCollector) ObserveConcurrencyWait(name, scope string, duration float64) {
+	c.concurrencyWait.WithLabelValues(name, scope).Observe(duration)
+}
+
+// ObserveConcurrencyRejected 观察因并发上限被拒绝的请求
+func (c *RateLimitCollector) ObserveConcurrencyRejected(name, scope string) {
+	c.concurrencyRejected.WithLabelValues(name, scope).Inc()
+}
+
+// collectors 返回所有底层指标
+func (c *RateLimitCollector) collectors() []metric.Collector {
+	return []metric.Collector{
 		c.requestsTotal.GetCollector(),
 		c.rejectedTotal.GetCollector(),
+		c.shadowTotal.GetCollector(),
 		c.limiterLatency.GetCollector(),
 		c.activeLimiters.GetCollector(),
 		c.waitingQueue.GetCollector(),
+		c.queueRejected.GetCollector(),
+		c.waitDuration.GetCollector(),
+		c.retryCount.GetCollector(),
+		c.errorsTotal.GetCollector(),
+		c.concurrencyInFlight.GetCollector(),
+		c.concurrencyWait.GetCollector(),
+		c.concurrencyRejected.GetCollector(),
 	}
+}
 
-	for _, collector := range collectors {
+// Describe 实现 Collector 接口
+func (c *RateLimitCollector) Describe(ch chan<- *metric.Desc) {
+	for _, collector := range c.collectors() {
 		collector.Describe(ch)
 	}
 }
 
 // Collect 实现 Collector 接口
 func (c *RateLimitCollector) Collect(ch chan<- metric.Metric) {
-	collectors := []metric.Collector{
-		c.requestsTotal.GetCollector(),
-		c.rejectedTotal.GetCollector(),
-		c.limiterLatency.GetCollector(),
-		c.activeLimiters.GetCollector(),
-		c.waitingQueue.GetCollector(),
-	}
-
-	for _, collector := range collectors {
+	for _, collector := range c.collectors() {
 		collector.Collect(ch)
 	}
 }
@@ -0,0 +1,21 @@
+package metrics
+
+import "context"
+
+// skipDecisionKey 跳过判断结果记录的 context 键
+type skipDecisionKey struct{}
+
+// WithoutDecision 标记 ctx 的判断结果由调用方记录
+// 组合限流器调用内部限流器时使用，避免同一个请求被重复计数
+func WithoutDecision(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipDecisionKey{}, true)
+}
+
+// skipDecision 判断 ctx 是否跳过判断结果记录
+func skipDecision(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipDecisionKey{}).(bool)
+	return skip
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import "context"
+
+// shadowKey 影子模式的 context 键
+type shadowKey struct{}
+
+// WithShadow 标记 ctx 处于影子模式
+// 限流器使用该 ctx 判断时照常计数，但结果只记入 shadow_requests_total，不计入 requests_total 和 rejected_total
+func WithShadow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shadowKey{}, true)
+}
+
+// IsShadow 判断 ctx 是否处于影子模式
+func IsShadow(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	shadow, _ := ctx.Value(shadowKey{}).(bool)
+	return shadow
+}
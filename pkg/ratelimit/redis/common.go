@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// takeFunc 执行一次限流判断
+type takeFunc func(ctx context.Context, key string, n, limit int64, window time.Duration) (*core.Result, error)
+
+// validateArgs 校验限流参数
+func validateArgs(n, limit int64, window time.Duration) error {
+	if n <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "n must be positive", nil)
+	}
+	if limit <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "limit must be positive", nil)
+	}
+	if window <= 0 {
+		return errors.NewError(codes.RateLimitConfigError, "window must be positive", nil)
+	}
+	return nil
+}
+
+// burstOf 返回突发容量，未配置时等于limit
+func burstOf(burst, limit int64) int64 {
+	if burst <= 0 {
+		return limit
+	}
+	return burst
+}
+
+// parseResult 解析脚本返回的 {allowed, remaining, retry_after_us, reset_after_us}
+func parseResult(result interface{}, limit int64, now time.Time) (*core.Result, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 4 {
+		return nil, errors.NewError(codes.RateLimitStoreError, "unexpected rate limit script result", nil)
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, errors.NewError(codes.RateLimitStoreError, "unexpected rate limit script result", nil)
+		}
+		ints[i] = n
+	}
+
+	retryAfter := time.Duration(ints[2]) * time.Microsecond
+	if ints[2] < 0 {
+		retryAfter = -1
+	}
+
+	return &core.Result{
+		Allowed:    ints[0] == 1,
+		Limit:      limit,
+		Remaining:  ints[1],
+		RetryAfter: retryAfter,
+		ResetAt:    now.Add(time.Duration(ints[3]) * time.Microsecond),
+	}, nil
+}
+
+// waitFor 根据脚本返回的重试时间等待，直到允许通过、超出最大等待时间或 ctx 结束
+// 与滑动窗口一致，未设置截止时间时最多等待一个窗口
+func waitFor(ctx context.Context, take takeFunc, key string, limit int64, window time.Duration) error {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
+	}()
+
+	deadline := start.Add(window)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	for {
+		result, err := take(ctx, key, 1, limit, window)
+		if err != nil {
+			return err
+		}
+		if result.Allowed {
+			return nil
+		}
+		if result.RetryAfter < 0 {
+			return errors.NewError(codes.RateLimitConfigError, "request exceeds burst capacity", nil)
+		}
+		if time.Now().Add(result.RetryAfter).After(deadline) {
+			return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+		}
+
+		timer := time.NewTimer(result.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+)
+
+// NewLimiter 根据 LimiterOptions.Algorithm 创建限流器，未设置算法时使用滑动窗口
+func NewLimiter(store *ratelimit.Store, opts ...core.LimiterOption) (core.Limiter, error) {
+	if store == nil {
+		return nil, errors.NewError(codes.RateLimitInitError, "store is required", nil)
+	}
+
+	options := &core.LimiterOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	switch options.Algorithm {
+	case "", core.AlgorithmSlidingWindow:
+		return NewSlidingWindowLimiter(store, opts...), nil
+	case core.AlgorithmTokenBucket:
+		return NewTokenBucketLimiter(store, opts...), nil
+	case core.AlgorithmGCRA:
+		return NewGCRALimiter(store, opts...), nil
+	default:
+		return nil, errors.NewError(codes.RateLimitConfigError, "unsupported rate limit algorithm: "+options.Algorithm, nil)
+	}
+}
@@ -0,0 +1,227 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// gcraScript GCRA脚本
+// 状态只有理论到达时间(TAT)一个值，时间单位为微秒
+// 返回 {allowed, remaining, retry_after_us, reset_after_us}
+const gcraScript = `
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local now = tonumber(ARGV[5])
+
+local interval = window / limit
+local tolerance = interval * burst
+
+local tat = tonumber(redis.call('GET', key))
+if tat == nil or tat < now then
+    tat = now
+end
+
+if n > burst then
+    return {0, math.max(0, math.floor((tolerance - (tat - now)) / interval)), -1, math.ceil(tat - now)}
+end
+
+local new_tat = tat + interval * n
+local allow_at = new_tat - tolerance
+if allow_at > now then
+    local remaining = math.floor((tolerance - (tat - now)) / interval)
+    if remaining < 0 then
+        remaining = 0
+    end
+    return {0, remaining, math.ceil(allow_at - now), math.ceil(tat - now)}
+end
+
+redis.call('SET', key, tostring(new_tat), 'PX', math.ceil((new_tat - now) / 1000) + 1)
+return {1, math.floor((tolerance - (new_tat - now)) / interval), 0, math.ceil(new_tat - now)}
+`
+
+// gcraSettleScript 结算脚本，按 delta 个请求间隔移动理论到达时间
+// delta 为负时退还，TAT 不早于当前时间；为正时追加扣减，TAT 可以超出容忍范围
+const gcraSettleScript = `
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local delta = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local interval = window / limit
+local tat = tonumber(redis.call('GET', key))
+if tat == nil or tat < now then
+    tat = now
+end
+
+tat = tat + interval * delta
+if tat <= now then
+    redis.call('DEL', key)
+    return 1
+end
+
+redis.call('SET', key, tostring(tat), 'PX', math.ceil((tat - now) / 1000) + 1)
+return 1
+`
+
+// GCRA限流器实现
+type gcraLimiter struct {
+	store *ratelimit.Store
+	opts  *core.LimiterOptions
+	log   types.Logger
+	// queue 公平等待队列，未启用时为空
+	queue *waitQueue
+}
+
+// NewGCRALimiter 创建GCRA限流器
+// 请求按 window/limit 的间隔均匀放行，最多允许 Burst(未设置时等于 limit) 个请求突发
+func NewGCRALimiter(store *ratelimit.Store, opts ...core.LimiterOption) core.Limiter {
+	options := &core.LimiterOptions{
+		Name:      core.AlgorithmGCRA,
+		Algorithm: core.AlgorithmGCRA,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	limiter := &gcraLimiter{
+		store: store,
+		opts:  options,
+		log: logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "ratelimit"},
+			types.Field{Key: "type", Value: core.AlgorithmGCRA},
+		),
+	}
+
+	if options.WaitQueueDepth > 0 {
+		limiter.queue = newWaitQueue(store, options.WaitQueueDepth, limiter.log)
+	}
+
+	metrics.Collector.SetActiveLimiters(core.AlgorithmGCRA, 1)
+	limiter.log.Info(context.Background(), "created new gcra rate limiter")
+
+	return limiter
+}
+
+// Close 停止等待队列的订阅，未启用等待队列时无操作
+func (l *gcraLimiter) Close() error {
+	if l.queue != nil {
+		l.queue.close()
+	}
+	return nil
+}
+
+// Allow 实现限流判断
+func (l *gcraLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	return l.AllowN(ctx, key, 1, limit, window)
+}
+
+// AllowN 判断是否允许N个请求通过
+func (l *gcraLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Take 判断是否允许N个请求通过并返回详细结果
+func (l *gcraLimiter) Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "allow", time.Since(start).Seconds())
+	}()
+
+	if err := validateArgs(n, limit, window); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := l.store.Eval(ctx, gcraScript, []string{key},
+		burstOf(l.opts.Burst, limit),
+		limit,
+		window.Microseconds(),
+		n,
+		now.UnixMicro(),
+	)
+	if err != nil {
+		l.log.Error(ctx, "failed to evaluate gcra script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return nil, errors.NewError(codes.RateLimitStoreError, "failed to evaluate gcra script", err)
+	}
+
+	res, err := parseResult(result, limit, now)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Collector.ObserveDecision(ctx, key, res.Allowed)
+	return res, nil
+}
+
+// Wait 等待直到允许通过或超时
+func (l *gcraLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	if l.queue != nil {
+		return l.queue.wait(ctx, l.Take, key, limit, window)
+	}
+	return waitFor(ctx, l.Take, key, limit, window)
+}
+
+// Reset 重置限流器
+func (l *gcraLimiter) Reset(ctx context.Context, key string) error {
+	if err := l.store.Del(ctx, key); err != nil {
+		l.log.Error(ctx, "failed to reset rate limiter",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to reset rate limiter", err)
+	}
+	return nil
+}
+
+// Refund 退还已扣减的配额
+func (l *gcraLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, -n, limit, window)
+}
+
+// Charge 追加扣减配额，超出突发容量的部分会推迟后续请求的放行时间
+func (l *gcraLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, n, limit, window)
+}
+
+// settle 按 delta 个请求间隔调整理论到达时间，delta 为正时追加扣减，为负时退还
+func (l *gcraLimiter) settle(ctx context.Context, key string, delta int64, limit int64, window time.Duration) error {
+	_, err := l.store.Eval(ctx, gcraSettleScript, []string{key},
+		limit,
+		window.Microseconds(),
+		delta,
+		time.Now().UnixMicro(),
+	)
+	if err != nil {
+		l.log.Error(ctx, "failed to evaluate gcra settle script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to settle gcra", err)
+	}
+	return nil
+}
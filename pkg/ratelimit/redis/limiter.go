@@ -9,50 +9,24 @@ import (
 	"gobase/pkg/errors/codes"
 	"gobase/pkg/logger"
 	"gobase/pkg/logger/types"
-	"gobase/pkg/monitor/prometheus/metric"
 	"gobase/pkg/ratelimit/core"
 	"gobase/pkg/ratelimit/metrics"
 )
 
-var (
-	// RequestsTotal 记录所有限流请求
-	RequestsTotal = metric.NewCounter(metric.CounterOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "requests_total",
-		Help:      "Total number of requests handled by rate limiter",
-	}).WithLabels("key", "result") // result: allowed/rejected
-
-	// RejectedTotal 记录被限流的请求
-	RejectedTotal = metric.NewCounter(metric.CounterOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "rejected_total",
-		Help:      "Total number of requests rejected by rate limiter",
-	}).WithLabels("key")
-
-	// LimiterLatency 记录限流器处理延迟
-	LimiterLatency = metric.NewHistogram(metric.HistogramOpts{
-		Namespace: "gobase",
-		Subsystem: "ratelimit",
-		Name:      "latency_seconds",
-		Help:      "Latency of rate limiter operations",
-		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-	}).WithLabels("key", "operation") // operation: allow/wait
-)
-
 // 滑动窗口限流器实现
 type slidingWindowLimiter struct {
 	store *ratelimit.Store
 	opts  *core.LimiterOptions
 	log   types.Logger
+	// queue 公平等待队列，未启用时为空
+	queue *waitQueue
 }
 
 // 创建新的滑动窗口限流器
 func NewSlidingWindowLimiter(store *ratelimit.Store, opts ...core.LimiterOption) core.Limiter {
 	options := &core.LimiterOptions{
-		Name:      "sliding_window",
-		Algorithm: "sliding_window",
+		Name:      core.AlgorithmSlidingWindow,
+		Algorithm: core.AlgorithmSlidingWindow,
 	}
 
 	for _, opt := range opts {
@@ -71,13 +45,25 @@ func NewSlidingWindowLimiter(store *ratelimit.Store, opts ...core.LimiterOption)
 		log:   defaultLogger,
 	}
 
+	if options.WaitQueueDepth > 0 {
+		limiter.queue = newWaitQueue(store, options.WaitQueueDepth, limiter.log)
+	}
+
 	// 更新活跃限流器计数
-	metrics.Collector.SetActiveLimiters("sliding_window", 1)
+	metrics.Collector.SetActiveLimiters(core.AlgorithmSlidingWindow, 1)
 	limiter.log.Info(context.Background(), "created new sliding window rate limiter")
 
 	return limiter
 }
 
+// Close 停止等待队列的订阅，未启用等待队列时无操作
+func (l *slidingWindowLimiter) Close() error {
+	if l.queue != nil {
+		l.queue.close()
+	}
+	return nil
+}
+
 // Allow 实现限流判断
 func (l *slidingWindowLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
 	return l.AllowN(ctx, key, 1, limit, window)
@@ -85,6 +71,15 @@ func (l *slidingWindowLimiter) Allow(ctx context.Context, key string, limit int6
 
 // AllowN 判断是否允许N个请求通过
 func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Take 判断是否允许N个请求通过并返回详细结果
+func (l *slidingWindowLimiter) Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
 	start := time.Now()
 	defer func() {
 		metrics.Collector.ObserveLatency(key, "allow", time.Since(start).Seconds())
@@ -98,10 +93,10 @@ func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64,
 	)
 
 	// 获取当前时间戳（毫秒级别即可）
-	now := time.Now().UnixMilli()
+	now := time.Now()
 	counterKey := key + ":counter"
 
-	// 修改 Lua 脚本
+	// 返回 {allowed, remaining, retry_after_us, reset_after_us}
 	script := `
         local key = KEYS[1]
         local counter_key = KEYS[2]
@@ -113,19 +108,39 @@ func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64,
         -- 清理过期数据
         redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
         
-        -- 获取当前窗口内的请求总数
+        -- 获取当前窗口内的请求总数，记录按时间升序排列
         local total = 0
+        local entries = {}
         local members = redis.call('ZRANGE', key, 0, -1, 'WITHSCORES')
         for i = 1, #members, 2 do
             local count = tonumber(redis.call('HGET', counter_key, members[i]))
             if count then
                 total = total + count
+                entries[#entries + 1] = {tonumber(members[i + 1]), count}
             end
         end
         
         -- 检查是否超过限制
         if (total + n) > limit then
-            return 0
+            local remaining = math.max(limit - total, 0)
+            local reset = 0
+            if #entries > 0 then
+                reset = entries[#entries][1] + window - now
+            end
+            if n > limit then
+                return {0, remaining, -1, reset * 1000}
+            end
+            -- 从最早的记录开始过期，直到腾出足够的配额
+            local retry = 0
+            local freed = 0
+            for _, entry in ipairs(entries) do
+                freed = freed + entry[2]
+                if total - freed + n <= limit then
+                    retry = entry[1] + window - now
+                    break
+                end
+            end
+            return {0, remaining, retry * 1000, reset * 1000}
         end
         
         -- 添加新请求记录
@@ -137,12 +152,12 @@ func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64,
         redis.call('EXPIRE', key, math.ceil(window/1000) + 1)
         redis.call('EXPIRE', counter_key, math.ceil(window/1000) + 1)
         
-        return 1
+        return {1, limit - total - n, 0, window * 1000}
     `
 
 	// 执行Redis Lua脚本
 	result, err := l.store.Eval(ctx, script, []string{key, counterKey},
-		now,                   // 当前时间戳（毫秒）
+		now.UnixMilli(),       // 当前时间戳（毫秒）
 		window.Milliseconds(), // 窗口大小（毫秒）
 		limit,                 // 限制数量
 		n,                     // 请求数量
@@ -152,24 +167,31 @@ func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int64,
 			types.Field{Key: "key", Value: key},
 			types.Field{Key: "error", Value: err},
 		)
-		return false, errors.Wrap(err, "failed to evaluate rate limit script")
+		return nil, errors.Wrap(err, "failed to evaluate rate limit script")
 	}
 
-	allowed := result.(int64) == 1
-	metrics.Collector.ObserveRequest(key, allowed)
+	res, err := parseResult(result, limit, now)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Collector.ObserveDecision(ctx, key, res.Allowed)
 
-	if !allowed {
+	if !res.Allowed {
 		l.log.Debug(ctx, "rate limit exceeded",
 			types.Field{Key: "key", Value: key},
 			types.Field{Key: "limit", Value: limit},
 		)
 	}
 
-	return allowed, nil
+	return res, nil
 }
 
 // Wait 等待直到允许通过或超时
 func (l *slidingWindowLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	if l.queue != nil {
+		return l.queue.wait(ctx, l.Take, key, limit, window)
+	}
+
 	start := time.Now()
 	defer func() {
 		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
@@ -265,4 +287,74 @@ func (l *slidingWindowLimiter) Reset(ctx context.Context, key string) error {
 	return nil
 }
 
-// ... 实现其他接口方法 ...
+// slidingWindowSettleScript 结算脚本
+// delta 为正时在当前时刻追加一条记录，为负时从最新的记录开始扣减计数
+const slidingWindowSettleScript = `
+local key = KEYS[1]
+local counter_key = KEYS[2]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local delta = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+if delta > 0 then
+    local member = tostring(now)
+    redis.call('ZADD', key, now, member)
+    redis.call('HINCRBY', counter_key, member, delta)
+    redis.call('EXPIRE', key, math.ceil(window/1000) + 1)
+    redis.call('EXPIRE', counter_key, math.ceil(window/1000) + 1)
+    return 1
+end
+
+local n = -delta
+local members = redis.call('ZREVRANGE', key, 0, -1)
+for _, member in ipairs(members) do
+    if n <= 0 then
+        break
+    end
+    local count = tonumber(redis.call('HGET', counter_key, member)) or 0
+    if count <= n then
+        redis.call('ZREM', key, member)
+        redis.call('HDEL', counter_key, member)
+        n = n - count
+    else
+        redis.call('HINCRBY', counter_key, member, -n)
+        n = 0
+    end
+end
+return 1
+`
+
+// Refund 从最新的请求记录开始退还已扣减的请求数
+func (l *slidingWindowLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, -n, limit, window)
+}
+
+// Charge 在当前时刻追加请求记录，窗口内的请求数可以超过阈值
+func (l *slidingWindowLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, n, limit, window)
+}
+
+// settle 按 delta 调整窗口内的请求数，delta 为正时追加扣减，为负时退还
+func (l *slidingWindowLimiter) settle(ctx context.Context, key string, delta int64, limit int64, window time.Duration) error {
+	_, err := l.store.Eval(ctx, slidingWindowSettleScript, []string{key, key + ":counter"},
+		time.Now().UnixMilli(),
+		window.Milliseconds(),
+		delta,
+	)
+	if err != nil {
+		l.log.Error(ctx, "failed to evaluate sliding window settle script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to settle sliding window", err)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// queueChannel 队首变化通知频道，消息内容为新队首的排队号
+const queueChannel = "ratelimit:queue:notify"
+
+// queuePollInterval 未收到通知时检查排队位置的间隔，用于兜底丢失的通知和崩溃的队首
+const queuePollInterval = 200 * time.Millisecond
+
+// queueResubscribeInterval 订阅断开后重新订阅的间隔
+const queueResubscribeInterval = time.Second
+
+// queueCleanup 清理已超过截止时间的排队号，崩溃的等待者在截止时间后被移出队列
+const queueCleanup = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', now)
+for _, t in ipairs(expired) do
+    redis.call('ZREM', KEYS[1], t)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now)
+`
+
+// queueEnqueueScript 领取排队号
+// KEYS[1] 为按领取顺序排列的队列，KEYS[2] 为排队号的截止时间(毫秒)，KEYS[3] 为序号计数器
+// 返回 {enqueued, depth, position}，队列已满时 enqueued 为0
+const queueEnqueueScript = `
+local ticket = ARGV[1]
+local now = tonumber(ARGV[2])
+local deadline = tonumber(ARGV[3])
+local max_depth = tonumber(ARGV[4])
+` + queueCleanup + `
+local depth = redis.call('ZCARD', KEYS[1])
+if depth >= max_depth then
+    return {0, depth, -1}
+end
+
+local seq = redis.call('INCR', KEYS[3])
+redis.call('ZADD', KEYS[1], seq, ticket)
+redis.call('ZADD', KEYS[2], deadline, ticket)
+
+-- 队列在最晚的截止时间之后过期
+local ttl = deadline - now + 1000
+for i = 1, 3 do
+    if redis.call('PTTL', KEYS[i]) < ttl then
+        redis.call('PEXPIRE', KEYS[i], ttl)
+    end
+end
+return {1, depth + 1, redis.call('ZRANK', KEYS[1], ticket)}
+`
+
+// queuePositionScript 查询排队位置，排队号已被移出队列时返回 -1
+// 返回 {position, depth}
+const queuePositionScript = `
+local ticket = ARGV[1]
+local now = tonumber(ARGV[2])
+` + queueCleanup + `
+local rank = redis.call('ZRANK', KEYS[1], ticket)
+if not rank then
+    rank = -1
+end
+return {rank, redis.call('ZCARD', KEYS[1])}
+`
+
+// queueLeaveScript 离开队列
+// 返回 {depth, next}，next 为新的队首排队号，队列为空时为空字符串
+const queueLeaveScript = `
+local ticket = ARGV[1]
+local now = tonumber(ARGV[2])
+redis.call('ZREM', KEYS[1], ticket)
+redis.call('ZREM', KEYS[2], ticket)
+` + queueCleanup + `
+local head = redis.call('ZRANGE', KEYS[1], 0, 0)
+local next = ''
+if #head > 0 then
+    next = head[1]
+end
+return {redis.call('ZCARD', KEYS[1]), next}
+`
+
+// waitQueue 公平等待队列
+// 等待者按到达顺序领取排队号，只有队首访问限流器，队首离开时通过发布订阅唤醒下一个等待者
+type waitQueue struct {
+	store    *ratelimit.Store
+	maxDepth int64
+	log      types.Logger
+
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+
+	// ctx 订阅的生命周期，close 时取消
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	done    chan struct{}
+}
+
+// newWaitQueue 创建公平等待队列
+func newWaitQueue(store *ratelimit.Store, maxDepth int64, log types.Logger) *waitQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &waitQueue{
+		store:    store,
+		maxDepth: maxDepth,
+		log:      log,
+		waiters:  make(map[string]chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// close 停止订阅并等待订阅协程退出，之后的等待者按 queuePollInterval 轮询
+func (q *waitQueue) close() {
+	q.mu.Lock()
+	q.cancel()
+	started := q.started
+	q.mu.Unlock()
+
+	if started {
+		<-q.done
+	}
+}
+
+// queueKeys 返回限流键对应的队列键
+// 使用相同的哈希标签，保证 Redis Cluster 下脚本访问的键位于同一槽位
+func queueKeys(key string) []string {
+	tag := "{" + key + "}"
+	return []string{tag + ":queue", tag + ":queue:deadline", tag + ":queue:seq"}
+}
+
+// wait 排队等待直到允许通过、队列已满、超出最大等待时间或 ctx 结束
+// 与 waitFor 一致，未设置截止时间时最多等待一个窗口
+func (q *waitQueue) wait(ctx context.Context, take takeFunc, key string, limit int64, window time.Duration) error {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
+	}()
+
+	deadline := start.Add(window)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// 先登记唤醒通道再入队，避免错过成为队首的通知
+	ticket := uuid.NewString()
+	wake := q.register(ticket)
+	defer q.unregister(ticket)
+
+	position, err := q.enqueue(ctx, key, ticket, start, deadline)
+	if err != nil {
+		return err
+	}
+	if position < 0 {
+		metrics.Collector.ObserveQueueRejected(key)
+		return errors.NewError(codes.TooManyRequests, "wait queue is full", nil)
+	}
+	defer q.leave(ctx, key, ticket)
+
+	for {
+		if position == 0 {
+			result, err := take(ctx, key, 1, limit, window)
+			if err != nil {
+				return err
+			}
+			if result.Allowed {
+				return nil
+			}
+			if result.RetryAfter < 0 {
+				return errors.NewError(codes.RateLimitConfigError, "request exceeds burst capacity", nil)
+			}
+			if time.Now().Add(result.RetryAfter).After(deadline) {
+				return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+			}
+			if err := sleepCtx(ctx, result.RetryAfter); err != nil {
+				return err
+			}
+			continue
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+		}
+		poll := queuePollInterval
+		if remaining < poll {
+			poll = remaining
+		}
+
+		timer := time.NewTimer(poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if position, err = q.position(ctx, key, ticket); err != nil {
+			return err
+		}
+		if position < 0 {
+			// 排队号已超过截止时间被清理
+			return errors.NewError(codes.TooManyRequests, "wait timeout exceeded", nil)
+		}
+	}
+}
+
+// enqueue 领取排队号，返回排队位置，队列已满时返回 -1
+func (q *waitQueue) enqueue(ctx context.Context, key, ticket string, now, deadline time.Time) (int64, error) {
+	result, err := q.store.Eval(ctx, queueEnqueueScript, queueKeys(key),
+		ticket,
+		now.UnixMilli(),
+		deadline.UnixMilli(),
+		q.maxDepth,
+	)
+	if err != nil {
+		q.log.Error(ctx, "failed to enqueue waiter",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return 0, errors.NewError(codes.RateLimitStoreError, "failed to enqueue waiter", err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		return 0, errors.NewError(codes.RateLimitStoreError, "unexpected wait queue script result", nil)
+	}
+	depth, _ := values[1].(int64)
+	position, _ := values[2].(int64)
+
+	metrics.Collector.SetWaitingQueueSize(key, float64(depth))
+	return position, nil
+}
+
+// position 查询排队位置
+func (q *waitQueue) position(ctx context.Context, key, ticket string) (int64, error) {
+	result, err := q.store.Eval(ctx, queuePositionScript, queueKeys(key), ticket, time.Now().UnixMilli())
+	if err != nil {
+		return 0, errors.NewError(codes.RateLimitStoreError, "failed to query wait queue position", err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, errors.NewError(codes.RateLimitStoreError, "unexpected wait queue script result", nil)
+	}
+	position, _ := values[0].(int64)
+	depth, _ := values[1].(int64)
+
+	metrics.Collector.SetWaitingQueueSize(key, float64(depth))
+	return position, nil
+}
+
+// leave 离开队列并唤醒新的队首，失败时排队号在截止时间后被清理
+func (q *waitQueue) leave(ctx context.Context, key, ticket string) {
+	// 请求 ctx 可能已经结束，离开队列不能因此失败
+	ctx = context.WithoutCancel(ctx)
+
+	result, err := q.store.Eval(ctx, queueLeaveScript, queueKeys(key), ticket, time.Now().UnixMilli())
+	if err != nil {
+		q.log.Warn(ctx, "failed to leave wait queue",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return
+	}
+	depth, _ := values[0].(int64)
+	next, _ := values[1].(string)
+
+	metrics.Collector.SetWaitingQueueSize(key, float64(depth))
+	if next == "" {
+		return
+	}
+	if err := q.store.Publish(ctx, queueChannel, next); err != nil {
+		q.log.Warn(ctx, "failed to notify next waiter",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+	}
+}
+
+// register 登记排队号的唤醒通道，首次使用时启动订阅
+func (q *waitQueue) register(ticket string) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	q.mu.Lock()
+	if !q.started && q.ctx.Err() == nil {
+		q.started = true
+		go q.subscribe()
+	}
+	q.waiters[ticket] = ch
+	q.mu.Unlock()
+	return ch
+}
+
+// unregister 注销排队号的唤醒通道
+func (q *waitQueue) unregister(ticket string) {
+	q.mu.Lock()
+	delete(q.waiters, ticket)
+	q.mu.Unlock()
+}
+
+// notify 唤醒本实例中持有该排队号的等待者
+func (q *waitQueue) notify(ticket string) {
+	q.mu.Lock()
+	ch, ok := q.waiters[ticket]
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// subscribe 订阅队首变化通知直到 close，订阅断开期间等待者退化为按 queuePollInterval 轮询
+func (q *waitQueue) subscribe() {
+	defer close(q.done)
+
+	ctx := q.ctx
+	for {
+		sub := q.store.Subscribe(ctx, queueChannel)
+		// 阻塞中的读取不一定响应 ctx 取消，关闭订阅使其返回
+		stop := context.AfterFunc(ctx, func() { sub.Close() })
+		for {
+			msg, err := sub.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					q.log.Warn(ctx, "wait queue subscription interrupted", types.Error(err))
+				}
+				break
+			}
+			q.notify(msg.Payload)
+		}
+		if stop() {
+			sub.Close()
+		}
+		if err := sleepCtx(ctx, queueResubscribeInterval); err != nil {
+			return
+		}
+	}
+}
+
+// sleepCtx 等待 d 或直到 ctx 结束
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
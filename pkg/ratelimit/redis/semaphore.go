@@ -0,0 +1,337 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// 并发限流器类型
+const semaphoreType = "semaphore"
+
+// semaphoreAcquireScript 获取许可
+// 许可保存在有序集合中，成员为许可ID，分数为过期时间(毫秒)，先清理过期许可再判断数量
+// 返回 {acquired, in_flight}
+const semaphoreAcquireScript = `
+local key = KEYS[1]
+local id = ARGV[1]
+local limit = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+local count = redis.call('ZCARD', key)
+if count >= limit then
+    return {0, count}
+end
+
+redis.call('ZADD', key, now + ttl, id)
+redis.call('PEXPIRE', key, ttl)
+return {1, count + 1}
+`
+
+// semaphoreRefreshScript 续期许可，许可不存在或已过期时返回0
+const semaphoreRefreshScript = `
+local key = KEYS[1]
+local id = ARGV[1]
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local expires = tonumber(redis.call('ZSCORE', key, id))
+if expires == nil then
+    return 0
+end
+if expires <= now then
+    redis.call('ZREM', key, id)
+    return 0
+end
+
+redis.call('ZADD', key, now + ttl, id)
+if redis.call('PTTL', key) < ttl then
+    redis.call('PEXPIRE', key, ttl)
+end
+return 1
+`
+
+// semaphoreReleaseScript 释放许可
+const semaphoreReleaseScript = `
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`
+
+// semaphoreCountScript 统计未过期的许可数
+const semaphoreCountScript = `
+return redis.call('ZCOUNT', KEYS[1], '(' .. ARGV[1], '+inf')
+`
+
+// semaphore 基于Redis有序集合的分布式并发限流器
+type semaphore struct {
+	store *ratelimit.Store
+	opts  *core.ConcurrencyOptions
+	log   types.Logger
+}
+
+// NewSemaphore 创建Redis并发限流器
+// 每个许可带有有效期，持有期间自动续期，持有者崩溃后许可在有效期结束时释放
+func NewSemaphore(store *ratelimit.Store, opts ...core.ConcurrencyOption) (core.ConcurrencyLimiter, error) {
+	if store == nil {
+		return nil, errors.NewError(codes.RateLimitInitError, "redis store is required", nil)
+	}
+
+	options := core.DefaultConcurrencyOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.LeaseTTL <= 0 {
+		return nil, errors.NewError(codes.RateLimitConfigError, "lease ttl must be positive", nil)
+	}
+	if options.RetryInterval <= 0 {
+		return nil, errors.NewError(codes.RateLimitConfigError, "retry interval must be positive", nil)
+	}
+	if options.RenewInterval == 0 {
+		options.RenewInterval = options.LeaseTTL / 3
+	}
+	if options.RenewInterval >= options.LeaseTTL {
+		return nil, errors.NewError(codes.RateLimitConfigError, "renew interval must be less than lease ttl", nil)
+	}
+
+	s := &semaphore{
+		store: store,
+		opts:  options,
+		log: logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "ratelimit"},
+			types.Field{Key: "type", Value: semaphoreType},
+		),
+	}
+
+	metrics.Collector.SetActiveLimiters(semaphoreType, 1)
+	s.log.Info(context.Background(), "created new redis semaphore")
+
+	return s, nil
+}
+
+// TryAcquire 尝试获取许可
+func (s *semaphore) TryAcquire(ctx context.Context, key string, limit int64) (core.Lease, bool, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "acquire", time.Since(start).Seconds())
+	}()
+
+	if limit <= 0 {
+		return nil, false, errors.NewError(codes.RateLimitConfigError, "limit must be positive", nil)
+	}
+
+	id := uuid.NewString()
+	result, err := s.store.Eval(ctx, semaphoreAcquireScript, []string{key},
+		id,
+		limit,
+		time.Now().UnixMilli(),
+		s.opts.LeaseTTL.Milliseconds(),
+	)
+	if err != nil {
+		s.log.Error(ctx, "failed to evaluate semaphore acquire script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return nil, false, errors.NewError(codes.RateLimitStoreError, "failed to acquire semaphore", err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, false, errors.NewError(codes.RateLimitStoreError, "unexpected semaphore script result", nil)
+	}
+	acquired, _ := values[0].(int64)
+
+	// 并发许可不计入限流请求指标，由并发中间件记录在途数和拒绝数
+	if acquired != 1 {
+		return nil, false, nil
+	}
+
+	lease := newSemaphoreLease(s, key, id)
+	if s.opts.RenewInterval > 0 {
+		lease.startWatchdog()
+	}
+	return lease, true, nil
+}
+
+// Acquire 等待直到获取许可或 ctx 结束
+func (s *semaphore) Acquire(ctx context.Context, key string, limit int64) (core.Lease, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "wait", time.Since(start).Seconds())
+	}()
+
+	for {
+		lease, ok, err := s.TryAcquire(ctx, key, limit)
+		if err != nil {
+			// 等待期间 ctx 结束导致的存储错误按等待超时处理
+			if ctx.Err() != nil {
+				return nil, errors.NewError(codes.TooManyRequests, "wait timeout exceeded", ctx.Err())
+			}
+			return nil, err
+		}
+		if ok {
+			return lease, nil
+		}
+
+		timer := time.NewTimer(s.opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.NewError(codes.TooManyRequests, "wait timeout exceeded", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// InFlight 返回当前持有的许可数
+func (s *semaphore) InFlight(ctx context.Context, key string) (int64, error) {
+	result, err := s.store.Eval(ctx, semaphoreCountScript, []string{key}, time.Now().UnixMilli())
+	if err != nil {
+		return 0, errors.NewError(codes.RateLimitStoreError, "failed to count semaphore leases", err)
+	}
+	count, ok := result.(int64)
+	if !ok {
+		return 0, errors.NewError(codes.RateLimitStoreError, "unexpected semaphore script result", nil)
+	}
+	return count, nil
+}
+
+// semaphoreLease Redis并发许可
+type semaphoreLease struct {
+	sem *semaphore
+	key string
+	id  string
+
+	lost     chan struct{}
+	lostOnce sync.Once
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newSemaphoreLease(sem *semaphore, key, id string) *semaphoreLease {
+	return &semaphoreLease{
+		sem:  sem,
+		key:  key,
+		id:   id,
+		lost: make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+}
+
+// Key 返回许可所属的限流键
+func (l *semaphoreLease) Key() string {
+	return l.key
+}
+
+// ID 返回许可标识
+func (l *semaphoreLease) ID() string {
+	return l.id
+}
+
+// Lost 返回许可丢失通知通道
+func (l *semaphoreLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release 释放许可
+func (l *semaphoreLease) Release(ctx context.Context) error {
+	l.stopWatchdog()
+	if _, err := l.sem.store.Eval(ctx, semaphoreReleaseScript, []string{l.key}, l.id); err != nil {
+		l.sem.log.Error(ctx, "failed to release semaphore lease",
+			types.Field{Key: "key", Value: l.key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to release semaphore", err)
+	}
+	return nil
+}
+
+// refresh 续期许可，返回许可是否仍然有效
+func (l *semaphoreLease) refresh(ctx context.Context) (bool, error) {
+	result, err := l.sem.store.Eval(ctx, semaphoreRefreshScript, []string{l.key},
+		l.id,
+		time.Now().UnixMilli(),
+		l.sem.opts.LeaseTTL.Milliseconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+	held, _ := result.(int64)
+	return held == 1, nil
+}
+
+// startWatchdog 启动看门狗，定期续期直到释放或许可丢失
+func (l *semaphoreLease) startWatchdog() {
+	l.done = make(chan struct{})
+	go l.watch()
+}
+
+func (l *semaphoreLease) watch() {
+	defer close(l.done)
+
+	opts := l.sem.opts
+	ticker := time.NewTicker(opts.RenewInterval)
+	defer ticker.Stop()
+
+	lastRenew := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), opts.RenewInterval)
+			held, err := l.refresh(ctx)
+			cancel()
+
+			if err == nil && held {
+				lastRenew = time.Now()
+				continue
+			}
+
+			// 许可已被清理，或连续续期失败直到许可过期，视为丢失
+			if err == nil || time.Since(lastRenew) >= opts.LeaseTTL {
+				l.sem.log.Error(context.Background(), "semaphore lease lost",
+					types.Field{Key: "key", Value: l.key},
+					types.Error(err),
+				)
+				l.markLost()
+				return
+			}
+
+			l.sem.log.Warn(context.Background(), "failed to renew semaphore lease",
+				types.Field{Key: "key", Value: l.key},
+				types.Error(err),
+			)
+		}
+	}
+}
+
+// stopWatchdog 停止看门狗并等待其退出
+func (l *semaphoreLease) stopWatchdog() {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	if l.done != nil {
+		<-l.done
+	}
+}
+
+// markLost 标记许可已丢失
+func (l *semaphoreLease) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
@@ -0,0 +1,240 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// tokenBucketScript 令牌桶脚本
+// 状态为 hash {tokens, ts}，每次调用按经过的时间补充令牌，时间单位为微秒
+// 返回 {allowed, remaining, retry_after_us, reset_after_us}
+const tokenBucketScript = `
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local now = tonumber(ARGV[5])
+
+local rate = limit / window
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+    tokens = burst
+    ts = now
+end
+
+-- 补充令牌，时钟回拨时不补充
+if now > ts then
+    tokens = math.min(burst, tokens + (now - ts) * rate)
+    ts = now
+end
+
+local allowed = 0
+local retry = 0
+if n > burst then
+    retry = -1
+elseif tokens >= n then
+    tokens = tokens - n
+    allowed = 1
+else
+    retry = math.ceil((n - tokens) / rate)
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', tostring(ts))
+-- 桶装满所需时间后状态与初始状态相同，可以过期
+redis.call('PEXPIRE', key, math.ceil((burst - tokens) / rate / 1000) + 1000)
+
+return {allowed, math.max(0, math.floor(tokens)), retry, math.ceil((burst - tokens) / rate)}
+`
+
+// tokenBucketSettleScript 结算脚本，先补充令牌再按 delta 调整令牌数
+// delta 为负时退还(不超过桶容量)，为正时追加扣减(令牌数可以为负)
+const tokenBucketSettleScript = `
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local delta = tonumber(ARGV[4])
+local now = tonumber(ARGV[5])
+
+local rate = limit / window
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+    tokens = burst
+    ts = now
+end
+
+if now > ts then
+    tokens = math.min(burst, tokens + (now - ts) * rate)
+    ts = now
+end
+tokens = math.min(burst, tokens - delta)
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', key, math.ceil((burst - tokens) / rate / 1000) + 1000)
+return 1
+`
+
+// 令牌桶限流器实现
+type tokenBucketLimiter struct {
+	store *ratelimit.Store
+	opts  *core.LimiterOptions
+	log   types.Logger
+	// queue 公平等待队列，未启用时为空
+	queue *waitQueue
+}
+
+// NewTokenBucketLimiter 创建令牌桶限流器
+// 令牌以 limit/window 的速率补充，桶容量为 Burst(未设置时等于 limit)
+func NewTokenBucketLimiter(store *ratelimit.Store, opts ...core.LimiterOption) core.Limiter {
+	options := &core.LimiterOptions{
+		Name:      core.AlgorithmTokenBucket,
+		Algorithm: core.AlgorithmTokenBucket,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	limiter := &tokenBucketLimiter{
+		store: store,
+		opts:  options,
+		log: logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "ratelimit"},
+			types.Field{Key: "type", Value: core.AlgorithmTokenBucket},
+		),
+	}
+
+	if options.WaitQueueDepth > 0 {
+		limiter.queue = newWaitQueue(store, options.WaitQueueDepth, limiter.log)
+	}
+
+	metrics.Collector.SetActiveLimiters(core.AlgorithmTokenBucket, 1)
+	limiter.log.Info(context.Background(), "created new token bucket rate limiter")
+
+	return limiter
+}
+
+// Close 停止等待队列的订阅，未启用等待队列时无操作
+func (l *tokenBucketLimiter) Close() error {
+	if l.queue != nil {
+		l.queue.close()
+	}
+	return nil
+}
+
+// Allow 实现限流判断
+func (l *tokenBucketLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	return l.AllowN(ctx, key, 1, limit, window)
+}
+
+// AllowN 判断是否允许N个请求通过
+func (l *tokenBucketLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	result, err := l.Take(ctx, key, n, limit, window)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Take 判断是否允许N个请求通过并返回详细结果
+func (l *tokenBucketLimiter) Take(ctx context.Context, key string, n int64, limit int64, window time.Duration) (*core.Result, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Collector.ObserveLatency(key, "allow", time.Since(start).Seconds())
+	}()
+
+	if err := validateArgs(n, limit, window); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := l.store.Eval(ctx, tokenBucketScript, []string{key},
+		burstOf(l.opts.Burst, limit),
+		limit,
+		window.Microseconds(),
+		n,
+		now.UnixMicro(),
+	)
+	if err != nil {
+		l.log.Error(ctx, "failed to evaluate token bucket script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return nil, errors.NewError(codes.RateLimitStoreError, "failed to evaluate token bucket script", err)
+	}
+
+	res, err := parseResult(result, limit, now)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Collector.ObserveDecision(ctx, key, res.Allowed)
+	return res, nil
+}
+
+// Wait 等待直到允许通过或超时
+func (l *tokenBucketLimiter) Wait(ctx context.Context, key string, limit int64, window time.Duration) error {
+	if l.queue != nil {
+		return l.queue.wait(ctx, l.Take, key, limit, window)
+	}
+	return waitFor(ctx, l.Take, key, limit, window)
+}
+
+// Reset 重置限流器
+func (l *tokenBucketLimiter) Reset(ctx context.Context, key string) error {
+	if err := l.store.Del(ctx, key); err != nil {
+		l.log.Error(ctx, "failed to reset rate limiter",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to reset rate limiter", err)
+	}
+	return nil
+}
+
+// Refund 退还已扣减的令牌，不超过桶容量
+func (l *tokenBucketLimiter) Refund(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, -n, limit, window)
+}
+
+// Charge 追加扣减令牌，令牌数可以为负，补充的令牌先偿还透支
+func (l *tokenBucketLimiter) Charge(ctx context.Context, key string, n int64, limit int64, window time.Duration) error {
+	if err := validateArgs(n, limit, window); err != nil {
+		return err
+	}
+	return l.settle(ctx, key, n, limit, window)
+}
+
+// settle 按 delta 调整令牌数，delta 为正时追加扣减，为负时退还
+func (l *tokenBucketLimiter) settle(ctx context.Context, key string, delta int64, limit int64, window time.Duration) error {
+	_, err := l.store.Eval(ctx, tokenBucketSettleScript, []string{key},
+		burstOf(l.opts.Burst, limit),
+		limit,
+		window.Microseconds(),
+		delta,
+		time.Now().UnixMicro(),
+	)
+	if err != nil {
+		l.log.Error(ctx, "failed to evaluate token bucket settle script",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		return errors.NewError(codes.RateLimitStoreError, "failed to settle token bucket", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/client/redis"
+	"gobase/pkg/ratelimit/core"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+// newLimiter 创建连接到 miniredis 的指定算法限流器
+func newLimiter(b *testing.B, algorithm string) core.Limiter {
+	mr, err := miniredis.Run()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(10),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { client.Close() })
+
+	limiter, err := redislimiter.NewLimiter(ratelimit.NewStore(client), core.WithAlgorithm(algorithm))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return limiter
+}
+
+// benchmarkAllow 在窗口内已有大量请求记录时测量单次判断耗时
+// 滑动窗口的耗时随窗口内记录数增长，令牌桶和GCRA保持不变
+func benchmarkAllow(b *testing.B, algorithm string) {
+	for _, limit := range []int64{100, 1000} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			ctx := context.Background()
+			limiter := newLimiter(b, algorithm)
+			key := "bench:" + algorithm
+
+			// 预热，使窗口内积累接近 limit 条记录，滑动窗口按毫秒合并记录，需要间隔 1ms 写入
+			for i := int64(0); i < limit-1; i++ {
+				if _, err := limiter.Allow(ctx, key, limit, time.Hour); err != nil {
+					b.Fatal(err)
+				}
+				if algorithm == core.AlgorithmSlidingWindow {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := limiter.Allow(ctx, key, limit, time.Hour); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSlidingWindowAllow(b *testing.B) {
+	benchmarkAllow(b, core.AlgorithmSlidingWindow)
+}
+
+func BenchmarkTokenBucketAllow(b *testing.B) {
+	benchmarkAllow(b, core.AlgorithmTokenBucket)
+}
+
+func BenchmarkGCRAAllow(b *testing.B) {
+	benchmarkAllow(b, core.AlgorithmGCRA)
+}
+
+// benchmarkAllowParallel 并发请求不同的键
+func benchmarkAllowParallel(b *testing.B, algorithm string) {
+	ctx := context.Background()
+	limiter := newLimiter(b, algorithm)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := fmt.Sprintf("bench:%s:%d", algorithm, i%100)
+			if _, err := limiter.Allow(ctx, key, 1000, time.Minute); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSlidingWindowAllowParallel(b *testing.B) {
+	benchmarkAllowParallel(b, core.AlgorithmSlidingWindow)
+}
+
+func BenchmarkTokenBucketAllowParallel(b *testing.B) {
+	benchmarkAllowParallel(b, core.AlgorithmTokenBucket)
+}
+
+func BenchmarkGCRAAllowParallel(b *testing.B) {
+	benchmarkAllowParallel(b, core.AlgorithmGCRA)
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/adaptive"
+	"gobase/pkg/ratelimit/core"
+)
+
+// fakeCPU 固定返回的CPU使用率
+type fakeCPU float64
+
+func (c fakeCPU) CPUUsage() float64 { return float64(c) }
+
+// runBatch 同时持有 n 个许可后按 outcome 释放，delay 为持有时间
+func runBatch(t *testing.T, l *adaptive.Limiter, key string, n int, delay time.Duration, outcome core.Outcome) {
+	ctx := context.Background()
+	leases := make([]core.Lease, 0, n)
+	for i := 0; i < n; i++ {
+		lease, ok, err := l.TryAcquire(ctx, key, 1000)
+		require.NoError(t, err)
+		require.True(t, ok)
+		leases = append(leases, lease)
+	}
+	time.Sleep(delay)
+	for _, lease := range leases {
+		require.NoError(t, lease.(core.FeedbackLease).Done(ctx, outcome))
+	}
+}
+
+func TestAdaptive_AIMD(t *testing.T) {
+	l, err := adaptive.New(
+		adaptive.WithAlgorithm(adaptive.AlgorithmAIMD),
+		adaptive.WithLimits(4, 2, 10),
+	)
+	require.NoError(t, err)
+
+	// 并发达到上限一半以上时每个成功请求使上限加一
+	runBatch(t, l, "aimd", 4, 0, core.OutcomeSuccess)
+	assert.Equal(t, int64(7), l.Limit("aimd"))
+
+	// 负载不足时上限不变
+	runBatch(t, l, "aimd", 1, 0, core.OutcomeSuccess)
+	assert.Equal(t, int64(7), l.Limit("aimd"))
+
+	// 失败使上限按比例缩减，且不低于最小值
+	for i := 0; i < 20; i++ {
+		runBatch(t, l, "aimd", 1, 0, core.OutcomeDropped)
+	}
+	assert.Equal(t, int64(2), l.Limit("aimd"))
+
+	// 忽略的结果不参与调整
+	runBatch(t, l, "aimd", 2, 0, core.OutcomeIgnored)
+	assert.Equal(t, int64(2), l.Limit("aimd"))
+}
+
+func TestAdaptive_AIMDLatency(t *testing.T) {
+	l, err := adaptive.New(
+		adaptive.WithAlgorithm(adaptive.AlgorithmAIMD),
+		adaptive.WithLimits(10, 1, 100),
+		adaptive.WithLatencyThreshold(5*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	runBatch(t, l, "slow", 5, 20*time.Millisecond, core.OutcomeSuccess)
+	assert.Less(t, l.Limit("slow"), int64(10))
+}
+
+func TestAdaptive_Gradient(t *testing.T) {
+	l, err := adaptive.New(
+		adaptive.WithAlgorithm(adaptive.AlgorithmGradient),
+		adaptive.WithLimits(4, 1, 100),
+		adaptive.WithSmoothing(1),
+	)
+	require.NoError(t, err)
+
+	// 延迟稳定时上限增加
+	for i := 0; i < 5; i++ {
+		runBatch(t, l, "gradient", int(l.Limit("gradient")), time.Millisecond, core.OutcomeSuccess)
+	}
+	grown := l.Limit("gradient")
+	assert.Greater(t, grown, int64(4))
+
+	// 延迟明显升高时上限下降
+	runBatch(t, l, "gradient", int(grown), 50*time.Millisecond, core.OutcomeSuccess)
+	assert.Less(t, l.Limit("gradient"), grown)
+}
+
+func TestAdaptive_CPU(t *testing.T) {
+	l, err := adaptive.New(
+		adaptive.WithAlgorithm(adaptive.AlgorithmAIMD),
+		adaptive.WithLimits(10, 1, 100),
+		adaptive.WithCPU(fakeCPU(95), 80),
+	)
+	require.NoError(t, err)
+
+	runBatch(t, l, "cpu", 10, 0, core.OutcomeSuccess)
+	assert.Less(t, l.Limit("cpu"), int64(10))
+}
+
+func TestAdaptive_Acquire(t *testing.T) {
+	ctx := context.Background()
+	l, err := adaptive.New(adaptive.WithLimits(2, 1, 10), adaptive.WithRetryInterval(time.Millisecond))
+	require.NoError(t, err)
+
+	// limit 参数作为静态上限
+	first, ok, err := l.TryAcquire(ctx, "acquire", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = l.TryAcquire(ctx, "acquire", 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(waitCtx, "acquire", 1)
+	assert.True(t, errors.HasErrorCode(err, codes.TooManyRequests))
+
+	require.NoError(t, first.Release(ctx))
+	require.NoError(t, first.Release(ctx))
+	count, err := l.InFlight(ctx, "acquire")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	lease, err := l.Acquire(ctx, "acquire", 1)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(ctx))
+
+	// 许可的获取结果不计入限流请求指标
+	assert.Zero(t, decisionCount(t, "gobase_ratelimit_requests_total", "acquire", "allowed"))
+	assert.Zero(t, decisionCount(t, "gobase_ratelimit_requests_total", "acquire", "rejected"))
+}
+
+func TestAdaptive_InvalidOptions(t *testing.T) {
+	tests := []adaptive.Option{
+		adaptive.WithAlgorithm("vegas"),
+		adaptive.WithLimits(0, 0, 10),
+		adaptive.WithLimits(20, 1, 10),
+		adaptive.WithBackoffRatio(1),
+		adaptive.WithTolerance(0.5),
+		adaptive.WithSmoothing(0),
+	}
+	for _, opt := range tests {
+		_, err := adaptive.New(opt)
+		assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+	}
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+// newMiniredisStore 创建连接到 miniredis 的限流存储
+func newMiniredisStore(t *testing.T) *ratelimit.Store {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return ratelimit.NewStore(client)
+}
+
+func TestNewLimiter(t *testing.T) {
+	store := newMiniredisStore(t)
+
+	for _, algorithm := range []string{"", core.AlgorithmSlidingWindow, core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		limiter, err := redislimiter.NewLimiter(store, core.WithAlgorithm(algorithm))
+		require.NoError(t, err, algorithm)
+		assert.NotNil(t, limiter, algorithm)
+	}
+
+	_, err := redislimiter.NewLimiter(store, core.WithAlgorithm("leaky_bucket"))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+
+	_, err = redislimiter.NewLimiter(nil)
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitInitError))
+}
+
+func TestBurstLimiters(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMiniredisStore(t)
+			key := "test:" + algorithm
+
+			limiter, err := redislimiter.NewLimiter(store,
+				core.WithAlgorithm(algorithm),
+				core.WithBurst(5),
+			)
+			require.NoError(t, err)
+
+			// 突发容量内全部放行
+			for i := 0; i < 5; i++ {
+				allowed, err := limiter.Allow(ctx, key, 10, time.Second)
+				require.NoError(t, err)
+				assert.True(t, allowed, "request %d", i)
+			}
+			allowed, err := limiter.Allow(ctx, key, 10, time.Second)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// 按 limit/window 的速率恢复，100ms 恢复一个
+			time.Sleep(120 * time.Millisecond)
+			allowed, err = limiter.Allow(ctx, key, 10, time.Second)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			// 超过突发容量的请求永远无法通过
+			allowed, err = limiter.AllowN(ctx, key, 6, 10, time.Second)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// Wait 按脚本返回的重试时间等待
+			start := time.Now()
+			require.NoError(t, limiter.Wait(ctx, key, 10, time.Second))
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+			// 重置后恢复全部容量
+			require.NoError(t, limiter.Reset(ctx, key))
+			allowed, err = limiter.AllowN(ctx, key, 5, 10, time.Second)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		})
+	}
+}
+
+func TestBurstLimitersDefaultBurst(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, err := redislimiter.NewLimiter(newMiniredisStore(t), core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+
+			allowed, err := limiter.AllowN(ctx, "default", 100, 100, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			allowed, err = limiter.Allow(ctx, "default", 100, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestBurstLimitersWait(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, err := redislimiter.NewLimiter(newMiniredisStore(t),
+				core.WithAlgorithm(algorithm),
+				core.WithBurst(1),
+			)
+			require.NoError(t, err)
+
+			require.NoError(t, limiter.Wait(ctx, "wait", 1, time.Minute))
+
+			// 下一个令牌在一分钟后，超出截止时间直接返回
+			timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			err = limiter.Wait(timeoutCtx, "wait", 1, time.Minute)
+			assert.True(t, errors.HasErrorCode(err, codes.TooManyRequests), fmt.Sprint(err))
+		})
+	}
+}
+
+func TestBurstLimitersInvalidArgs(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		limiter, err := redislimiter.NewLimiter(newMiniredisStore(t), core.WithAlgorithm(algorithm))
+		require.NoError(t, err)
+
+		_, err = limiter.Allow(context.Background(), "invalid", 0, time.Second)
+		assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError), algorithm)
+
+		_, err = limiter.AllowN(context.Background(), "invalid", 0, 10, time.Second)
+		assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError), algorithm)
+	}
+}
+
+func TestLimiterTakeResult(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmSlidingWindow, core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, err := redislimiter.NewLimiter(newMiniredisStore(t), core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+
+			rl, ok := limiter.(core.ResultLimiter)
+			require.True(t, ok)
+
+			result, err := rl.Take(ctx, "take", 3, 4, time.Second)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, int64(4), result.Limit)
+			assert.Equal(t, int64(1), result.Remaining)
+			assert.Zero(t, result.RetryAfter)
+			assert.WithinDuration(t, time.Now().Add(time.Second), result.ResetAt, time.Second)
+
+			result, err = rl.Take(ctx, "take", 2, 4, time.Second)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Equal(t, int64(1), result.Remaining)
+			assert.Greater(t, result.RetryAfter, time.Duration(0))
+			assert.LessOrEqual(t, result.RetryAfter, time.Second)
+
+			result, err = rl.Take(ctx, "take", 5, 4, time.Second)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Less(t, result.RetryAfter, time.Duration(0))
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/hybrid"
+	"gobase/pkg/ratelimit/memory"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// remoteLimiter 记录调用次数、可模拟不可用的 remote 限流器
+type remoteLimiter struct {
+	core.Limiter
+
+	mu    sync.Mutex
+	calls int
+	down  bool
+}
+
+func newRemoteLimiter() *remoteLimiter {
+	return &remoteLimiter{Limiter: memory.NewTokenBucketLimiter()}
+}
+
+func (r *remoteLimiter) AllowN(ctx context.Context, key string, n int64, limit int64, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	r.calls++
+	down := r.down
+	r.mu.Unlock()
+
+	if down {
+		return false, errors.NewError(codes.RateLimitStoreError, "connection refused", nil)
+	}
+	return r.Limiter.AllowN(ctx, key, n, limit, window)
+}
+
+func (r *remoteLimiter) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func (r *remoteLimiter) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func TestHybridLeasesBatches(t *testing.T) {
+	ctx := context.Background()
+	remote := newRemoteLimiter()
+	limiter, err := hybrid.New(remote, hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	for i := 0; i < 25; i++ {
+		allowed, err := limiter.Allow(ctx, "api", 100, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, 3, remote.callCount())
+}
+
+// decisionCount 返回全局收集器中指定指标、key 和结果的计数
+func decisionCount(t *testing.T, name, key, result string) float64 {
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(metrics.Collector))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["key"] == key && labels["result"] == result {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestHybridObservesEveryDecision(t *testing.T) {
+	limiter, err := hybrid.New(newRemoteLimiter(), hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	// 本地租约和租借批次的请求都逐个计数
+	for i := 0; i < 15; i++ {
+		_, err := limiter.Allow(context.Background(), "hybrid:metrics", 100, time.Minute)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, float64(15), decisionCount(t, "gobase_ratelimit_requests_total", "hybrid:metrics", "allowed"))
+
+	// 影子模式下只记入 shadow_requests_total
+	shadow := metrics.WithShadow(context.Background())
+	for i := 0; i < 3; i++ {
+		_, err := limiter.Allow(shadow, "hybrid:shadow", 100, time.Minute)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, float64(3), decisionCount(t, "gobase_ratelimit_shadow_requests_total", "hybrid:shadow", "allowed"))
+	assert.Zero(t, decisionCount(t, "gobase_ratelimit_requests_total", "hybrid:shadow", "allowed"))
+}
+
+func TestHybridNeverExceedsRemoteLimit(t *testing.T) {
+	ctx := context.Background()
+	remote := newRemoteLimiter()
+	limiter, err := hybrid.New(remote, hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+
+	allowed := 0
+	for i := 0; i < 30; i++ {
+		ok, err := limiter.Allow(ctx, "api", 15, time.Minute)
+		require.NoError(t, err)
+		if ok {
+			allowed++
+		}
+	}
+	// 剩余配额不足一个批次时逐个申请
+	assert.Equal(t, 15, allowed)
+}
+
+func TestHybridFallback(t *testing.T) {
+	ctx := context.Background()
+	remote := newRemoteLimiter()
+	remote.setDown(true)
+
+	limiter, err := hybrid.New(remote,
+		hybrid.WithInstances(4),
+		hybrid.WithRetryInterval(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// remote 不可用时按 limit/Instances 限流
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		ok, err := limiter.Allow(ctx, "api", 40, time.Minute)
+		require.NoError(t, err)
+		if ok {
+			allowed++
+		}
+	}
+	assert.Equal(t, 10, allowed)
+	// RetryInterval 内不再访问 remote
+	assert.Equal(t, 1, remote.callCount())
+
+	// remote 恢复后重新从 remote 租借
+	remote.setDown(false)
+	time.Sleep(150 * time.Millisecond)
+	ok, err := limiter.Allow(ctx, "api", 40, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, remote.callCount())
+}
+
+func TestHybridOptions(t *testing.T) {
+	_, err := hybrid.New(nil)
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitInitError))
+
+	_, err = hybrid.New(newRemoteLimiter(), hybrid.WithInstances(0))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+
+	_, err = hybrid.New(newRemoteLimiter(), hybrid.WithBatchSize(0))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+}
+
+func TestHybridTakeResult(t *testing.T) {
+	ctx := context.Background()
+	limiter, err := hybrid.New(memory.NewTokenBucketLimiter(), hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+	rl := limiter.(core.ResultLimiter)
+
+	// 租借 10 个后本地剩余 9 个，remote 剩余 90 个
+	result, err := rl.Take(ctx, "api", 1, 100, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(100), result.Limit)
+	assert.Equal(t, int64(99), result.Remaining)
+
+	result, err = rl.Take(ctx, "api", 1, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(98), result.Remaining)
+
+	// remote 不支持详细结果时只统计本地租约
+	limiter, err = hybrid.New(newRemoteLimiter(), hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+	result, err = limiter.(core.ResultLimiter).Take(ctx, "api", 1, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), result.Remaining)
+}
+
+func TestHybridSettle(t *testing.T) {
+	ctx := context.Background()
+	remote := memory.NewTokenBucketLimiter()
+	limiter, err := hybrid.New(remote, hybrid.WithBatchSize(10), hybrid.WithLeaseTTL(time.Minute))
+	require.NoError(t, err)
+	settle := limiter.(core.SettleLimiter)
+
+	// 首个请求从 remote 租借10个，本地剩余9个
+	allowed, err := limiter.AllowN(ctx, "api", 1, 20, time.Hour)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// 退还到本地租约，追加扣减先消耗本地租约，不足的部分在 remote 扣减
+	require.NoError(t, settle.Refund(ctx, "api", 3, 20, time.Hour))
+	require.NoError(t, settle.Charge(ctx, "api", 15, 20, time.Hour))
+
+	allowed, err = limiter.AllowN(ctx, "api", 8, 20, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	allowed, err = limiter.AllowN(ctx, "api", 7, 20, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// remote 不支持结算时返回配置错误
+	plain, err := hybrid.New(newRemoteLimiter())
+	require.NoError(t, err)
+	err = plain.(core.SettleLimiter).Refund(ctx, "api", 1, 20, time.Hour)
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+}
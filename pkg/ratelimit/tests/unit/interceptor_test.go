@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"gobase/pkg/ratelimit/interceptor"
+	"gobase/pkg/ratelimit/memory"
+)
+
+// newHealthClient 启动带限流拦截器的 gRPC 服务，返回健康检查客户端
+func newHealthClient(t *testing.T, cfg *interceptor.Config) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.UnaryServerInterceptor(cfg)),
+		grpc.StreamInterceptor(interceptor.StreamServerInterceptor(cfg)),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestInterceptor_Unary(t *testing.T) {
+	limiter, err := memory.NewLimiter()
+	require.NoError(t, err)
+
+	client := newHealthClient(t, &interceptor.Config{
+		Limiter: limiter,
+		KeyFunc: interceptor.MetadataKey("x-api-key"),
+		Limit:   2,
+		Window:  time.Minute,
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "tenant-a")
+	for i := 0; i < 2; i++ {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+	}
+
+	var header metadata.MD
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	assert.Equal(t, grpccodes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get(interceptor.RetryAfterHeader))
+
+	// 不同元数据键单独计数
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "tenant-b")
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+func TestInterceptor_StreamAndSkip(t *testing.T) {
+	limiter, err := memory.NewLimiter()
+	require.NoError(t, err)
+
+	client := newHealthClient(t, &interceptor.Config{
+		Limiter: limiter,
+		Limit:   1,
+		Window:  time.Minute,
+		SkipFunc: func(fullMethod string) bool {
+			return fullMethod == healthpb.Health_Check_FullMethodName
+		},
+	})
+	ctx := context.Background()
+
+	// 流在建立时计数一次
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, grpccodes.ResourceExhausted, status.Code(err))
+
+	// 跳过的方法不受限制
+	for i := 0; i < 3; i++ {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+	}
+}
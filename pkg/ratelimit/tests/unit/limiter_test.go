@@ -274,7 +274,7 @@ func TestSlidingWindowLimiter_Allow(t *testing.T) {
 					mock.AnythingOfType("int64"),             // window in nanoseconds
 					int64(10),                                // limit
 					int64(1),                                 // n=1 for Allow()
-				).Return(interface{}(int64(1)), nil)
+				).Return(interface{}([]interface{}{int64(1), int64(9), int64(0), int64(1000000)}), nil)
 			},
 			want:    true,
 			wantErr: false,
@@ -293,7 +293,7 @@ func TestSlidingWindowLimiter_Allow(t *testing.T) {
 					mock.AnythingOfType("int64"),             // window in nanoseconds
 					int64(10),                                // limit
 					int64(1),                                 // n=1 for Allow()
-				).Return(interface{}(int64(0)), nil)
+				).Return(interface{}([]interface{}{int64(0), int64(0), int64(500000), int64(1000000)}), nil)
 			},
 			want:    false,
 			wantErr: false,
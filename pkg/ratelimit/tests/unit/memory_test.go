@@ -0,0 +1,137 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/memory"
+)
+
+func TestMemoryLimiters(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, err := memory.NewLimiter(core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+
+			// 滑动窗口在窗口开头占满配额时，Wait 需要等待超过一个窗口，避开窗口开头
+			if offset := time.Since(time.Now().Truncate(100 * time.Millisecond)); offset < 30*time.Millisecond {
+				time.Sleep(30*time.Millisecond - offset)
+			}
+
+			for i := 0; i < 5; i++ {
+				allowed, err := limiter.Allow(ctx, "user:1", 5, 100*time.Millisecond)
+				require.NoError(t, err)
+				assert.True(t, allowed, "request %d", i)
+			}
+			allowed, err := limiter.Allow(ctx, "user:1", 5, 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// 不同键互不影响
+			allowed, err = limiter.Allow(ctx, "user:2", 5, 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			// 超过 limit 的请求永远无法通过
+			allowed, err = limiter.AllowN(ctx, "user:3", 6, 5, 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// Wait 等到配额恢复
+			require.NoError(t, limiter.Wait(ctx, "user:1", 5, 100*time.Millisecond))
+
+			require.NoError(t, limiter.Reset(ctx, "user:1"))
+			allowed, err = limiter.AllowN(ctx, "user:1", 5, 5, 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			_, err = limiter.Allow(ctx, "user:1", 0, time.Second)
+			assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+		})
+	}
+
+	_, err := memory.NewLimiter(core.WithAlgorithm(core.AlgorithmGCRA))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+}
+
+func TestMemoryLimitersConcurrent(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			limiter, err := memory.NewLimiter(core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+
+			var allowed int64
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 10; j++ {
+						ok, err := limiter.Allow(context.Background(), "shared", 50, time.Hour)
+						assert.NoError(t, err)
+						if ok {
+							atomic.AddInt64(&allowed, 1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, int64(50), allowed)
+		})
+	}
+}
+
+func TestMemorySlidingWindowWeighting(t *testing.T) {
+	ctx := context.Background()
+	limiter := memory.NewSlidingWindowLimiter()
+	window := 200 * time.Millisecond
+
+	// 对齐到窗口起点附近，避免跨窗口
+	time.Sleep(time.Until(time.Now().Truncate(window).Add(window)))
+
+	allowed, err := limiter.AllowN(ctx, "weighted", 10, 10, window)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// 进入下一个窗口初期，上一个窗口的计数仍占大部分权重
+	time.Sleep(window + 20*time.Millisecond)
+	allowed, err = limiter.AllowN(ctx, "weighted", 5, 10, window)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// Wait 等到上一个窗口的权重衰减
+	require.NoError(t, limiter.Wait(ctx, "weighted", 10, window))
+}
+
+func TestMemoryLimiterTakeResult(t *testing.T) {
+	for _, algorithm := range []string{core.AlgorithmTokenBucket, core.AlgorithmSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			limiter, err := memory.NewLimiter(core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+			rl := limiter.(core.ResultLimiter)
+
+			result, err := rl.Take(context.Background(), "take", 3, 4, time.Hour)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, int64(4), result.Limit)
+			assert.Equal(t, int64(1), result.Remaining)
+			assert.True(t, result.ResetAt.After(time.Now()))
+
+			result, err = rl.Take(context.Background(), "take", 2, 4, time.Hour)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Greater(t, result.RetryAfter, time.Duration(0))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"gobase/pkg/ratelimit/metrics"
+)
+
+func TestRateLimitCollector_MaxKeys(t *testing.T) {
+	c := metrics.NewRateLimitCollector(metrics.WithMaxKeys(3))
+
+	for i := 0; i < 10; i++ {
+		c.ObserveRequest("key:"+strconv.Itoa(i), true)
+	}
+	// 3 个 key 加上溢出标签
+	assert.Equal(t, 4, testutil.CollectAndCount(c, "gobase_ratelimit_requests_total"))
+
+	// 已记录的 key 继续使用原标签
+	c.ObserveRequest("key:0", false)
+	c.ObserveLatency("key:1", "allow", 0.01)
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "gobase_ratelimit_rejected_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "gobase_ratelimit_latency_seconds"))
+
+	c.ObserveLatency("key:9", "allow", 0.01)
+	assert.Equal(t, 2, testutil.CollectAndCount(c, "gobase_ratelimit_latency_seconds"))
+}
+
+func TestRateLimitCollector_Unlimited(t *testing.T) {
+	c := metrics.NewRateLimitCollector(metrics.WithMaxKeys(0))
+
+	for i := 0; i < 10; i++ {
+		c.ObserveCheckFailed("key:" + strconv.Itoa(i))
+	}
+	assert.Equal(t, 10, testutil.CollectAndCount(c, "gobase_ratelimit_requests_total"))
+}
+
+func TestRateLimitCollector_Shadow(t *testing.T) {
+	c := metrics.NewRateLimitCollector()
+	shadow := metrics.WithShadow(context.Background())
+
+	c.ObserveDecision(shadow, "shadow", true)
+	c.ObserveDecision(shadow, "shadow", false)
+	c.ObserveDecision(context.Background(), "enforced", false)
+
+	// 影子模式的拒绝不计入 rejected_total
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "gobase_ratelimit_rejected_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "gobase_ratelimit_requests_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(c, "gobase_ratelimit_shadow_requests_total"))
+	assert.True(t, metrics.IsShadow(shadow))
+	assert.False(t, metrics.IsShadow(context.Background()))
+}
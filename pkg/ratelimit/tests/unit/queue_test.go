@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/cache/redis/ratelimit"
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+func TestWaitQueue_FIFO(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// 每200ms补充一个令牌，没有突发容量
+	limiter, err := redislimiter.NewLimiter(newMiniredisStore(t),
+		core.WithAlgorithm(core.AlgorithmTokenBucket),
+		core.WithBurst(1),
+		core.WithWaitQueue(10),
+	)
+	require.NoError(t, err)
+
+	const key, limit, window = "fifo", int64(10), 2 * time.Second
+	allowed, err := limiter.Allow(ctx, key, limit, window)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if assert.NoError(t, limiter.Wait(ctx, key, limit, window)) {
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+			}
+		}(i)
+		// 保证领取排队号的顺序
+		time.Sleep(20 * time.Millisecond)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+}
+
+func TestWaitQueue_Full(t *testing.T) {
+	ctx := context.Background()
+	limiter, err := redislimiter.NewLimiter(newMiniredisStore(t),
+		core.WithAlgorithm(core.AlgorithmGCRA),
+		core.WithBurst(1),
+		core.WithWaitQueue(1),
+	)
+	require.NoError(t, err)
+
+	// 每200ms放行一个请求
+	const key, limit, window = "full", int64(5), time.Second
+	allowed, err := limiter.Allow(ctx, key, limit, window)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(ctx, key, limit, window) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// 队列已满时立即返回
+	start := time.Now()
+	err = limiter.Wait(ctx, key, limit, window)
+	assert.True(t, errors.HasErrorCode(err, codes.TooManyRequests))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// 队首通过后离开队列，后续等待者可以入队
+	require.NoError(t, <-done)
+	assert.NoError(t, limiter.Wait(ctx, key, limit, window))
+}
+
+func TestWaitQueue_Close(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()), redis.WithPoolSize(2))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	store := ratelimit.NewStore(client)
+
+	subscribers := func() int {
+		return mr.PubSubNumSub("ratelimit:queue:notify")["ratelimit:queue:notify"]
+	}
+
+	var limiters []core.Limiter
+	for _, algorithm := range []string{core.AlgorithmSlidingWindow, core.AlgorithmTokenBucket, core.AlgorithmGCRA} {
+		limiter, err := redislimiter.NewLimiter(store, core.WithAlgorithm(algorithm), core.WithWaitQueue(10))
+		require.NoError(t, err)
+		// 第一次排队时启动订阅
+		require.NoError(t, limiter.Wait(ctx, "close:"+algorithm, 10, time.Second))
+		limiters = append(limiters, limiter)
+	}
+	assert.Eventually(t, func() bool { return subscribers() == 3 }, time.Second, 10*time.Millisecond)
+
+	// 关闭后订阅退出，等待者退化为轮询
+	for _, limiter := range limiters {
+		closer, ok := limiter.(io.Closer)
+		require.True(t, ok)
+		require.NoError(t, closer.Close())
+	}
+	assert.Eventually(t, func() bool { return subscribers() == 0 }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, limiters[0].Wait(ctx, "close:after", 10, time.Second))
+	assert.Zero(t, subscribers())
+}
+
+func TestWaitQueue_HashTag(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client, err := redis.NewClient(redis.WithAddress(mr.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	limiter, err := redislimiter.NewLimiter(ratelimit.NewStore(client),
+		core.WithAlgorithm(core.AlgorithmGCRA),
+		core.WithWaitQueue(10),
+	)
+	require.NoError(t, err)
+	require.NoError(t, limiter.Wait(ctx, "tagged", 10, time.Second))
+
+	// 队列键带有相同的哈希标签，Redis Cluster 下位于同一槽位
+	assert.True(t, mr.Exists("{tagged}:queue:seq"))
+	assert.False(t, mr.Exists("tagged:queue:seq"))
+}
@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+func TestSemaphore_TryAcquire(t *testing.T) {
+	ctx := context.Background()
+	sem, err := redislimiter.NewSemaphore(newMiniredisStore(t))
+	require.NoError(t, err)
+
+	first, ok, err := sem.TryAcquire(ctx, "sem:test", 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	second, ok, err := sem.TryAcquire(ctx, "sem:test", 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotEqual(t, first.ID(), second.ID())
+
+	_, ok, err = sem.TryAcquire(ctx, "sem:test", 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	count, err := sem.InFlight(ctx, "sem:test")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, first.Release(ctx))
+	require.NoError(t, first.Release(ctx))
+	third, ok, err := sem.TryAcquire(ctx, "sem:test", 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, second.Release(ctx))
+	require.NoError(t, third.Release(ctx))
+	count, err = sem.InFlight(ctx, "sem:test")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// 许可的获取结果不计入限流请求指标
+	assert.Zero(t, decisionCount(t, "gobase_ratelimit_requests_total", "sem:test", "allowed"))
+	assert.Zero(t, decisionCount(t, "gobase_ratelimit_requests_total", "sem:test", "rejected"))
+}
+
+func TestSemaphore_Acquire(t *testing.T) {
+	ctx := context.Background()
+	sem, err := redislimiter.NewSemaphore(newMiniredisStore(t), core.WithAcquireRetryInterval(5*time.Millisecond))
+	require.NoError(t, err)
+
+	lease, err := sem.Acquire(ctx, "sem:wait", 1)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	_, err = sem.Acquire(waitCtx, "sem:wait", 1)
+	assert.True(t, errors.HasErrorCode(err, codes.TooManyRequests))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		lease.Release(ctx)
+	}()
+	waitCtx, cancel = context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	next, err := sem.Acquire(waitCtx, "sem:wait", 1)
+	require.NoError(t, err)
+	require.NoError(t, next.Release(ctx))
+}
+
+func TestSemaphore_LeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := newMiniredisStore(t)
+
+	// 不续期的许可模拟崩溃的持有者
+	crashed, err := redislimiter.NewSemaphore(store,
+		core.WithLeaseTTL(50*time.Millisecond),
+		core.WithRenewInterval(-1),
+	)
+	require.NoError(t, err)
+	_, ok, err := crashed.TryAcquire(ctx, "sem:expiry", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = crashed.TryAcquire(ctx, "sem:expiry", 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	time.Sleep(80 * time.Millisecond)
+	lease, ok, err := crashed.TryAcquire(ctx, "sem:expiry", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, lease.Release(ctx))
+
+	// 自动续期的许可在有效期后仍然有效
+	renewed, err := redislimiter.NewSemaphore(store, core.WithLeaseTTL(60*time.Millisecond))
+	require.NoError(t, err)
+	lease, ok, err = renewed.TryAcquire(ctx, "sem:renew", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	time.Sleep(150 * time.Millisecond)
+	_, ok, err = renewed.TryAcquire(ctx, "sem:renew", 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	select {
+	case <-lease.Lost():
+		t.Fatal("lease should not be lost")
+	default:
+	}
+	require.NoError(t, lease.Release(ctx))
+}
+
+func TestSemaphore_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	sem, err := redislimiter.NewSemaphore(newMiniredisStore(t))
+	require.NoError(t, err)
+
+	var acquired int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok, err := sem.TryAcquire(ctx, "sem:concurrent", 5); err == nil && ok {
+				atomic.AddInt64(&acquired, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(5), acquired)
+}
+
+func TestNewSemaphore_InvalidOptions(t *testing.T) {
+	_, err := redislimiter.NewSemaphore(nil)
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitInitError))
+
+	store := newMiniredisStore(t)
+	_, err = redislimiter.NewSemaphore(store, core.WithLeaseTTL(0))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+
+	_, err = redislimiter.NewSemaphore(store, core.WithLeaseTTL(time.Second), core.WithRenewInterval(2*time.Second))
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+
+	sem, err := redislimiter.NewSemaphore(store)
+	require.NoError(t, err)
+	_, _, err = sem.TryAcquire(context.Background(), "sem:invalid", 0)
+	assert.True(t, errors.HasErrorCode(err, codes.RateLimitConfigError))
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/memory"
+	redislimiter "gobase/pkg/ratelimit/redis"
+)
+
+func TestSettleLimiters(t *testing.T) {
+	newRedis := func(algorithm string) func(t *testing.T) core.Limiter {
+		return func(t *testing.T) core.Limiter {
+			limiter, err := redislimiter.NewLimiter(newMiniredisStore(t), core.WithAlgorithm(algorithm))
+			require.NoError(t, err)
+			return limiter
+		}
+	}
+
+	limiters := map[string]func(t *testing.T) core.Limiter{
+		"memory_token_bucket":   func(t *testing.T) core.Limiter { return memory.NewTokenBucketLimiter() },
+		"memory_sliding_window": func(t *testing.T) core.Limiter { return memory.NewSlidingWindowLimiter() },
+		"redis_token_bucket":    newRedis(core.AlgorithmTokenBucket),
+		"redis_gcra":            newRedis(core.AlgorithmGCRA),
+		"redis_sliding_window":  newRedis(core.AlgorithmSlidingWindow),
+	}
+
+	for name, create := range limiters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limiter, ok := create(t).(core.SettleLimiter)
+			require.True(t, ok)
+
+			// 窗口足够长，测试期间恢复的配额可以忽略
+			const key, limit, window = "settle", int64(5), time.Hour
+			allow := func(n int64) bool {
+				allowed, err := limiter.AllowN(ctx, key, n, limit, window)
+				require.NoError(t, err)
+				return allowed
+			}
+
+			assert.True(t, allow(5))
+			assert.False(t, allow(1))
+
+			// 退还的配额可以再次使用
+			require.NoError(t, limiter.Refund(ctx, key, 2, limit, window))
+			assert.True(t, allow(2))
+			assert.False(t, allow(1))
+
+			// 退还不超过容量
+			require.NoError(t, limiter.Refund(ctx, key, 10, limit, window))
+			assert.True(t, allow(5))
+			assert.False(t, allow(1))
+
+			// 追加扣减可以透支，透支的部分先被退还抵消
+			require.NoError(t, limiter.Charge(ctx, key, 3, limit, window))
+			require.NoError(t, limiter.Refund(ctx, key, 2, limit, window))
+			assert.False(t, allow(1))
+			require.NoError(t, limiter.Refund(ctx, key, 2, limit, window))
+			assert.True(t, allow(1))
+
+			// n 必须为正，负数不能反转操作
+			for _, n := range []int64{0, -1} {
+				assert.True(t, errors.HasErrorCode(limiter.Refund(ctx, key, n, limit, window), codes.RateLimitConfigError))
+				assert.True(t, errors.HasErrorCode(limiter.Charge(ctx, key, n, limit, window), codes.RateLimitConfigError))
+			}
+			assert.False(t, allow(1))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/ratelimit/memory"
+	"gobase/pkg/ratelimit/transport"
+)
+
+func TestTransport(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	limiter, err := memory.NewLimiter()
+	require.NoError(t, err)
+
+	t.Run("reject", func(t *testing.T) {
+		client := &http.Client{Transport: transport.NewTransport(nil, &transport.Config{
+			Limiter: limiter,
+			KeyFunc: func(*http.Request) string { return "reject" },
+			Limit:   2,
+			Window:  time.Minute,
+		})}
+
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		_, err := client.Get(server.URL)
+		assert.True(t, errors.HasErrorCode(err, codes.TooManyRequests))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		client := &http.Client{Transport: transport.NewTransport(nil, &transport.Config{
+			Limiter:     limiter,
+			Limit:       5,
+			Window:      500 * time.Millisecond,
+			WaitTimeout: time.Second,
+		})}
+
+		// 滑动窗口在窗口开头占满配额时，Wait 需要等待超过一个窗口，避开窗口开头
+		if offset := time.Since(time.Now().Truncate(500 * time.Millisecond)); offset < 150*time.Millisecond {
+			time.Sleep(150*time.Millisecond - offset)
+		}
+
+		// 第6个请求等待配额恢复后发出
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+}
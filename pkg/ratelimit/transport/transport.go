@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/logger"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/ratelimit/core"
+	"gobase/pkg/ratelimit/metrics"
+)
+
+// Config 客户端限流配置
+type Config struct {
+	// Limiter 限流器实例
+	Limiter core.Limiter
+	// KeyFunc 限流键生成函数，默认使用请求的目标主机
+	KeyFunc func(*http.Request) string
+	// Limit 限流阈值，通常为第三方接口允许的调用频率
+	Limit int64
+	// Window 时间窗口
+	Window time.Duration
+	// WaitTimeout 等待配额的最长时间，为0时超过限制立即返回错误
+	// 实际等待时间同时受请求 ctx 限制
+	WaitTimeout time.Duration
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		KeyFunc:     HostKey,
+		Limit:       100,
+		Window:      time.Minute,
+		WaitTimeout: 5 * time.Second,
+		// Limiter 必须由用户提供，因为它需要依赖外部存储
+	}
+}
+
+// HostKey 使用请求的目标主机作为限流键
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// Transport 在发出请求前按目标限流的 http.RoundTripper
+type Transport struct {
+	base http.RoundTripper
+	cfg  *Config
+	log  types.Logger
+}
+
+// NewTransport 创建客户端限流 RoundTripper，base 为空时使用 http.DefaultTransport
+func NewTransport(base http.RoundTripper, cfg *Config) *Transport {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if cfg.Limiter == nil {
+		panic("ratelimit transport requires a limiter instance")
+	}
+	if cfg.Limit <= 0 {
+		panic("ratelimit transport requires a positive limit")
+	}
+	if cfg.Window <= 0 {
+		panic("ratelimit transport requires a positive window")
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = HostKey
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{
+		base: base,
+		cfg:  cfg,
+		log: logger.GetLogger().WithFields(
+			types.Field{Key: "module", Value: "ratelimit"},
+			types.Field{Key: "component", Value: "transport"},
+		),
+	}
+}
+
+// RoundTrip 获取配额后再发出请求
+// 超过限制时返回 TooManyRequests 错误，限流器出错时返回 RateLimitError，请求不会发出
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.cfg.KeyFunc(req)
+	if key == "" {
+		closeBody(req)
+		return nil, errors.NewError(codes.RateLimitError, "invalid rate limit key", nil)
+	}
+
+	ctx := req.Context()
+	start := time.Now()
+	allowed, err := t.allow(ctx, key)
+	metrics.Collector.ObserveLatency(key, "total", time.Since(start).Seconds())
+
+	if err != nil {
+		closeBody(req)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		t.log.Error(ctx, "rate limit check failed",
+			types.Field{Key: "key", Value: key},
+			types.Error(err),
+		)
+		metrics.Collector.ObserveCheckFailed(key)
+		return nil, errors.NewError(codes.RateLimitError, "rate limit check failed", err)
+	}
+	if !allowed {
+		closeBody(req)
+		t.log.Debug(ctx, "outbound request throttled",
+			types.Field{Key: "key", Value: key},
+		)
+		return nil, errors.NewError(codes.TooManyRequests, "outbound rate limit exceeded", nil)
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// allow 执行限流判断
+func (t *Transport) allow(ctx context.Context, key string) (bool, error) {
+	if t.cfg.WaitTimeout <= 0 {
+		return t.cfg.Limiter.Allow(ctx, key, t.cfg.Limit, t.cfg.Window)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, t.cfg.WaitTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := t.cfg.Limiter.Wait(waitCtx, key, t.cfg.Limit, t.cfg.Window)
+	metrics.Collector.ObserveWait(key, time.Since(start).Seconds())
+
+	// 等待超时、等待队列已满都按限流处理，请求 ctx 结束时返回错误
+	if ctx.Err() == nil && (errors.HasErrorCode(err, codes.TooManyRequests) || errors.Is(err, context.DeadlineExceeded)) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// closeBody 按 http.RoundTripper 的约定，未发出请求时也要关闭请求体
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
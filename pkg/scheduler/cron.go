@@ -0,0 +1,290 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobase/pkg/errors"
+)
+
+// Schedule 调度计划
+type Schedule interface {
+	// Next 返回严格晚于 t 的下一次执行时间，没有可执行时间时返回零值
+	Next(t time.Time) time.Time
+}
+
+// bounds 字段取值范围
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{0, 59, nil}
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors 预定义的调度表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron 解析 cron 表达式
+// 支持 5 个字段(分 时 日 月 周)或 6 个字段(秒 分 时 日 月 周)，
+// 以及 @yearly、@monthly、@weekly、@daily、@hourly 和 @every <duration>
+// loc 为 nil 时使用本地时区
+func ParseCron(spec string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.NewScheduleError("cron spec is empty", nil)
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, errors.NewScheduleError("invalid @every interval", err)
+		}
+		if interval <= 0 {
+			return nil, errors.NewScheduleError("@every interval must be positive", nil)
+		}
+		return &everySchedule{interval: interval}, nil
+	}
+
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, errors.NewScheduleError(fmt.Sprintf("unknown cron descriptor %q", spec), nil)
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.NewScheduleError(fmt.Sprintf("cron spec %q must have 5 or 6 fields", spec), nil)
+	}
+
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, err
+	}
+	if s.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, err
+	}
+
+	// 周日可以写成 0 或 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = isWildcard(fields[3])
+	s.dowStar = isWildcard(fields[5])
+
+	return s, nil
+}
+
+// isWildcard 判断字段是否为不限制的通配符
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField 将字段解析为位图
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parsePart(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+// parsePart 解析单个列表项: *、?、n、a-b、*/s、a-b/s、a/s
+func parsePart(part string, b bounds) (uint64, error) {
+	rangeExpr, step := part, uint(1)
+	if i := strings.Index(part, "/"); i >= 0 {
+		rangeExpr = part[:i]
+		n, err := strconv.ParseUint(part[i+1:], 10, 0)
+		if err != nil || n == 0 {
+			return 0, errors.NewScheduleError(fmt.Sprintf("invalid step in %q", part), err)
+		}
+		step = uint(n)
+	}
+
+	var start, end uint
+	switch {
+	case rangeExpr == "*" || rangeExpr == "?":
+		start, end = b.min, b.max
+	case strings.Contains(rangeExpr, "-"):
+		bounds := strings.SplitN(rangeExpr, "-", 2)
+		var err error
+		if start, err = parseValue(bounds[0], b); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(bounds[1], b); err != nil {
+			return 0, err
+		}
+	default:
+		value, err := parseValue(rangeExpr, b)
+		if err != nil {
+			return 0, err
+		}
+		start, end = value, value
+		// a/s 表示从 a 开始到最大值
+		if step > 1 {
+			end = b.max
+		}
+	}
+
+	if start > end {
+		return 0, errors.NewScheduleError(fmt.Sprintf("invalid range in %q", part), nil)
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << v
+	}
+	return bits, nil
+}
+
+// parseValue 解析数值或名称
+func parseValue(value string, b bounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return 0, errors.NewScheduleError(fmt.Sprintf("invalid value %q", value), err)
+	}
+	if uint(n) < b.min || uint(n) > b.max {
+		return 0, errors.NewScheduleError(fmt.Sprintf("value %d out of range [%d, %d]", n, b.min, b.max), nil)
+	}
+	return uint(n), nil
+}
+
+// cronSchedule 基于位图的 cron 调度
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+	loc                                   *time.Location
+}
+
+// Next 返回下一次执行时间
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	// 从下一整秒开始查找
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+	added := false
+
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+			}
+			t = t.AddDate(0, 1, 0)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+			}
+			// 使用 time.Date 而不是 Add(24h)，避免夏令时切换导致的偏移
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+			}
+			t = t.Add(time.Hour)
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.loc)
+			}
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		if s.second&(1<<uint(t.Second())) == 0 {
+			added = true
+			t = t.Add(time.Second)
+			continue
+		}
+
+		return t.In(origLoc)
+	}
+
+	return time.Time{}
+}
+
+// dayMatches 判断日期是否匹配
+// 与标准 cron 一致：日和周都有限制时满足其一即可
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// everySchedule 固定间隔调度
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 返回下一次执行时间
+func (s *everySchedule) Next(t time.Time) time.Time {
+	if s.interval >= time.Second {
+		t = t.Truncate(time.Second)
+	}
+	return t.Add(s.interval)
+}
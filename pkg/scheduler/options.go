@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/logger/types"
+	"gobase/pkg/monitor/prometheus/collector"
+)
+
+// Option 调度器选项函数
+type Option func(*Options)
+
+// Options 调度器配置
+type Options struct {
+	// KeyPrefix 键前缀
+	KeyPrefix string
+
+	// Location 解析 cron 表达式使用的时区
+	Location *time.Location
+
+	// LeaseTTL 领导者租约有效时间，持有期间自动续期
+	LeaseTTL time.Duration
+
+	// CampaignInterval 非领导者竞选的间隔
+	CampaignInterval time.Duration
+
+	// InstanceID 当前实例标识，记录在任务状态中
+	InstanceID string
+
+	// Metrics 业务指标收集器，为 nil 时不记录指标
+	Metrics *collector.BusinessCollector
+
+	// Logger 日志记录器
+	Logger types.Logger
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() *Options {
+	hostname, _ := os.Hostname()
+	return &Options{
+		KeyPrefix:        "scheduler:",
+		Location:         time.Local,
+		LeaseTTL:         15 * time.Second,
+		CampaignInterval: 5 * time.Second,
+		InstanceID:       fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		Logger:           &types.NoopLogger{},
+	}
+}
+
+// WithKeyPrefix 设置键前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = prefix
+	}
+}
+
+// WithLocation 设置时区
+func WithLocation(loc *time.Location) Option {
+	return func(o *Options) {
+		o.Location = loc
+	}
+}
+
+// WithLeaseTTL 设置领导者租约有效时间
+func WithLeaseTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.LeaseTTL = ttl
+	}
+}
+
+// WithCampaignInterval 设置竞选间隔
+func WithCampaignInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.CampaignInterval = interval
+	}
+}
+
+// WithInstanceID 设置实例标识
+func WithInstanceID(id string) Option {
+	return func(o *Options) {
+		o.InstanceID = id
+	}
+}
+
+// WithMetrics 设置业务指标收集器
+func WithMetrics(metrics *collector.BusinessCollector) Option {
+	return func(o *Options) {
+		o.Metrics = metrics
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger types.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// Validate 验证配置
+func (o *Options) Validate() error {
+	if o.LeaseTTL <= 0 {
+		return errors.NewInvalidParamsError("lease ttl must be positive", nil)
+	}
+	if o.CampaignInterval <= 0 {
+		return errors.NewInvalidParamsError("campaign interval must be positive", nil)
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.Logger == nil {
+		o.Logger = &types.NoopLogger{}
+	}
+	return nil
+}
+
+// TaskOption 任务选项函数
+type TaskOption func(*task)
+
+// WithTimeout 设置任务执行超时
+func WithTimeout(timeout time.Duration) TaskOption {
+	return func(t *task) {
+		t.timeout = timeout
+	}
+}
@@ -0,0 +1,443 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/lock"
+	"gobase/pkg/logger/types"
+)
+
+// TaskFunc 定时任务函数
+type TaskFunc func(ctx context.Context) error
+
+// 任务执行状态
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// TaskInfo 任务运行信息
+type TaskInfo struct {
+	Name         string        `json:"name"`
+	Spec         string        `json:"spec"`
+	Leader       string        `json:"leader"`
+	LastRun      time.Time     `json:"last_run"`
+	NextRun      time.Time     `json:"next_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastStatus   string        `json:"last_status"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+// task 已注册的任务
+type task struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       TaskFunc
+	timeout  time.Duration
+}
+
+// saveStateScript 在栅栏令牌不小于已记录值时写入任务状态，防止被取代的领导者覆盖新状态
+// KEYS[1]: 状态键 ARGV[1]: 栅栏令牌 ARGV[2...]: 字段和值
+const saveStateScript = `
+local stored = tonumber(redis.call('HGET', KEYS[1], 'fence') or '0')
+if tonumber(ARGV[1]) < stored then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'fence', ARGV[1], unpack(ARGV, 2))
+return 1
+`
+
+// loadStateScript 读取任务状态
+const loadStateScript = `return redis.call('HGETALL', KEYS[1])`
+
+// Scheduler 分布式定时任务调度器
+// 每个任务通过独立的分布式锁选举领导者，只有领导者执行任务，保证多副本下每次只执行一次
+type Scheduler struct {
+	client redis.Client
+	locker lock.Locker
+	opts   *Options
+
+	mu      sync.Mutex
+	tasks   map[string]*task
+	started bool
+	stop    chan struct{}
+	// runCtx 任务执行使用的上下文，仅在停止超时后取消
+	runCtx    context.Context
+	runCancel context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// New 创建调度器
+func New(client redis.Client, opts ...Option) (*Scheduler, error) {
+	if client == nil {
+		return nil, errors.NewInvalidParamsError("redis client cannot be nil", nil)
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	locker, err := lock.NewLocker(client,
+		lock.WithKeyPrefix(options.KeyPrefix+"leader:"),
+		lock.WithTTL(options.LeaseTTL),
+		lock.WithLogger(options.Logger),
+	)
+	if err != nil {
+		return nil, errors.NewScheduleError("failed to create leader locker", err)
+	}
+
+	return &Scheduler{
+		client: client,
+		locker: locker,
+		opts:   options,
+		tasks:  make(map[string]*task),
+	}, nil
+}
+
+// Register 注册定时任务，必须在 Start 之前调用
+func (s *Scheduler) Register(name, spec string, fn TaskFunc, opts ...TaskOption) error {
+	if name == "" {
+		return errors.NewScheduleError("task name is required", nil)
+	}
+	if fn == nil {
+		return errors.NewScheduleError("task function is required", nil)
+	}
+
+	schedule, err := ParseCron(spec, s.opts.Location)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return errors.NewScheduleError(fmt.Sprintf("cron spec %q never fires", spec), nil)
+	}
+
+	t := &task{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.NewScheduleError("cannot register task after scheduler started", nil)
+	}
+	if _, exists := s.tasks[name]; exists {
+		return errors.NewScheduleError(fmt.Sprintf("task %q already registered", name), nil)
+	}
+	s.tasks[name] = t
+	return nil
+}
+
+// Start 启动调度器，立即返回
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.NewScheduleError("scheduler already started", nil)
+	}
+	s.started = true
+	s.stop = make(chan struct{})
+	s.runCtx, s.runCancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.campaign(t)
+	}
+
+	s.opts.Logger.Info(ctx, "scheduler started",
+		types.Field{Key: "tasks", Value: len(s.tasks)},
+		types.Field{Key: "instance", Value: s.opts.InstanceID},
+	)
+	return nil
+}
+
+// Stop 停止调度器并释放领导权，等待执行中的任务完成，ctx 结束时取消执行中的任务
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.runCancel()
+		return nil
+	case <-ctx.Done():
+		s.runCancel()
+		<-done
+		return errors.NewScheduleError("scheduler stop timed out", ctx.Err())
+	}
+}
+
+// Info 获取任务运行信息
+func (s *Scheduler) Info(ctx context.Context, name string) (*TaskInfo, error) {
+	s.mu.Lock()
+	t, ok := s.tasks[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewScheduleError(fmt.Sprintf("task %q not registered", name), nil)
+	}
+
+	state, err := s.loadState(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TaskInfo{
+		Name:       name,
+		Spec:       t.spec,
+		Leader:     state["leader"],
+		LastStatus: state["last_status"],
+		LastError:  state["last_error"],
+		LastRun:    parseMillis(state["last_run"]),
+		NextRun:    parseMillis(state["next_run"]),
+	}
+	if ms, err := strconv.ParseInt(state["last_duration"], 10, 64); err == nil {
+		info.LastDuration = time.Duration(ms) * time.Millisecond
+	}
+	return info, nil
+}
+
+// campaign 竞选任务领导者，成为领导者后负责调度该任务
+func (s *Scheduler) campaign(t *task) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		lk, err := s.locker.TryLock(s.runCtx, t.name)
+		if err != nil {
+			if !errors.HasErrorCode(err, codes.LockNotAcquired) {
+				s.opts.Logger.Warn(s.runCtx, "failed to campaign for task leadership",
+					types.Field{Key: "task", Value: t.name},
+					types.Error(err),
+				)
+			}
+			if !s.sleep(s.opts.CampaignInterval) {
+				return
+			}
+			continue
+		}
+
+		s.opts.Logger.Info(s.runCtx, "became task leader",
+			types.Field{Key: "task", Value: t.name},
+			types.Field{Key: "instance", Value: s.opts.InstanceID},
+		)
+		s.lead(t, lk)
+
+		unlockCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := lk.Unlock(unlockCtx); err != nil && !errors.HasErrorCode(err, codes.LockNotHeld) {
+			s.opts.Logger.Warn(unlockCtx, "failed to release task leadership",
+				types.Field{Key: "task", Value: t.name},
+				types.Error(err),
+			)
+		}
+		cancel()
+	}
+}
+
+// lead 作为领导者循环调度任务，直到停止或失去领导权
+func (s *Scheduler) lead(t *task, lk lock.Lock) {
+	// 从共享状态恢复上次执行时间，避免领导者切换后重复执行同一个时间点
+	var lastRun time.Time
+	if state, err := s.loadState(s.runCtx, t.name); err == nil {
+		lastRun = parseMillis(state["last_run"])
+	}
+
+	for {
+		base := time.Now()
+		if lastRun.After(base) {
+			base = lastRun
+		}
+		next := t.schedule.Next(base)
+		if next.IsZero() {
+			s.opts.Logger.Error(s.runCtx, "task has no next run time", types.Field{Key: "task", Value: t.name})
+			return
+		}
+
+		s.saveState(t.name, lk.FencingToken(),
+			"leader", s.opts.InstanceID,
+			"spec", t.spec,
+			"next_run", next.UnixMilli(),
+		)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-lk.Lost():
+			timer.Stop()
+			s.opts.Logger.Warn(s.runCtx, "lost task leadership", types.Field{Key: "task", Value: t.name})
+			return
+		case <-timer.C:
+		}
+
+		s.execute(t, lk, next)
+		lastRun = next
+	}
+}
+
+// execute 执行一次任务并记录结果
+func (s *Scheduler) execute(t *task, lk lock.Lock, scheduledAt time.Time) {
+	ctx, cancel := context.WithCancel(s.runCtx)
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.runCtx, t.timeout)
+	}
+	defer cancel()
+
+	// 失去领导权时取消执行，避免与新的领导者并发执行
+	go func() {
+		select {
+		case <-lk.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	start := time.Now()
+	err := s.call(ctx, t)
+	duration := time.Since(start)
+
+	status := StatusSuccess
+	lastError := ""
+	if err != nil {
+		status = StatusFailed
+		lastError = err.Error()
+		s.opts.Logger.Error(ctx, "scheduled task failed",
+			types.Field{Key: "task", Value: t.name},
+			types.Field{Key: "duration", Value: duration},
+			types.Error(err),
+		)
+	} else {
+		s.opts.Logger.Info(ctx, "scheduled task completed",
+			types.Field{Key: "task", Value: t.name},
+			types.Field{Key: "duration", Value: duration},
+		)
+	}
+
+	if s.opts.Metrics != nil {
+		var observed error
+		if err != nil {
+			observed = metricError(errors.GetErrorCode(err))
+		}
+		s.opts.Metrics.ObserveOperation("scheduler_"+t.name, duration.Seconds(), observed)
+	}
+
+	s.saveState(t.name, lk.FencingToken(),
+		"last_run", scheduledAt.UnixMilli(),
+		"last_duration", duration.Milliseconds(),
+		"last_status", status,
+		"last_error", lastError,
+	)
+}
+
+// call 调用任务函数，并将 panic 转换为错误
+func (s *Scheduler) call(ctx context.Context, t *task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.NewExecutionError(fmt.Sprintf("task %s panicked: %v", t.name, r), nil)
+		}
+	}()
+
+	if err := t.fn(ctx); err != nil {
+		return errors.NewExecutionError(fmt.Sprintf("task %s failed", t.name), err)
+	}
+	return nil
+}
+
+// saveState 保存任务状态，失败时只记录日志
+func (s *Scheduler) saveState(name string, fence int64, fields ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	args := append([]interface{}{fence}, fields...)
+	if _, err := s.client.Eval(ctx, saveStateScript, []string{s.stateKey(name)}, args...); err != nil {
+		s.opts.Logger.Warn(ctx, "failed to save task state",
+			types.Field{Key: "task", Value: name},
+			types.Error(err),
+		)
+	}
+}
+
+// loadState 读取任务状态
+func (s *Scheduler) loadState(ctx context.Context, name string) (map[string]string, error) {
+	result, err := s.client.Eval(ctx, loadStateScript, []string{s.stateKey(name)})
+	if err != nil {
+		return nil, errors.NewScheduleError("failed to load task state", err)
+	}
+
+	values, _ := result.([]interface{})
+	state := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		key, _ := values[i].(string)
+		value, _ := values[i+1].(string)
+		state[key] = value
+	}
+	return state, nil
+}
+
+// stateKey 返回任务状态键
+func (s *Scheduler) stateKey(name string) string {
+	return s.opts.KeyPrefix + "task:" + name
+}
+
+// sleep 等待指定时间，收到停止信号时返回 false
+func (s *Scheduler) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// parseMillis 将毫秒时间戳字符串转换为时间
+func parseMillis(value string) time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// metricError 记录到指标中的错误，只保留错误码以避免标签基数过高
+type metricError string
+
+// Error 实现 error 接口
+func (e metricError) Error() string {
+	return string(e)
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/scheduler"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, time.January, 15, 10, 30, 15, 0, time.UTC) // 周一
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", base, time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"every second", "* * * * * *", base, time.Date(2024, 1, 15, 10, 30, 16, 0, time.UTC)},
+		{"step minutes", "*/15 * * * *", base, time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"fixed time next day", "0 3 * * *", base, time.Date(2024, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{"range and list", "0 9-17/4 * * 1,3", base, time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"weekday names", "0 8 * * SAT", base, time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", base, time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"month names", "0 0 1 mar *", base, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", base, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 1 * 5", base, time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"daily descriptor", "@daily", base, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"hourly descriptor", "@hourly", base, time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"monthly descriptor", "@monthly", base, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"every interval", "@every 90s", base, time.Date(2024, 1, 15, 10, 31, 45, 0, time.UTC)},
+		{"year wrap", "0 0 1 1 *", time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := scheduler.ParseCron(tt.spec, time.UTC)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(tt.from))
+		})
+	}
+}
+
+func TestParseCronLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	schedule, err := scheduler.ParseCron("0 2 * * *", loc)
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@never",
+		"@every -1s",
+		"@every abc",
+	}
+
+	for _, spec := range specs {
+		_, err := scheduler.ParseCron(spec, time.UTC)
+		assert.True(t, errors.HasErrorCode(err, codes.ScheduleError), spec)
+	}
+}
+
+func TestParseCronNeverFires(t *testing.T) {
+	schedule, err := scheduler.ParseCron("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
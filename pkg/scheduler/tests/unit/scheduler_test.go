@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gobase/pkg/client/redis"
+	"gobase/pkg/errors"
+	"gobase/pkg/errors/codes"
+	"gobase/pkg/scheduler"
+)
+
+// newTestClient 创建连接到 miniredis 的客户端
+func newTestClient(t *testing.T, mr *miniredis.Miniredis) redis.Client {
+	client, err := redis.NewClient(
+		redis.WithAddress(mr.Addr()),
+		redis.WithPoolSize(2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// runRecorder 记录任务执行的计划时间
+type runRecorder struct {
+	mu   sync.Mutex
+	runs map[string][]time.Time
+}
+
+func (r *runRecorder) record(instance string) scheduler.TaskFunc {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.runs[instance] = append(r.runs[instance], time.Now())
+		return nil
+	}
+}
+
+func (r *runRecorder) count(instance string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.runs[instance])
+}
+
+func TestSchedulerRunsOnceAcrossReplicas(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	recorder := &runRecorder{runs: make(map[string][]time.Time)}
+
+	replicas := make([]*scheduler.Scheduler, 2)
+	for i, id := range []string{"a", "b"} {
+		s, err := scheduler.New(newTestClient(t, mr),
+			scheduler.WithInstanceID(id),
+			scheduler.WithCampaignInterval(20*time.Millisecond),
+		)
+		require.NoError(t, err)
+		require.NoError(t, s.Register("cleanup", "@every 50ms", recorder.record(id)))
+		replicas[i] = s
+	}
+
+	for _, s := range replicas {
+		require.NoError(t, s.Start(ctx))
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	// 只有一个副本成为领导者并执行任务
+	a, b := recorder.count("a"), recorder.count("b")
+	assert.True(t, (a > 0) != (b > 0), "expected exactly one leader, got a=%d b=%d", a, b)
+
+	leader, follower := replicas[0], replicas[1]
+	followerID := "b"
+	if b > 0 {
+		leader, follower = replicas[1], replicas[0]
+		followerID = "a"
+	}
+
+	info, err := leader.Info(ctx, "cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusSuccess, info.LastStatus)
+	assert.False(t, info.LastRun.IsZero())
+	assert.True(t, info.NextRun.After(info.LastRun))
+
+	// 领导者停止后由其他副本接管
+	require.NoError(t, leader.Stop(ctx))
+	assert.Eventually(t, func() bool {
+		return recorder.count(followerID) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, follower.Stop(ctx))
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	s, err := scheduler.New(newTestClient(t, mr))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Register("report", "@every 30ms", func(ctx context.Context) error {
+		return errors.NewSystemError("downstream unavailable", nil)
+	}))
+	require.NoError(t, s.Register("panic", "@every 30ms", func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(ctx)
+
+	for _, name := range []string{"report", "panic"} {
+		assert.Eventually(t, func() bool {
+			info, err := s.Info(ctx, name)
+			return err == nil && info.LastStatus == scheduler.StatusFailed
+		}, time.Second, 10*time.Millisecond, name)
+
+		info, err := s.Info(ctx, name)
+		require.NoError(t, err)
+		assert.Contains(t, info.LastError, "code="+codes.ExecutionError)
+	}
+}
+
+func TestSchedulerRegister(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	s, err := scheduler.New(newTestClient(t, mr))
+	require.NoError(t, err)
+
+	noop := func(ctx context.Context) error { return nil }
+
+	require.NoError(t, s.Register("warmup", "*/5 * * * *", noop, scheduler.WithTimeout(time.Minute)))
+
+	err = s.Register("warmup", "*/5 * * * *", noop)
+	assert.True(t, errors.HasErrorCode(err, codes.ScheduleError))
+
+	err = s.Register("invalid", "not a cron", noop)
+	assert.True(t, errors.HasErrorCode(err, codes.ScheduleError))
+
+	err = s.Register("never", "0 0 30 2 *", noop)
+	assert.True(t, errors.HasErrorCode(err, codes.ScheduleError))
+
+	_, err = s.Info(context.Background(), "missing")
+	assert.True(t, errors.HasErrorCode(err, codes.ScheduleError))
+}